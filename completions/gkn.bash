@@ -4,7 +4,7 @@ _gkn_complete() {
   prev="${COMP_WORDS[COMP_CWORD-1]}"
 
   if [[ ${COMP_CWORD} -eq 1 ]]; then
-    COMPREPLY=( $(compgen -W "help cd shell repo skills config doctor version clone quickstart" -- "$cur") )
+    COMPREPLY=( $(compgen -W "help cd shell repo log grep skills config doctor version clone quickstart" -- "$cur") )
     return 0
   fi
 
@@ -14,11 +14,11 @@ _gkn_complete() {
       return 0
       ;;
     repo)
-      COMPREPLY=( $(compgen -W "list status cd open path recent info graph clone exec" -- "$cur") )
+      COMPREPLY=( $(compgen -W "list status cd open path recent info graph clone exec du gc discover remote duplicates" -- "$cur") )
       return 0
       ;;
     skills)
-      COMPREPLY=( $(compgen -W "clone sync link watch diff verify status pin clean" -- "$cur") )
+      COMPREPLY=( $(compgen -W "clone list lint sync link watch promote diff verify status pin lock install rollback clean prune" -- "$cur") )
       return 0
       ;;
     config)
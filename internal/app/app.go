@@ -43,6 +43,10 @@ func (a App) Run(ctx context.Context, args []string) int {
 		return a.runRepoClone(ctx, args[1:])
 	case "quickstart":
 		return a.runQuickstart(ctx, args[1:])
+	case "log":
+		return a.runLog(ctx, args[1:])
+	case "grep":
+		return a.runGrep(ctx, args[1:])
 	case "skills":
 		return a.runSkills(ctx, args[1:])
 	case "config":
@@ -67,6 +71,8 @@ Commands:
   clone <url> [--name repo]
   quickstart <name> [--public|--private]
   repo      repo operations
+  log [--since 7d] [--author me] [--format table|markdown|json] [--group-by repo|author]
+  grep <pattern> [--glob glob] [--parallel n] [--limit n]
   skills    skills sync operations
   config    config operations
   doctor    environment checks
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TT-AIXion/github-kanri/internal/output"
+)
+
+func TestGrepCommand(t *testing.T) {
+	app, cfg := newTestApp(t)
+	alpha := initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	beta := initGitRepo(t, filepath.Join(cfg.ReposRoot, "beta"), true)
+	_ = os.WriteFile(filepath.Join(alpha, "main.go"), []byte("package main\n// TODO one\n// TODO two\n"), 0o644)
+	_ = os.WriteFile(filepath.Join(beta, "notes.md"), []byte("TODO docs\n"), 0o644)
+	_ = runGit(alpha, "add", ".")
+	_ = runGit(beta, "add", ".")
+
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.Run(context.Background(), []string{"grep", "TODO", "--parallel", "2"}); code != 0 {
+		t.Fatalf("grep failed: %s", out.String())
+	}
+	if !strings.Contains(out.String(), "alpha:main.go:2:// TODO one") || !strings.Contains(out.String(), "beta:notes.md:1:TODO docs") {
+		t.Fatalf("unexpected grep output: %s", out.String())
+	}
+	out.Reset()
+	if code := app.runGrep(context.Background(), []string{"todo", "--ignore-case", "--glob", "*.go"}); code != 0 {
+		t.Fatalf("grep glob failed")
+	}
+	if strings.Contains(out.String(), "beta") {
+		t.Fatalf("expected glob to filter: %s", out.String())
+	}
+	out.Reset()
+	if code := app.runGrep(context.Background(), []string{"TODO", "--limit", "1", "--parallel", "0"}); code != 0 {
+		t.Fatalf("grep limit failed")
+	}
+	if !strings.Contains(out.String(), "truncated") {
+		t.Fatalf("expected truncation: %s", out.String())
+	}
+	out.Reset()
+	if code := app.runGrep(context.Background(), []string{"TODO", "--max-per-repo", "1", "--only", "alpha"}); code != 0 {
+		t.Fatalf("grep max-per-repo failed")
+	}
+	if strings.Count(out.String(), "alpha:") != 1 {
+		t.Fatalf("expected one alpha match: %s", out.String())
+	}
+	out.Reset()
+	if code := app.runGrep(context.Background(), []string{"nothing-here", "--fixed-strings"}); code != 0 {
+		t.Fatalf("grep no match failed")
+	}
+	if !strings.Contains(out.String(), "no matches") {
+		t.Fatalf("expected no matches: %s", out.String())
+	}
+
+	appJSON := app
+	appJSON.Out.JSON = true
+	out.Reset()
+	if code := appJSON.runGrep(context.Background(), []string{"TODO"}); code != 0 {
+		t.Fatalf("grep json failed")
+	}
+	if !strings.Contains(out.String(), `"truncated":false`) {
+		t.Fatalf("unexpected json: %s", out.String())
+	}
+
+	cfg.DenyPaths = []string{filepath.ToSlash(beta)}
+	writeConfig(t, cfg)
+	if code := app.runGrep(context.Background(), []string{"TODO"}); code == 0 {
+		t.Fatalf("expected guard error")
+	}
+}
+
+func TestGrepErrors(t *testing.T) {
+	app, _ := newTestApp(t)
+	if code := app.runGrep(context.Background(), []string{"--bad"}); code == 0 {
+		t.Fatalf("expected parse error")
+	}
+	if code := app.runGrep(context.Background(), []string{}); code == 0 {
+		t.Fatalf("expected pattern error")
+	}
+	if code := app.runGrep(context.Background(), []string{"a", "b"}); code == 0 {
+		t.Fatalf("expected argument error")
+	}
+}
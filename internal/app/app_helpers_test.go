@@ -1,6 +1,7 @@
 package app
 
 import (
+	"flag"
 	"os"
 	"testing"
 
@@ -52,3 +53,19 @@ func TestLoadConfigExpandError(t *testing.T) {
 		t.Fatalf("expected expand error")
 	}
 }
+
+func TestParseInterspersed(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	limit := fs.Int("limit", 0, "limit")
+	verbose := fs.Bool("verbose", false, "verbose")
+	positional, err := parseInterspersed(fs, []string{"a", "--limit", "2", "b", "--verbose"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(positional) != 2 || positional[0] != "a" || positional[1] != "b" || *limit != 2 || !*verbose {
+		t.Fatalf("unexpected parse: %v %d %v", positional, *limit, *verbose)
+	}
+	if _, err := parseInterspersed(fs, []string{"a", "--bad"}); err == nil {
+		t.Fatalf("expected parse error")
+	}
+}
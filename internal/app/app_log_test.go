@@ -0,0 +1,134 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TT-AIXion/github-kanri/internal/output"
+)
+
+func TestLogCommand(t *testing.T) {
+	app, cfg := newTestApp(t)
+	_ = initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	_ = initGitRepo(t, filepath.Join(cfg.ReposRoot, "beta"), true)
+	_ = initGitRepo(t, filepath.Join(cfg.ReposRoot, "empty"), false)
+
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.Run(context.Background(), []string{"log", "--since", "1d"}); code != 0 {
+		t.Fatalf("log failed: %s", out.String())
+	}
+	if !strings.Contains(out.String(), "alpha") || !strings.Contains(out.String(), "beta") {
+		t.Fatalf("expected both repos: %s", out.String())
+	}
+	out.Reset()
+	if code := app.runLog(context.Background(), []string{"--author", "me", "--format", "markdown"}); code != 0 {
+		t.Fatalf("log markdown failed")
+	}
+	if !strings.Contains(out.String(), "## ") || !strings.Contains(out.String(), "**alpha**") {
+		t.Fatalf("unexpected markdown: %s", out.String())
+	}
+	out.Reset()
+	if code := app.runLog(context.Background(), []string{"--group-by", "repo"}); code != 0 {
+		t.Fatalf("log group failed")
+	}
+	if !strings.Contains(out.String(), "COMMITS") {
+		t.Fatalf("unexpected group output: %s", out.String())
+	}
+	out.Reset()
+	if code := app.runLog(context.Background(), []string{"--group-by", "author", "--format", "markdown"}); code != 0 {
+		t.Fatalf("log group markdown failed")
+	}
+	if !strings.Contains(out.String(), "| Tester | 2 |") {
+		t.Fatalf("unexpected author counts: %s", out.String())
+	}
+	out.Reset()
+	if code := app.runLog(context.Background(), []string{"--format", "json", "--limit", "1"}); code != 0 {
+		t.Fatalf("log json failed")
+	}
+	if !strings.Contains(out.String(), `"byRepo"`) {
+		t.Fatalf("unexpected json: %s", out.String())
+	}
+	out.Reset()
+	if code := app.runLog(context.Background(), []string{"--author", "nobody"}); code != 0 {
+		t.Fatalf("log empty failed")
+	}
+	if !strings.Contains(out.String(), "no commits") {
+		t.Fatalf("expected no commits: %s", out.String())
+	}
+}
+
+func TestLogReportsRepoErrors(t *testing.T) {
+	app, cfg := newTestApp(t)
+	_ = initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	_ = initGitRepo(t, filepath.Join(cfg.ReposRoot, "empty"), false)
+	_ = os.MkdirAll(filepath.Join(cfg.ReposRoot, "broken", ".git"), 0o755)
+
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runLog(context.Background(), nil); code != exitPartial {
+		t.Fatalf("expected partial failure: %d %s", code, out.String())
+	}
+	if !strings.Contains(out.String(), "ERR broken:") || strings.Contains(out.String(), "empty:") || !strings.Contains(out.String(), "alpha") {
+		t.Fatalf("expected broken repo reported: %s", out.String())
+	}
+	out.Reset()
+	if code := app.runLog(context.Background(), []string{"--format", "json"}); code != exitPartial || !strings.Contains(out.String(), `"errors":[{"repo":"broken"`) {
+		t.Fatalf("expected json errors: %d %s", code, out.String())
+	}
+}
+
+func TestLogAuthorMeWithoutEmail(t *testing.T) {
+	app, cfg := newTestApp(t)
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(t.TempDir(), "gitconfig"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	_ = initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	anon := initGitRepo(t, filepath.Join(cfg.ReposRoot, "anon"), true)
+	_ = runGit(anon, "config", "--unset", "user.email")
+
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runLog(context.Background(), []string{"--author", "me"}); code != exitPartial {
+		t.Fatalf("expected partial failure: %d %s", code, out.String())
+	}
+	if !strings.Contains(out.String(), "ERR anon: --author me: user.email not set") || !strings.Contains(out.String(), "alpha") {
+		t.Fatalf("expected missing email reported: %s", out.String())
+	}
+	out.Reset()
+	if code := app.runLog(context.Background(), []string{"--author", "me", "--format", "json"}); code != exitPartial || !strings.Contains(out.String(), `"errors":[{"repo":"anon"`) {
+		t.Fatalf("expected json error: %d %s", code, out.String())
+	}
+}
+
+func TestLogErrors(t *testing.T) {
+	app, _ := newTestApp(t)
+	cases := [][]string{
+		{"--bad"},
+		{"--format", "csv"},
+		{"--group-by", "day"},
+	}
+	for _, args := range cases {
+		if code := app.runLog(context.Background(), args); code == 0 {
+			t.Fatalf("expected error: %v", args)
+		}
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	cases := map[string]string{
+		"7d":         "7 days ago",
+		"24h":        "24 hours ago",
+		"2w":         "2 weeks ago",
+		"2026-01-01": "2026-01-01",
+		"":           "",
+	}
+	for in, want := range cases {
+		if got := parseSince(in); got != want {
+			t.Fatalf("parseSince(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TT-AIXion/github-kanri/internal/output"
+)
+
+func TestRepoDiscoverMove(t *testing.T) {
+	app, cfg := newTestApp(t)
+	_ = initGitRepo(t, filepath.Join(cfg.ReposRoot, "managed"), true)
+	elsewhere := filepath.Join(cfg.ProjectsRoot, "Downloads")
+	stray := initGitRepo(t, filepath.Join(elsewhere, "stray"), true)
+	_ = initGitRepo(t, filepath.Join(elsewhere, "nested", "other"), true)
+
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runRepo(context.Background(), []string{"discover", elsewhere, cfg.ReposRoot}); code != 0 {
+		t.Fatalf("discover failed: %s", out.String())
+	}
+	if !strings.Contains(out.String(), "found stray") || strings.Contains(out.String(), "managed") {
+		t.Fatalf("unexpected discover output: %s", out.String())
+	}
+	out.Reset()
+	if code := app.runRepoDiscover(context.Background(), []string{elsewhere, "--move", "--dry-run"}); code != 0 {
+		t.Fatalf("discover dry-run failed")
+	}
+	if !strings.Contains(out.String(), "would-move stray") {
+		t.Fatalf("unexpected dry-run output: %s", out.String())
+	}
+	if _, err := os.Stat(stray); err != nil {
+		t.Fatalf("dry-run moved repo")
+	}
+
+	_ = os.MkdirAll(filepath.Join(cfg.ReposRoot, "other"), 0o755)
+	appJSON := app
+	appJSON.Out.JSON = true
+	out.Reset()
+	if code := appJSON.runRepoDiscover(context.Background(), []string{elsewhere, "--move"}); code != 0 {
+		t.Fatalf("discover move failed")
+	}
+	if !strings.Contains(out.String(), `"moved"`) || !strings.Contains(out.String(), "destination exists") {
+		t.Fatalf("unexpected move output: %s", out.String())
+	}
+	if !dirExists(filepath.Join(cfg.ReposRoot, "stray", ".git")) {
+		t.Fatalf("expected stray moved into reposRoot")
+	}
+
+	out.Reset()
+	if code := app.runRepoDiscover(context.Background(), []string{filepath.Join(cfg.ProjectsRoot, "empty-nothing")}); code == 0 {
+		t.Fatalf("expected missing dir error")
+	}
+	_ = os.MkdirAll(filepath.Join(cfg.ProjectsRoot, "empty"), 0o755)
+	out.Reset()
+	if code := app.runRepoDiscover(context.Background(), []string{filepath.Join(cfg.ProjectsRoot, "empty")}); code != 0 {
+		t.Fatalf("discover empty failed")
+	}
+	if !strings.Contains(out.String(), "no unmanaged repos") {
+		t.Fatalf("expected none found: %s", out.String())
+	}
+}
+
+func TestRepoDiscoverClone(t *testing.T) {
+	app, cfg := newTestApp(t)
+	bare := initBareRepo(t, filepath.Join(cfg.ProjectsRoot, "upstream.git"))
+	seedBareRepo(t, bare)
+	elsewhere := filepath.Join(cfg.ProjectsRoot, "work")
+	withOrigin := initGitRepo(t, filepath.Join(elsewhere, "local-copy"), true)
+	_ = runGit(withOrigin, "remote", "add", "origin", bare)
+	_ = initGitRepo(t, filepath.Join(elsewhere, "no-origin"), true)
+	dirty := initGitRepo(t, filepath.Join(elsewhere, "dirty"), true)
+	_ = runGit(dirty, "remote", "add", "origin", bare+"x")
+	_ = os.WriteFile(filepath.Join(dirty, "a.txt"), []byte("changed"), 0o644)
+
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runRepoDiscover(context.Background(), []string{elsewhere, "--clone", "--dry-run"}); code != 0 {
+		t.Fatalf("clone dry-run failed: %s", out.String())
+	}
+	if !strings.Contains(out.String(), "would-clone local-copy") || !strings.Contains(out.String(), "no origin") || !strings.Contains(out.String(), "dirty (use --force)") {
+		t.Fatalf("unexpected dry-run output: %s", out.String())
+	}
+	if code := app.runRepoDiscover(context.Background(), []string{elsewhere, "--clone"}); code != 0 {
+		t.Fatalf("clone failed: %s", out.String())
+	}
+	if !dirExists(filepath.Join(cfg.ReposRoot, "upstream", ".git")) {
+		t.Fatalf("expected clone into reposRoot")
+	}
+	if !dirExists(filepath.Join(withOrigin, ".git")) {
+		t.Fatalf("expected original kept")
+	}
+	if code := app.runRepoDiscover(context.Background(), []string{elsewhere, "--clone", "--force", "--only-missing"}); code == 0 {
+		t.Fatalf("expected parse error")
+	}
+	out.Reset()
+	if code := app.runRepoDiscover(context.Background(), []string{dirty, "--clone", "--force"}); code == 0 {
+		t.Fatalf("expected clone error: %s", out.String())
+	}
+}
+
+func TestRepoDiscoverErrors(t *testing.T) {
+	app, cfg := newTestApp(t)
+	if code := app.runRepoDiscover(context.Background(), []string{}); code == 0 {
+		t.Fatalf("expected dir error")
+	}
+	if code := app.runRepoDiscover(context.Background(), []string{"x", "--move", "--clone"}); code == 0 {
+		t.Fatalf("expected exclusive error")
+	}
+	stray := initGitRepo(t, filepath.Join(cfg.ProjectsRoot, "else", "stray"), false)
+	cfg.DenyPaths = []string{filepath.ToSlash(filepath.Join(cfg.ReposRoot, "stray"))}
+	writeConfig(t, cfg)
+	if code := app.runRepoDiscover(context.Background(), []string{filepath.Dir(stray), "--move"}); code != 0 {
+		t.Fatalf("expected guard skip")
+	}
+	cfg.DenyPaths = []string{filepath.ToSlash(stray)}
+	writeConfig(t, cfg)
+	if code := app.runRepoDiscover(context.Background(), []string{filepath.Dir(stray), "--move"}); code != 0 {
+		t.Fatalf("expected guard skip")
+	}
+	cfg.DenyPaths = nil
+	writeConfig(t, cfg)
+	orig := moveDir
+	moveDir = func(string, string) error { return errors.New("cross-device") }
+	defer func() { moveDir = orig }()
+	if code := app.runRepoDiscover(context.Background(), []string{filepath.Dir(stray), "--move"}); code == 0 {
+		t.Fatalf("expected rename error")
+	}
+}
+
+func TestIsUnder(t *testing.T) {
+	if !isUnder("/a/b/c", "/a/b") || !isUnder("/a/b", "/a/b") {
+		t.Fatalf("expected under")
+	}
+	if isUnder("/a/bc", "/a/b") || isUnder("/a", "/a/b") || isUnder("/a", "") {
+		t.Fatalf("expected not under")
+	}
+}
+
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,122 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TT-AIXion/github-kanri/internal/output"
+)
+
+func TestRepoDuAndGc(t *testing.T) {
+	app, cfg := newTestApp(t)
+	alpha := initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	beta := initGitRepo(t, filepath.Join(cfg.ReposRoot, "beta"), true)
+	_ = os.MkdirAll(filepath.Join(beta, "target", "debug"), 0o755)
+	_ = os.WriteFile(filepath.Join(beta, "target", "debug", "app"), bytes.Repeat([]byte("x"), 1024), 0o644)
+	_ = os.WriteFile(filepath.Join(alpha, ".gitignore"), []byte("node_modules/\n*.log\n"), 0o644)
+	_ = os.MkdirAll(filepath.Join(alpha, "node_modules", "pkg"), 0o755)
+	_ = os.WriteFile(filepath.Join(alpha, "node_modules", "pkg", "index.js"), bytes.Repeat([]byte("x"), 4096), 0o644)
+	_ = os.WriteFile(filepath.Join(alpha, "debug.log"), []byte("log"), 0o644)
+
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runRepo(context.Background(), []string{"du"}); code != 0 {
+		t.Fatalf("du failed: %s", out.String())
+	}
+	lines := strings.Split(out.String(), "\n")
+	if len(lines) < 3 || !strings.HasSuffix(lines[1], "alpha") {
+		t.Fatalf("expected alpha first: %s", out.String())
+	}
+	appJSON := app
+	appJSON.Out.JSON = true
+	out.Reset()
+	if code := appJSON.runRepoDu(context.Background(), []string{"--sort", "artifacts", "--limit", "1"}); code != 0 {
+		t.Fatalf("du json failed")
+	}
+	if !strings.Contains(out.String(), `"node_modules"`) || strings.Contains(out.String(), "beta") {
+		t.Fatalf("unexpected du json: %s", out.String())
+	}
+	out.Reset()
+	if code := appJSON.runRepoDu(context.Background(), []string{"--only", "beta"}); code != 0 {
+		t.Fatalf("du json failed")
+	}
+	if !strings.Contains(out.String(), `"artifactPaths":["target"]`) || strings.Contains(out.String(), `"artifacts":0`) {
+		t.Fatalf("expected untracked target measured: %s", out.String())
+	}
+
+	if code := app.runRepo(context.Background(), []string{"gc"}); code == 0 {
+		t.Fatalf("expected force error")
+	}
+	out.Reset()
+	if code := app.runRepoGc(context.Background(), []string{"--dry-run", "--only", "alpha"}); code != 0 {
+		t.Fatalf("gc dry-run failed")
+	}
+	if !strings.Contains(out.String(), "would remove 2 paths") {
+		t.Fatalf("unexpected dry-run output: %s", out.String())
+	}
+	if _, err := os.Stat(filepath.Join(alpha, "node_modules")); err != nil {
+		t.Fatalf("dry-run removed files")
+	}
+	out.Reset()
+	if code := app.runRepoGc(context.Background(), []string{"--dry-run", "--only", "beta"}); code != 0 || !strings.Contains(out.String(), "would remove 0 paths (0B)") {
+		t.Fatalf("gc must only remove ignored paths: %s", out.String())
+	}
+	if code := appJSON.runRepoGc(context.Background(), []string{"--force", "--no-clean", "--only", "beta"}); code != 0 {
+		t.Fatalf("gc no-clean failed")
+	}
+	if code := app.runRepoGc(context.Background(), []string{"--force"}); code != 0 {
+		t.Fatalf("gc failed")
+	}
+	if _, err := os.Stat(filepath.Join(alpha, "node_modules")); !os.IsNotExist(err) {
+		t.Fatalf("expected node_modules removed")
+	}
+
+	cfg.DenyPaths = []string{filepath.ToSlash(alpha)}
+	writeConfig(t, cfg)
+	if code := app.runRepoGc(context.Background(), []string{"--force"}); code == 0 {
+		t.Fatalf("expected guard error")
+	}
+	cfg.DenyPaths = nil
+	cfg.AllowCommands = []string{"git gc*"}
+	writeConfig(t, cfg)
+	if code := app.runRepoDu(context.Background(), []string{}); code == 0 {
+		t.Fatalf("expected du command error")
+	}
+	if code := appJSON.runRepoDu(context.Background(), []string{}); code == 0 {
+		t.Fatalf("expected du json command error")
+	}
+}
+
+func TestRepoDuErrors(t *testing.T) {
+	app, _ := newTestApp(t)
+	cases := [][]string{
+		{"du", "--bad"},
+		{"du", "--sort", "name"},
+		{"gc", "--bad"},
+	}
+	for _, args := range cases {
+		if code := app.runRepo(context.Background(), args); code == 0 {
+			t.Fatalf("expected error: %v", args)
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	cases := map[int64]string{
+		0:                  "0B",
+		1023:               "1023B",
+		1024:               "1.0KiB",
+		5 * 1024 * 1024:    "5.0MiB",
+		3 << 30:            "3.0GiB",
+		1536 * 1024 * 1024: "1.5GiB",
+	}
+	for in, want := range cases {
+		if got := formatBytes(in); got != want {
+			t.Fatalf("formatBytes(%d) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TT-AIXion/github-kanri/internal/output"
+)
+
+func TestRepoDuplicates(t *testing.T) {
+	app, cfg := newTestApp(t)
+	bare := initBareRepo(t, filepath.Join(cfg.ProjectsRoot, "tool.git"))
+	seedBareRepo(t, bare)
+	first := filepath.Join(cfg.ReposRoot, "tool")
+	second := filepath.Join(cfg.ReposRoot, "old", "tool-copy")
+	if err := runGit(cfg.ProjectsRoot, "clone", bare, first); err != nil {
+		t.Fatalf("clone: %v", err)
+	}
+	if err := runGit(cfg.ProjectsRoot, "clone", bare, second); err != nil {
+		t.Fatalf("clone: %v", err)
+	}
+	one := initGitRepo(t, filepath.Join(cfg.ReposRoot, "a", "api"), true)
+	two := initGitRepo(t, filepath.Join(cfg.ReposRoot, "b", "api"), true)
+	_ = runGit(one, "remote", "add", "origin", "git@github.com:acme/api.git")
+	_ = runGit(two, "remote", "add", "origin", "https://github.com/other/api.git")
+
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runRepo(context.Background(), []string{"duplicates"}); code != 0 {
+		t.Fatalf("duplicates failed: %s", out.String())
+	}
+	text := out.String()
+	if !strings.Contains(text, "origin "+bare+" (identical clones)") {
+		t.Fatalf("expected origin group: %s", text)
+	}
+	if !strings.Contains(text, "* "+first) {
+		t.Fatalf("expected in-layout clone kept: %s", text)
+	}
+	if !strings.Contains(text, "name api (same name, different origins)") {
+		t.Fatalf("expected name group: %s", text)
+	}
+	for _, dir := range []string{"a", "b"} {
+		initGitRepo(t, filepath.Join(cfg.ReposRoot, dir, "notes"), true)
+	}
+	out.Reset()
+	if code := app.runRepoDuplicates(context.Background(), []string{"--only", "notes"}); code != 0 || !strings.Contains(out.String(), "name notes (same name, no origins)") {
+		t.Fatalf("expected no-origin name group: %s", out.String())
+	}
+
+	_ = os.WriteFile(filepath.Join(second, "a.txt"), []byte("local"), 0o644)
+	out.Reset()
+	if code := app.runRepo(context.Background(), []string{"duplicates", "--only", "tool*"}); code != 0 {
+		t.Fatalf("duplicates dirty failed")
+	}
+	if !strings.Contains(out.String(), "WARN origin") || !strings.Contains(out.String(), "* "+second) {
+		t.Fatalf("expected dirty clone kept: %s", out.String())
+	}
+
+	appJSON := app
+	appJSON.Out.JSON = true
+	out.Reset()
+	if code := appJSON.runRepoDuplicates(context.Background(), []string{"--exclude", "api"}); code != 0 {
+		t.Fatalf("json duplicates failed")
+	}
+	if !strings.Contains(out.String(), `"divergent":true`) {
+		t.Fatalf("unexpected json: %s", out.String())
+	}
+	out.Reset()
+	if code := app.runRepoDuplicates(context.Background(), []string{"--only", "api", "--exclude", "api"}); code != 0 {
+		t.Fatalf("empty duplicates failed")
+	}
+	if !strings.Contains(out.String(), "no duplicates") {
+		t.Fatalf("expected no duplicates: %s", out.String())
+	}
+	if code := app.runRepoDuplicates(context.Background(), []string{"--bad"}); code == 0 {
+		t.Fatalf("expected parse error")
+	}
+}
+
+func TestNewDuplicateGroup(t *testing.T) {
+	now := time.Now()
+	ms := []duplicateMember{
+		{Path: "/r/x/a", Head: "1", LastCommit: now.Add(-time.Hour)},
+		{Path: "/r/b", Head: "2", LastCommit: now.Add(-2 * time.Hour)},
+		{Path: "/r/x/c", Head: "2", LastCommit: now},
+	}
+	g := newDuplicateGroup("origin", "k", ms, "/r")
+	if !g.Divergent || !g.Members[1].Keep || !strings.Contains(g.Reason, "different HEADs") {
+		t.Fatalf("expected in-layout clone kept: %+v", g)
+	}
+	ms = []duplicateMember{
+		{Path: "/r/x/a", Head: "1", Ahead: 1},
+		{Path: "/r/x/b", Head: "1", Dirty: true},
+	}
+	g = newDuplicateGroup("origin", "k", ms, "/r")
+	if g.Members[0].Keep || g.Members[1].Keep || !strings.Contains(g.Reason, "merge manually") {
+		t.Fatalf("expected manual merge: %+v", g)
+	}
+	ms = []duplicateMember{
+		{Path: "/r/a", Head: "1", LastCommit: now},
+		{Path: "/r/x/b", Head: "1", NoUpstream: true, LastCommit: now.Add(-time.Hour)},
+	}
+	g = newDuplicateGroup("origin", "k", ms, "/r")
+	if !g.Divergent || g.Members[0].Keep || !g.Members[1].Keep || !strings.Contains(g.Reason, "without an upstream") {
+		t.Fatalf("expected clone without upstream kept: %+v", g)
+	}
+	ms = []duplicateMember{
+		{Path: "/r/a", Head: "1", Ahead: 1},
+		{Path: "/r/x/b", Head: "1", NoUpstream: true},
+	}
+	g = newDuplicateGroup("origin", "k", ms, "/r")
+	if g.Members[0].Keep || g.Members[1].Keep || !strings.Contains(g.Reason, "untracked work") {
+		t.Fatalf("expected manual merge with unknown clone: %+v", g)
+	}
+}
+
+func TestNameGroupReason(t *testing.T) {
+	ms := []duplicateMember{{Identity: "a"}, {}}
+	if got := nameGroupReason(ms); got != "same name, some without origin" {
+		t.Fatalf("unexpected reason: %q", got)
+	}
+}
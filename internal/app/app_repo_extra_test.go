@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"os"
 	"path/filepath"
 	"testing"
 
@@ -133,6 +134,15 @@ func TestRepoCloneError(t *testing.T) {
 	if code := app.runRepoClone(context.Background(), []string{bare}); code == 0 {
 		t.Fatalf("expected clone error")
 	}
+	cfg.AllowCommands = []string{"git*"}
+	cfg.DenyPaths = []string{filepath.ToSlash(filepath.Join(cfg.ReposRoot, "remote"))}
+	writeConfig(t, cfg)
+	if code := app.runRepoClone(context.Background(), []string{bare}); code == 0 {
+		t.Fatalf("expected guard error")
+	}
+	if _, err := os.Stat(filepath.Join(cfg.ReposRoot, "remote")); !os.IsNotExist(err) {
+		t.Fatalf("denied clone created the repo: %v", err)
+	}
 }
 
 func TestRepoExecJSONErrorAndParallel(t *testing.T) {
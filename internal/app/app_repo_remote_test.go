@@ -0,0 +1,95 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TT-AIXion/github-kanri/internal/output"
+)
+
+func TestRepoRemoteCommands(t *testing.T) {
+	app, cfg := newTestApp(t)
+	alpha := initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	beta := initGitRepo(t, filepath.Join(cfg.ReposRoot, "beta"), true)
+	_ = initGitRepo(t, filepath.Join(cfg.ReposRoot, "gamma"), true)
+	_ = runGit(alpha, "remote", "add", "origin", "https://github.com/acme/alpha.git")
+	_ = runGit(beta, "remote", "add", "origin", "git@github.com:acme/beta.git")
+
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runRepo(context.Background(), []string{"remote"}); code != 0 {
+		t.Fatalf("remote help failed")
+	}
+	if code := app.runRepo(context.Background(), []string{"remote", "nope"}); code == 0 {
+		t.Fatalf("expected unknown command error")
+	}
+	out.Reset()
+	if code := app.runRepo(context.Background(), []string{"remote", "list"}); code != 0 {
+		t.Fatalf("remote list failed")
+	}
+	if !strings.Contains(out.String(), "alpha github.com/acme/alpha") || !strings.Contains(out.String(), "gamma no-origin") {
+		t.Fatalf("unexpected list: %s", out.String())
+	}
+	out.Reset()
+	if code := app.runRepo(context.Background(), []string{"info", "alpha"}); code != 0 {
+		t.Fatalf("info failed")
+	}
+
+	args := []string{"remote", "rewrite", "--from", "https://github.com/", "--to", "git@github.com:"}
+	out.Reset()
+	if code := app.runRepo(context.Background(), append(args, "--dry-run")); code != 0 {
+		t.Fatalf("rewrite dry-run failed")
+	}
+	if !strings.Contains(out.String(), "would rewrite alpha") || strings.Contains(out.String(), "beta") {
+		t.Fatalf("unexpected dry-run: %s", out.String())
+	}
+	out.Reset()
+	if code := app.runRepo(context.Background(), args); code != 0 {
+		t.Fatalf("rewrite failed")
+	}
+	if !strings.Contains(out.String(), "rewrote alpha https://github.com/acme/alpha.git -> git@github.com:acme/alpha.git") {
+		t.Fatalf("unexpected rewrite: %s", out.String())
+	}
+	out.Reset()
+	if code := app.runRepo(context.Background(), args); code != 0 {
+		t.Fatalf("rewrite rerun failed")
+	}
+	if !strings.Contains(out.String(), "no matching remotes") {
+		t.Fatalf("expected idempotent rewrite: %s", out.String())
+	}
+
+	appJSON := app
+	appJSON.Out.JSON = true
+	back := []string{"remote", "rewrite", "--from", "git@github.com:", "--to", "https://github.com/"}
+	if code := appJSON.runRepo(context.Background(), back); code != 0 {
+		t.Fatalf("json rewrite failed")
+	}
+	if code := appJSON.runRepo(context.Background(), []string{"remote", "list"}); code != 0 {
+		t.Fatalf("json list failed")
+	}
+	cfg.DenyPaths = []string{filepath.ToSlash(alpha)}
+	writeConfig(t, cfg)
+	if code := app.runRepo(context.Background(), []string{"remote", "rewrite", "--from", "https://", "--to", "ssh://"}); code == 0 {
+		t.Fatalf("expected guard error")
+	}
+	if code := appJSON.runRepo(context.Background(), []string{"remote", "rewrite", "--from", "https://", "--to", "ssh://"}); code == 0 {
+		t.Fatalf("expected json guard error")
+	}
+}
+
+func TestRepoRemoteErrors(t *testing.T) {
+	app, _ := newTestApp(t)
+	cases := [][]string{
+		{"remote", "list", "--bad"},
+		{"remote", "rewrite", "--bad"},
+		{"remote", "rewrite", "--from", "x"},
+	}
+	for _, args := range cases {
+		if code := app.runRepo(context.Background(), args); code == 0 {
+			t.Fatalf("expected error: %v", args)
+		}
+	}
+}
@@ -1,10 +1,14 @@
 package app
 
 import (
+	"bytes"
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/TT-AIXion/github-kanri/internal/output"
 )
 
 func TestRepoCommands(t *testing.T) {
@@ -154,3 +158,47 @@ func TestRepoCloneAndExec(t *testing.T) {
 		t.Fatalf("expected require-clean error")
 	}
 }
+
+func TestRepoOpenWeb(t *testing.T) {
+	app, cfg := newTestApp(t)
+	alpha := initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	_ = initGitRepo(t, filepath.Join(cfg.ReposRoot, "beta"), true)
+	if err := runGit(alpha, "remote", "add", "origin", "git@github.com:Acme/Alpha.git"); err != nil {
+		t.Fatalf("git remote: %v", err)
+	}
+	bin := t.TempDir()
+	opened := filepath.Join(bin, "opened")
+	_ = os.WriteFile(filepath.Join(bin, "xdg-open"), []byte("#!/bin/sh\necho \"$@\" > "+opened+"\n"), 0o755)
+	prependPath(t, bin)
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runRepo(context.Background(), []string{"open", "--web", "alpha"}); code != 0 {
+		t.Fatalf("open --web failed: %s", out.String())
+	}
+	if data, _ := os.ReadFile(opened); strings.TrimSpace(string(data)) != "https://github.com/acme/alpha" {
+		t.Fatalf("unexpected browser url: %q", data)
+	}
+	if code := app.runRepo(context.Background(), []string{"open", "--web", "beta"}); code != 1 || !strings.Contains(out.String(), "no origin remote") {
+		t.Fatalf("expected missing origin error: %s", out.String())
+	}
+	_ = runGit(alpha, "remote", "set-url", "origin", "/srv/git/alpha.git")
+	if code := app.runRepo(context.Background(), []string{"open", "--web", "alpha"}); code != 1 {
+		t.Fatalf("expected unsupported remote error")
+	}
+	_ = runGit(alpha, "remote", "set-url", "origin", "https://github.com/acme/alpha.git")
+	cfg.AllowCommands = []string{"git*"}
+	writeConfig(t, cfg)
+	if code := app.runRepo(context.Background(), []string{"open", "--web", "alpha"}); code != 1 {
+		t.Fatalf("expected guarded opener")
+	}
+}
+
+func TestBrowserCommand(t *testing.T) {
+	url := "https://github.com/acme/alpha"
+	for goos, want := range map[string]string{"darwin": "open " + url, "windows": "rundll32 url.dll,FileProtocolHandler " + url, "linux": "xdg-open " + url} {
+		name, args := browserCommand(goos, url)
+		if got := strings.Join(append([]string{name}, args...), " "); got != want {
+			t.Fatalf("%s: got %q, want %q", goos, got, want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TT-AIXion/github-kanri/internal/config"
+	"github.com/TT-AIXion/github-kanri/internal/output"
+)
+
+func writeSkillManifest(t *testing.T, dir, manifest string) {
+	t.Helper()
+	_ = os.MkdirAll(dir, 0o755)
+	if err := os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte(manifest), 0o644); err != nil {
+		t.Fatalf("write skill: %v", err)
+	}
+}
+
+func TestSkillsListAndLint(t *testing.T) {
+	app, cfg := newTestApp(t)
+	writeSkillManifest(t, filepath.Join(cfg.SkillsRoot, "review"), "---\nname: review\ndescription: Review code\nversion: 1.0.0\nrequires: [style]\n---\nSee [guide](guide.md).\n")
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "review", "guide.md"), []byte("guide"), 0o644)
+	writeSkillManifest(t, filepath.Join(cfg.SkillsRoot, "style"), "---\nname: style\ndescription: Style rules\n---\n")
+	extra := filepath.Join(t.TempDir(), "extra")
+	writeSkillManifest(t, filepath.Join(extra, "style"), "---\nname: style\ndescription: Other style\n---\n")
+	disabled := false
+	cfg.SyncTargets = append(cfg.SyncTargets,
+		config.SyncTarget{Name: "overlap", Src: filepath.Join(cfg.SkillsRoot, "style"), Dest: []string{".x"}},
+		config.SyncTarget{Name: "extra", Src: extra, Dest: []string{".y"}, Enabled: &disabled},
+	)
+	writeConfig(t, cfg)
+
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runSkillsList(context.Background(), nil); code != 0 {
+		t.Fatalf("list failed: %s", out.String())
+	}
+	text := out.String()
+	if !strings.Contains(text, "review 1.0.0 skillsRoot:review - Review code") || !strings.Contains(text, "style - skillsRoot:style") || strings.Count(text, "style") != 2 {
+		t.Fatalf("unexpected list: %s", text)
+	}
+	out.Reset()
+	if code := app.runSkillsLint(context.Background(), nil); code != 0 || !strings.Contains(out.String(), "2 skills, 0 errors, 0 warnings") {
+		t.Fatalf("expected clean lint: %s", out.String())
+	}
+
+	out.Reset()
+	if code := app.runSkillsLint(context.Background(), []string{"--target", "extra"}); code != 0 {
+		t.Fatalf("expected clean extra lint: %s", out.String())
+	}
+	cfg.SyncTargets[2].Enabled = nil
+	writeConfig(t, cfg)
+	_ = os.Remove(filepath.Join(cfg.SkillsRoot, "review", "guide.md"))
+	writeSkillManifest(t, filepath.Join(cfg.SkillsRoot, "broken"), "no frontmatter")
+	writeSkillManifest(t, filepath.Join(cfg.SkillsRoot, "alias"), "---\nname: renamed\ndescription: d\n---\n")
+	out.Reset()
+	if code := app.runSkillsLint(context.Background(), nil); code != 1 {
+		t.Fatalf("expected lint errors: %s", out.String())
+	}
+	text = out.String()
+	for _, want := range []string{"referenced file not found: guide.md", "skillsRoot:broken: missing frontmatter", `duplicate name "style"`, "/extra:style", "does not match directory", "5 skills, 4 errors, 1 warnings"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("missing %q in lint output: %s", want, text)
+		}
+	}
+
+	appJSON := app
+	appJSON.Out.JSON = true
+	out.Reset()
+	if code := appJSON.runSkillsLint(context.Background(), nil); code != 1 || !strings.Contains(out.String(), `"errors":4`) || !strings.Contains(out.String(), `"level":"warning"`) {
+		t.Fatalf("unexpected lint json: %s", out.String())
+	}
+	out.Reset()
+	if code := appJSON.runSkillsList(context.Background(), []string{"--target", "overlap"}); code != 0 || !strings.Contains(out.String(), `"source":"skillsRoot/style"`) || !strings.Contains(out.String(), `"path":"."`) {
+		t.Fatalf("unexpected list json: %s", out.String())
+	}
+
+	_ = os.RemoveAll(extra)
+	out.Reset()
+	if code := app.runSkillsList(context.Background(), nil); code != exitPartial || !strings.Contains(out.String(), "source not found") {
+		t.Fatalf("expected missing source: %s", out.String())
+	}
+	out.Reset()
+	if code := app.runSkillsLint(context.Background(), []string{"--target", "extra"}); code != 1 || !strings.Contains(out.String(), "run gkn skills clone") {
+		t.Fatalf("expected missing source lint error: %s", out.String())
+	}
+	if code := app.runSkillsList(context.Background(), []string{"--target", "nope"}); code != 1 {
+		t.Fatalf("expected unknown target error")
+	}
+	if code := app.runSkillsLint(context.Background(), []string{"--bad"}); code != 1 {
+		t.Fatalf("expected flag error")
+	}
+	cfg.SyncTargets = nil
+	writeConfig(t, cfg)
+	out.Reset()
+	if code := appJSON.runSkillsList(context.Background(), nil); code != 0 || !strings.Contains(out.String(), `"source":"skillsRoot"`) {
+		t.Fatalf("expected skillsRoot fallback: %s", out.String())
+	}
+}
@@ -187,3 +187,21 @@ func TestSkillsSyncForce(t *testing.T) {
 		t.Fatalf("expected sync force")
 	}
 }
+
+func TestSkillsSyncRerunFailPolicy(t *testing.T) {
+	app, cfg := newTestApp(t)
+	_ = initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	cfg.ConflictPolicy = "fail"
+	writeConfig(t, cfg)
+	if code := app.runSkillsSync(context.Background(), []string{"--target", "skills"}); code != 0 {
+		t.Fatalf("expected first sync")
+	}
+	if code := app.runSkillsSync(context.Background(), []string{"--target", "skills"}); code != 0 {
+		t.Fatalf("expected rerun to succeed with unchanged files")
+	}
+	appJSON := app
+	appJSON.Out.JSON = true
+	if code := appJSON.runSkillsSync(context.Background(), []string{"--target", "skills"}); code != 0 {
+		t.Fatalf("expected json rerun")
+	}
+}
@@ -1,15 +1,11 @@
 package app
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"os"
 	"path/filepath"
 	"testing"
-	"time"
-
-	"github.com/TT-AIXion/github-kanri/internal/output"
 )
 
 func TestSkillsClonePullError(t *testing.T) {
@@ -88,138 +84,3 @@ func TestSkillsPinCheckoutError(t *testing.T) {
 		t.Fatalf("expected checkout error")
 	}
 }
-
-func TestSkillsStateErrors(t *testing.T) {
-	app, cfg := newTestApp(t)
-	_ = app
-	cfg.SkillsRoot = filepath.Join(t.TempDir(), "missing")
-	if _, err := skillsState(context.Background(), cfg); err == nil {
-		t.Fatalf("expected stat error")
-	}
-
-	cfg2 := cfg
-	cfg2.SkillsRoot = t.TempDir()
-	_ = initGitRepo(t, cfg2.SkillsRoot, true)
-	cfg2.AllowCommands = []string{"git status*"}
-	if _, err := skillsState(context.Background(), cfg2); err == nil {
-		t.Fatalf("expected rev-parse error")
-	}
-
-	cfg3 := cfg2
-	cfg3.AllowCommands = []string{"git rev-parse*"}
-	if _, err := skillsState(context.Background(), cfg3); err == nil {
-		t.Fatalf("expected status error")
-	}
-}
-
-func TestSkillsWatchIntervalReset(t *testing.T) {
-	app, cfg := newTestApp(t)
-	_ = initGitRepo(t, cfg.SkillsRoot, true)
-	writeConfig(t, cfg)
-	orig := timeAfter
-	defer func() { timeAfter = orig }()
-	got := make(chan time.Duration, 1)
-	timeAfter = func(d time.Duration) <-chan time.Time {
-		got <- d
-		return make(chan time.Time)
-	}
-	ctx, cancel := context.WithCancel(context.Background())
-	done := make(chan int, 1)
-	go func() { done <- app.runSkillsWatch(ctx, []string{"--interval", "0"}) }()
-	if d := <-got; d != 5*time.Second {
-		t.Fatalf("expected default interval")
-	}
-	cancel()
-	<-done
-}
-
-func TestSkillsWatchSyncOnChange(t *testing.T) {
-	app, cfg := newTestApp(t)
-	var out bytes.Buffer
-	var errBuf bytes.Buffer
-	app.Out = output.Writer{Out: &out, ErrW: &errBuf}
-	_ = initGitRepo(t, cfg.SkillsRoot, true)
-	_ = initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
-	writeConfig(t, cfg)
-	orig := timeAfter
-	defer func() { timeAfter = orig }()
-	tick := make(chan time.Time, 1)
-	started := make(chan struct{})
-	timeAfter = func(time.Duration) <-chan time.Time {
-		select {
-		case <-started:
-		default:
-			close(started)
-		}
-		return tick
-	}
-	ctx, cancel := context.WithCancel(context.Background())
-	done := make(chan int, 1)
-	go func() { done <- app.runSkillsWatch(ctx, []string{"--interval", "1"}) }()
-	<-started
-	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "new.txt"), []byte("x"), 0o644)
-	tick <- time.Now()
-	time.Sleep(100 * time.Millisecond)
-	cancel()
-	if code := <-done; code != 0 {
-		t.Fatalf("expected watch ok: %s", errBuf.String())
-	}
-}
-
-func TestSkillsWatchStateError(t *testing.T) {
-	app, cfg := newTestApp(t)
-	_ = initGitRepo(t, cfg.SkillsRoot, true)
-	writeConfig(t, cfg)
-	orig := timeAfter
-	defer func() { timeAfter = orig }()
-	tick := make(chan time.Time, 1)
-	started := make(chan struct{})
-	timeAfter = func(time.Duration) <-chan time.Time {
-		select {
-		case <-started:
-		default:
-			close(started)
-		}
-		return tick
-	}
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	done := make(chan int, 1)
-	go func() { done <- app.runSkillsWatch(ctx, []string{"--interval", "1"}) }()
-	<-started
-	_ = os.RemoveAll(cfg.SkillsRoot)
-	tick <- time.Now()
-	if code := <-done; code == 0 {
-		t.Fatalf("expected watch error")
-	}
-}
-
-func TestSkillsWatchSyncError(t *testing.T) {
-	app, cfg := newTestApp(t)
-	_ = initGitRepo(t, cfg.SkillsRoot, true)
-	_ = initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
-	cfg.DenyPaths = []string{"**"}
-	writeConfig(t, cfg)
-	orig := timeAfter
-	defer func() { timeAfter = orig }()
-	tick := make(chan time.Time, 1)
-	started := make(chan struct{})
-	timeAfter = func(time.Duration) <-chan time.Time {
-		select {
-		case <-started:
-		default:
-			close(started)
-		}
-		return tick
-	}
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	done := make(chan int, 1)
-	go func() { done <- app.runSkillsWatch(ctx, []string{"--interval", "1"}) }()
-	<-started
-	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "new.txt"), []byte("x"), 0o644)
-	tick <- time.Now()
-	if code := <-done; code == 0 {
-		t.Fatalf("expected watch sync error")
-	}
-}
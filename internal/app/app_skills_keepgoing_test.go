@@ -0,0 +1,121 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TT-AIXion/github-kanri/internal/output"
+)
+
+func TestSkillsSyncKeepGoing(t *testing.T) {
+	app, cfg := newTestApp(t)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "a.txt"), []byte("upstream"), 0o644)
+	alpha := initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	beta := initGitRepo(t, filepath.Join(cfg.ReposRoot, "beta"), true)
+	gamma := initGitRepo(t, filepath.Join(cfg.ReposRoot, "gamma"), true)
+	_ = os.MkdirAll(filepath.Join(beta, ".codex", "skills"), 0o755)
+	_ = os.WriteFile(filepath.Join(beta, ".codex", "skills", "a.txt"), []byte("local"), 0o644)
+	cfg.ConflictPolicy = "fail"
+	writeConfig(t, cfg)
+
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runSkillsSync(context.Background(), []string{"--atomic", "--keep-going"}); code != 1 {
+		t.Fatalf("expected flag conflict: %s", out.String())
+	}
+	out.Reset()
+	if code := app.runSkillsSync(context.Background(), nil); code != 1 {
+		t.Fatalf("expected abort: %s", out.String())
+	}
+	if _, err := os.Stat(filepath.Join(gamma, ".codex")); !os.IsNotExist(err) {
+		t.Fatalf("expected gamma skipped without --keep-going")
+	}
+	if code := app.runSkillsRollback(context.Background(), nil); code != 0 {
+		t.Fatalf("rollback failed")
+	}
+
+	out.Reset()
+	if code := app.runSkillsSync(context.Background(), []string{"--keep-going"}); code != exitPartial {
+		t.Fatalf("expected partial failure: %s", out.String())
+	}
+	for _, want := range []string{"ERR beta skills: conflict detected", "1 of 3 targets failed", "gkn skills rollback --run"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("missing %q: %s", want, out.String())
+		}
+	}
+	for _, r := range []string{alpha, gamma} {
+		if data, _ := os.ReadFile(filepath.Join(r, ".codex", "skills", "a.txt")); string(data) != "upstream" {
+			t.Fatalf("expected %s synced, got %q", r, data)
+		}
+	}
+
+	out.Reset()
+	app.Out.JSON = true
+	if code := app.runSkillsSync(context.Background(), []string{"--keep-going"}); code != exitPartial {
+		t.Fatalf("expected partial failure: %s", out.String())
+	}
+	var resp struct {
+		Data []syncResult `json:"data"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &resp); err != nil || len(resp.Data) != 3 || resp.Data[1].Error == "" || resp.Data[0].Error != "" {
+		t.Fatalf("unexpected json: %s %v", out.String(), err)
+	}
+}
+
+func TestSkillsCleanKeepGoing(t *testing.T) {
+	app, cfg := newTestApp(t)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "a.txt"), []byte("a"), 0o644)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "b.txt"), []byte("b"), 0o644)
+	var repos []string
+	for _, name := range []string{"alpha", "beta", "gamma"} {
+		repos = append(repos, initGitRepo(t, filepath.Join(cfg.ReposRoot, name), true))
+	}
+	if code := app.runSkillsSync(context.Background(), nil); code != 0 {
+		t.Fatalf("sync failed")
+	}
+	_ = os.Remove(filepath.Join(cfg.SkillsRoot, "b.txt"))
+	cfg.DenyPaths = []string{repos[1] + "/**"}
+	writeConfig(t, cfg)
+	stale := func(r string) bool {
+		_, err := os.Stat(filepath.Join(r, ".codex", "skills", "b.txt"))
+		return err == nil
+	}
+
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runSkillsClean(context.Background(), nil); code != exitPartial {
+		t.Fatalf("expected partial failure: %s", out.String())
+	}
+	if stale(repos[0]) || !stale(repos[2]) {
+		t.Fatalf("expected clean to stop at beta")
+	}
+	out.Reset()
+	if code := app.runSkillsClean(context.Background(), []string{"--keep-going"}); code != exitPartial {
+		t.Fatalf("expected partial failure: %s", out.String())
+	}
+	if stale(repos[2]) || !stale(repos[1]) || !strings.Contains(out.String(), "1 of 3 targets failed") {
+		t.Fatalf("expected gamma cleaned past beta: %s", out.String())
+	}
+	out.Reset()
+	app.Out.JSON = true
+	if code := app.runSkillsClean(context.Background(), []string{"--keep-going", "--dry-run"}); code != exitPartial || !strings.Contains(out.String(), `"error":"deny path`) {
+		t.Fatalf("unexpected json: %s", out.String())
+	}
+}
+
+func TestSkillsVerifyAllFailed(t *testing.T) {
+	app, cfg := newTestApp(t)
+	_ = initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	_ = initGitRepo(t, filepath.Join(cfg.ReposRoot, "beta"), true)
+	_ = os.RemoveAll(cfg.SkillsRoot)
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runSkillsVerify(context.Background(), nil); code != 1 || !strings.Contains(out.String(), "2 of 2 targets failed") {
+		t.Fatalf("expected total failure: %s", out.String())
+	}
+}
@@ -0,0 +1,138 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TT-AIXion/github-kanri/internal/fsutil"
+	"github.com/TT-AIXion/github-kanri/internal/output"
+)
+
+func TestSkillsSyncLinkDir(t *testing.T) {
+	app, cfg := newTestApp(t)
+	_ = os.MkdirAll(filepath.Join(cfg.SkillsRoot, "review"), 0o755)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "review", "SKILL.md"), []byte("review"), 0o644)
+	repoPath := initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	cfg.SyncTargets[0].Mode = "link-dir"
+	cfg.SyncTargets[0].RelativeLinks = true
+	cfg.ConflictPolicy = "fail"
+	writeConfig(t, cfg)
+
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runSkillsSync(context.Background(), []string{"--target", "skills"}); code != 0 {
+		t.Fatalf("sync failed: %s", out.String())
+	}
+	link := filepath.Join(repoPath, ".codex", "skills", "review")
+	target, err := os.Readlink(link)
+	if err != nil || filepath.IsAbs(target) {
+		t.Fatalf("expected relative dir link: %q %v", target, err)
+	}
+
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "review", "extra.md"), []byte("extra"), 0o644)
+	for _, run := range []func(context.Context, []string) int{app.runSkillsVerify, app.runSkillsStatus} {
+		out.Reset()
+		if code := run(context.Background(), []string{"--target", "skills"}); code != 0 || strings.Contains(out.String(), "drift") {
+			t.Fatalf("expected link-dir target clean: %s", out.String())
+		}
+	}
+
+	_ = os.Remove(link)
+	_ = os.MkdirAll(link, 0o755)
+	out.Reset()
+	if code := app.runSkillsDiff(context.Background(), []string{"--target", "skills"}); code != 0 || !strings.Contains(out.String(), "not-link review") {
+		t.Fatalf("expected not-link drift: %s", out.String())
+	}
+	if code := app.runSkillsVerify(context.Background(), []string{"--target", "skills"}); code != 2 {
+		t.Fatalf("expected verify mismatch")
+	}
+	if code := app.runSkillsSync(context.Background(), []string{"--target", "skills", "--force"}); code != 0 {
+		t.Fatalf("forced sync failed")
+	}
+	if code := app.runSkillsClean(context.Background(), []string{"--target", "skills", "--force"}); code != 0 {
+		t.Fatalf("clean failed")
+	}
+	if _, err := os.Readlink(link); err != nil {
+		t.Fatalf("clean should keep current links: %v", err)
+	}
+}
+
+func TestSkillsLinkDirFlag(t *testing.T) {
+	app, cfg := newTestApp(t)
+	_ = os.MkdirAll(filepath.Join(cfg.SkillsRoot, "review"), 0o755)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "review", "SKILL.md"), []byte("review"), 0o644)
+	repoPath := initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	if code := app.runSkillsLink(context.Background(), []string{"--target", "skills", "--dir", "--relative"}); code != 0 {
+		t.Fatalf("link failed")
+	}
+	target, err := os.Readlink(filepath.Join(repoPath, ".codex", "skills", "review"))
+	if err != nil || filepath.IsAbs(target) {
+		t.Fatalf("expected relative dir link: %q %v", target, err)
+	}
+	if code := app.runSkillsSync(context.Background(), []string{"--target", "skills", "--mode", "link-dir", "--relative", "--force"}); code != 0 {
+		t.Fatalf("sync link-dir failed")
+	}
+}
+
+func TestSkillsVerifyLinkIssues(t *testing.T) {
+	app, cfg := newTestApp(t)
+	for _, name := range []string{"a.md", "b.md", "c.md"} {
+		_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, name), []byte(name), 0o644)
+	}
+	alpha := initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	beta := initGitRepo(t, filepath.Join(cfg.ReposRoot, "beta"), true)
+	cfg.SyncTargets[0].Mode = "link"
+	writeConfig(t, cfg)
+	if code := app.runSkillsSync(context.Background(), []string{"--target", "skills"}); code != 0 {
+		t.Fatalf("sync failed")
+	}
+	dest := filepath.Join(alpha, ".codex", "skills")
+	_ = os.Remove(filepath.Join(dest, "a.md"))
+	_ = os.Symlink(filepath.Join(cfg.SkillsRoot, "gone.md"), filepath.Join(dest, "a.md"))
+	_ = os.Remove(filepath.Join(dest, "b.md"))
+	_ = os.Symlink(filepath.Join(cfg.SkillsRoot, "c.md"), filepath.Join(dest, "b.md"))
+	_ = os.Remove(filepath.Join(dest, "c.md"))
+	_ = os.WriteFile(filepath.Join(dest, "c.md"), []byte("c.md"), 0o644)
+	_ = os.Remove(filepath.Join(beta, ".codex", "skills", fsutil.ManifestName))
+	_ = os.MkdirAll(filepath.Join(beta, ".codex", "skills", fsutil.ManifestName), 0o755)
+
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runSkillsVerify(context.Background(), []string{"--target", "skills"}); code != 2 {
+		t.Fatalf("expected mismatch code: %s", out.String())
+	}
+	if !strings.Contains(out.String(), "alpha skills mismatch dangling=1 wrong-target=1 not-link=1") {
+		t.Fatalf("expected link categories: %s", out.String())
+	}
+
+	out.Reset()
+	if code := app.runSkillsStatus(context.Background(), []string{"--target", "skills"}); code != exitPartial {
+		t.Fatalf("expected status partial failure code: %s", out.String())
+	}
+	if statusCell(out.String(), "alpha", "skills", "STATE") != "drift" || statusCell(out.String(), "alpha", "skills", "LINKS") != "3" || !strings.Contains(out.String(), "ERR beta skills:") || !strings.Contains(out.String(), "1 of 2 targets failed") {
+		t.Fatalf("expected status to continue past beta error: %s", out.String())
+	}
+
+	out.Reset()
+	if code := app.runSkillsDiff(context.Background(), []string{"--target", "skills"}); code != exitPartial {
+		t.Fatalf("expected diff partial failure code: %s", out.String())
+	}
+	for _, want := range []string{"dangling a.md", "wrong-target b.md", "not-link c.md", "ERR beta skills:"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("missing %q: %s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	app.Out.JSON = true
+	if code := app.runSkillsVerify(context.Background(), []string{"--target", "skills", "--only", "alpha"}); code != 2 {
+		t.Fatalf("expected json mismatch: %s", out.String())
+	}
+	if !strings.Contains(out.String(), `"dangling":["a.md"]`) {
+		t.Fatalf("expected json categories: %s", out.String())
+	}
+}
@@ -0,0 +1,153 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TT-AIXion/github-kanri/internal/config"
+	"github.com/TT-AIXion/github-kanri/internal/fsutil"
+	"github.com/TT-AIXion/github-kanri/internal/output"
+)
+
+func revParse(t *testing.T, dir, ref string) string {
+	t.Helper()
+	out, err := exec.Command("git", "-C", dir, "rev-parse", ref).Output()
+	if err != nil {
+		t.Fatalf("rev-parse %s: %v", ref, err)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestSkillsLockAndFrozenInstall(t *testing.T) {
+	app, cfg := newTestApp(t)
+	upstream := initGitRepo(t, filepath.Join(t.TempDir(), "upstream"), true)
+	commitSkill(t, upstream, "v1", "v1")
+	commitSkill(t, upstream, "v2", "v2")
+	_ = os.RemoveAll(cfg.SkillsRoot)
+	if err := runGit(cfg.ProjectsRoot, "clone", upstream, cfg.SkillsRoot); err != nil {
+		t.Fatalf("clone: %v", err)
+	}
+	cfg.SyncTargets = append(cfg.SyncTargets, config.SyncTarget{Name: "edge", Remote: upstream, Src: "skills", Dest: []string{".codex/edge"}})
+	writeConfig(t, cfg)
+	cfgPath, _ := config.DefaultConfigPath()
+	lockPath := filepath.Join(filepath.Dir(cfgPath), skillsLockName)
+
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runSkillsInstall(context.Background(), nil); code != 0 {
+		t.Fatalf("install failed: %s", out.String())
+	}
+	lock, err := readSkillsLock(lockPath)
+	if err != nil || len(lock.Sources) != 2 {
+		t.Fatalf("unexpected lock: %+v %v", lock, err)
+	}
+	v2 := revParse(t, upstream, "v2")
+	if lock.Sources[0].Name != rootSourceName || lock.Sources[0].Commit != v2 || lock.Sources[0].Remote != upstream || lock.Sources[1].Commit != v2 || lock.Sources[1].Digest == "" {
+		t.Fatalf("unexpected lock sources: %+v", lock.Sources)
+	}
+	if digest, err := fsutil.SourceDigest(cfg.SkillsRoot, nil, []string{".git", ".git/**"}, nil); err != nil || lock.Sources[0].Digest != digest {
+		t.Fatalf("expected a digest of the source files: %s %s %v", lock.Sources[0].Digest, digest, err)
+	}
+
+	edgeDir := filepath.Join(filepath.Dir(cfgPath), "..", "..", ".cache")
+	_ = os.RemoveAll(edgeDir)
+	_ = runGit(cfg.SkillsRoot, "checkout", "-q", "v1")
+	commitSkill(t, upstream, "v3", "v3")
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, ".git", "info", "exclude"), []byte("*.log\n"), 0o644)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "debug.log"), []byte("ignored"), 0o644)
+	out.Reset()
+	if code := app.runSkillsInstall(context.Background(), []string{"--frozen"}); code != 0 {
+		t.Fatalf("frozen install failed: %s", out.String())
+	}
+	if got := revParse(t, cfg.SkillsRoot, "HEAD"); got != v2 {
+		t.Fatalf("expected locked skillsRoot commit, got %s", got)
+	}
+	cfgLoaded, _, _ := loadConfig()
+	if got := revParse(t, cfgLoaded.SyncTargets[1].Checkout, "HEAD"); got != v2 {
+		t.Fatalf("expected locked edge commit, got %s", got)
+	}
+
+	lock.Sources[1].Digest = "bogus"
+	data, _ := json.Marshal(lock)
+	_ = os.WriteFile(lockPath, data, 0o644)
+	out.Reset()
+	if code := app.runSkillsInstall(context.Background(), []string{"--frozen"}); code != exitPartial || !strings.Contains(out.String(), "content hash mismatch") {
+		t.Fatalf("expected digest mismatch: %s", out.String())
+	}
+
+	out.Reset()
+	if code := app.runSkillsPin(context.Background(), []string{"--target", "edge", "--ref", "v1"}); code != 0 {
+		t.Fatalf("pin failed: %s", out.String())
+	}
+	lock, _ = readSkillsLock(lockPath)
+	if len(lock.Sources) != 2 || lock.Sources[1].Ref != "v1" || lock.Sources[1].Commit != revParse(t, upstream, "v1") {
+		t.Fatalf("expected pin to update the lock: %+v", lock.Sources)
+	}
+
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "dirty.md"), []byte("x"), 0o644)
+	out.Reset()
+	if code := app.runSkillsLock(context.Background(), nil); code != 1 || !strings.Contains(out.String(), "has local changes") {
+		t.Fatalf("expected dirty source error: %s", out.String())
+	}
+	if code := app.runSkillsInstall(context.Background(), []string{"--frozen"}); code == 0 {
+		t.Fatalf("expected dirty install error")
+	}
+	_ = os.Remove(filepath.Join(cfg.SkillsRoot, "dirty.md"))
+}
+
+func TestSkillsFrozenInstallConfigMismatch(t *testing.T) {
+	app, cfg := newTestApp(t)
+	_ = initGitRepo(t, cfg.SkillsRoot, true)
+	lockPath := filepath.Join(t.TempDir(), "skills.lock")
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runSkillsInstall(context.Background(), []string{"--frozen", "--file", lockPath}); code != 1 {
+		t.Fatalf("expected missing lock error")
+	}
+	if code := app.runSkillsLock(context.Background(), []string{"--file", lockPath}); code != 0 {
+		t.Fatalf("lock failed: %s", out.String())
+	}
+
+	cfg.SyncTargets = append(cfg.SyncTargets, config.SyncTarget{Name: "extra", Remote: filepath.Join(t.TempDir(), "remote"), Dest: []string{".x"}})
+	writeConfig(t, cfg)
+	out.Reset()
+	if code := app.runSkillsInstall(context.Background(), []string{"--frozen", "--file", lockPath}); code != 1 || !strings.Contains(out.String(), "is not locked") {
+		t.Fatalf("expected unlocked source error: %s", out.String())
+	}
+	cfg.SyncTargets = []config.SyncTarget{{Name: "extra", Remote: filepath.Join(t.TempDir(), "remote"), Dest: []string{".x"}}}
+	writeConfig(t, cfg)
+	out.Reset()
+	if code := app.runSkillsInstall(context.Background(), []string{"--frozen", "--file", lockPath}); code != 1 || !strings.Contains(out.String(), "is not locked") {
+		t.Fatalf("expected unlocked source error: %s", out.String())
+	}
+
+	lock, _ := readSkillsLock(lockPath)
+	lock.Sources = append(lock.Sources, lockedSource{Name: "ghost"})
+	data, _ := json.Marshal(lock)
+	_ = os.WriteFile(lockPath, data, 0o644)
+	cfg.SyncTargets = nil
+	writeConfig(t, cfg)
+	out.Reset()
+	if code := app.runSkillsInstall(context.Background(), []string{"--frozen", "--file", lockPath}); code != 1 || !strings.Contains(out.String(), "unknown source ghost") {
+		t.Fatalf("expected unknown source error: %s", out.String())
+	}
+
+	_ = os.WriteFile(lockPath, []byte(`{"version":9}`), 0o644)
+	if code := app.runSkillsInstall(context.Background(), []string{"--frozen", "--file", lockPath}); code != 1 {
+		t.Fatalf("expected version error")
+	}
+	_ = os.WriteFile(lockPath, []byte(`{`), 0o644)
+	if code := app.runSkillsInstall(context.Background(), []string{"--frozen", "--file", lockPath}); code != 1 {
+		t.Fatalf("expected parse error")
+	}
+	_ = os.RemoveAll(cfg.SkillsRoot)
+	if code := app.runSkillsLock(context.Background(), []string{"--file", lockPath}); code != 1 {
+		t.Fatalf("expected not cloned error")
+	}
+}
@@ -0,0 +1,144 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TT-AIXion/github-kanri/internal/fsutil"
+	"github.com/TT-AIXion/github-kanri/internal/output"
+)
+
+func TestSkillsSyncManifestAndStatus(t *testing.T) {
+	app, cfg := newTestApp(t)
+	_ = initGitRepo(t, cfg.SkillsRoot, true)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "b.txt"), []byte("b"), 0o644)
+	repoPath := initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	cfg.ConflictPolicy = "fail"
+	writeConfig(t, cfg)
+
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runSkillsSync(context.Background(), []string{"--target", "skills"}); code != 0 {
+		t.Fatalf("sync failed: %s", out.String())
+	}
+	dest := filepath.Join(repoPath, ".codex", "skills")
+	m, ok, err := fsutil.ReadManifest(dest)
+	if err != nil || !ok {
+		t.Fatalf("expected manifest: %v", err)
+	}
+	if m.Target != "skills" || len(m.Commit) != 40 || !m.Managed("a.txt") || m.Managed(".git/HEAD") {
+		t.Fatalf("unexpected manifest: %+v", m)
+	}
+
+	out.Reset()
+	if code := app.runSkillsStatus(context.Background(), []string{"--target", "skills"}); code != 0 {
+		t.Fatalf("status failed: %s", out.String())
+	}
+	if statusCell(out.String(), "alpha", "skills", "STATE") != "clean" || statusCell(out.String(), "alpha", "skills", "REV") != m.Commit[:7] {
+		t.Fatalf("expected revision in status: %s", out.String())
+	}
+
+	_ = os.WriteFile(filepath.Join(dest, "a.txt"), []byte("local"), 0o644)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "b.txt"), []byte("upstream"), 0o644)
+	out.Reset()
+	if code := app.runSkillsStatus(context.Background(), []string{"--target", "skills"}); code != 0 {
+		t.Fatalf("status failed: %s", out.String())
+	}
+	if statusCell(out.String(), "alpha", "skills", "LOCAL") != "1" || statusCell(out.String(), "alpha", "skills", "UPSTREAM") != "1" {
+		t.Fatalf("expected drift classes: %s", out.String())
+	}
+	out.Reset()
+	if code := app.runSkillsDiff(context.Background(), []string{"--target", "skills"}); code != 0 {
+		t.Fatalf("diff failed: %s", out.String())
+	}
+	if !strings.Contains(out.String(), "local    a.txt") || !strings.Contains(out.String(), "upstream b.txt") {
+		t.Fatalf("expected per-file drift: %s", out.String())
+	}
+}
+
+func TestSkillsStatusUnmanaged(t *testing.T) {
+	app, cfg := newTestApp(t)
+	repoPath := initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	_ = os.MkdirAll(filepath.Join(repoPath, ".codex", "skills"), 0o755)
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runSkillsStatus(context.Background(), []string{"--target", "skills"}); code != 0 {
+		t.Fatalf("status failed: %s", out.String())
+	}
+	if !strings.Contains(out.String(), "unmanaged") {
+		t.Fatalf("expected unmanaged: %s", out.String())
+	}
+	_ = os.WriteFile(filepath.Join(repoPath, ".codex", "skills", fsutil.ManifestName), []byte("{"), 0o644)
+	if code := app.runSkillsStatus(context.Background(), []string{"--target", "skills"}); code == 0 {
+		t.Fatalf("expected manifest error")
+	}
+}
+
+func TestSkillsCleanKeepsUnmanagedFiles(t *testing.T) {
+	app, cfg := newTestApp(t)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "a.txt"), []byte("a"), 0o644)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "b.txt"), []byte("b"), 0o644)
+	repoPath := initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	if code := app.runSkillsSync(context.Background(), []string{"--target", "skills"}); code != 0 {
+		t.Fatalf("sync failed")
+	}
+	dest := filepath.Join(repoPath, ".codex", "skills")
+	_ = os.WriteFile(filepath.Join(dest, "local.txt"), []byte("mine"), 0o644)
+	_ = os.Remove(filepath.Join(cfg.SkillsRoot, "b.txt"))
+	if code := app.runSkillsClean(context.Background(), []string{"--target", "skills"}); code != 0 {
+		t.Fatalf("clean failed")
+	}
+	if _, err := os.Stat(filepath.Join(dest, "local.txt")); err != nil {
+		t.Fatalf("expected local file kept: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "b.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected managed file removed")
+	}
+}
+
+func TestSourceCommit(t *testing.T) {
+	_, cfg := newTestApp(t)
+	runner := buildRunner(cfg, false)
+	if got := sourceCommit(context.Background(), runner, filepath.Join(cfg.ProjectsRoot, "missing")); got != "" {
+		t.Fatalf("expected empty commit, got %q", got)
+	}
+	plain := filepath.Join(t.TempDir(), "plain")
+	_ = os.MkdirAll(plain, 0o755)
+	_ = os.WriteFile(filepath.Join(plain, ".git"), []byte("broken"), 0o644)
+	if got := sourceCommit(context.Background(), runner, plain); got != "" {
+		t.Fatalf("expected empty commit for non repo, got %q", got)
+	}
+}
+
+func TestSkillsSyncMergePolicy(t *testing.T) {
+	app, cfg := newTestApp(t)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "a.txt"), []byte("one\ntwo\n"), 0o644)
+	repoPath := initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	cfg.ConflictPolicy = "merge"
+	writeConfig(t, cfg)
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runSkillsSync(context.Background(), []string{"--target", "skills"}); code != 0 {
+		t.Fatalf("sync failed: %s", out.String())
+	}
+	dest := filepath.Join(repoPath, ".codex", "skills", "a.txt")
+	_ = os.WriteFile(dest, []byte("ONE\ntwo\n"), 0o644)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "a.txt"), []byte("uno\ntwo\n"), 0o644)
+	old := time.Now().Add(-time.Hour)
+	_ = os.Chtimes(dest, old, old)
+	out.Reset()
+	if code := app.runSkillsSync(context.Background(), []string{"--target", "skills"}); code != 0 {
+		t.Fatalf("sync failed: %s", out.String())
+	}
+	if !strings.Contains(out.String(), "conflicts=1") || !strings.Contains(out.String(), "conflict a.txt") {
+		t.Fatalf("expected conflict report: %s", out.String())
+	}
+	if data, _ := os.ReadFile(dest); !strings.Contains(string(data), "<<<<<<< local") {
+		t.Fatalf("expected markers: %q", data)
+	}
+}
@@ -0,0 +1,85 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TT-AIXion/github-kanri/internal/output"
+)
+
+func TestSkillsDiffPatchAndStat(t *testing.T) {
+	app, cfg := newTestApp(t)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "a.md"), []byte("one\ntwo\n"), 0o644)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "img.bin"), []byte{0, 1}, 0o644)
+	repoPath := initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	if code := app.runSkillsSync(context.Background(), []string{"--target", "skills"}); code != 0 {
+		t.Fatalf("sync failed")
+	}
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "a.md"), []byte("one\nTWO\n"), 0o644)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "img.bin"), []byte{0, 2}, 0o644)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "new.md"), []byte("new\n"), 0o644)
+	old := time.Now().Add(-time.Hour)
+	_ = os.Chtimes(filepath.Join(repoPath, ".codex", "skills", "a.md"), old, old)
+
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runSkillsDiff(context.Background(), []string{"--target", "skills", "--patch", "--stat"}); code != 0 {
+		t.Fatalf("diff failed: %s", out.String())
+	}
+	for _, want := range []string{
+		"--- a/a.md", "+++ b/a.md", "@@ -1,2 +1,2 @@", "-two", "+TWO",
+		"--- /dev/null", "+++ b/new.md", "Binary files a/img.bin and b/img.bin differ",
+		"a.md    | +1 -1", "img.bin | Bin", "3 files changed, 2 insertions(+), 1 deletions(-)",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("missing %q in:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if code := app.runSkillsDiff(context.Background(), []string{"--target", "skills", "--patch", "--max-size", "4"}); code != 0 {
+		t.Fatalf("diff failed: %s", out.String())
+	}
+	if !strings.Contains(out.String(), "File a.md too large to diff") {
+		t.Fatalf("expected size limit: %s", out.String())
+	}
+
+	out.Reset()
+	app.Out.JSON = true
+	if code := app.runSkillsDiff(context.Background(), []string{"--target", "skills"}); code != 0 {
+		t.Fatalf("diff failed: %s", out.String())
+	}
+	var env struct {
+		Data []diffResult `json:"data"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &env); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	files := env.Data[0].Files
+	if len(files) != 3 || files[0].Path != "new.md" || files[0].NewHash == "" {
+		t.Fatalf("unexpected files: %+v", files)
+	}
+	if files[1].Path != "a.md" || files[1].OldHash == files[1].NewHash || len(files[1].Hunks) != 1 {
+		t.Fatalf("expected hunks and hashes: %+v", files[1])
+	}
+}
+
+func TestSkillsDiffPatchError(t *testing.T) {
+	app, cfg := newTestApp(t)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "a.md"), []byte("a"), 0o644)
+	repoPath := initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	dest := filepath.Join(repoPath, ".codex", "skills")
+	_ = os.MkdirAll(dest, 0o755)
+	_ = os.Symlink(filepath.Join(dest, "loop"), filepath.Join(dest, "loop"))
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runSkillsDiff(context.Background(), []string{"--target", "skills", "--patch"}); code != 1 {
+		t.Fatalf("expected patch error: %s", out.String())
+	}
+}
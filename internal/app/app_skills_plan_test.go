@@ -0,0 +1,170 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TT-AIXion/github-kanri/internal/fsutil"
+	"github.com/TT-AIXion/github-kanri/internal/output"
+)
+
+func TestSkillsSyncPlanAndApply(t *testing.T) {
+	app, cfg := newTestApp(t)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "a.md"), []byte("a"), 0o644)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "b.md"), []byte("b"), 0o644)
+	repoPath := initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	dest := filepath.Join(repoPath, ".codex", "skills")
+	planPath := filepath.Join(t.TempDir(), "plan.json")
+
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runSkillsSync(context.Background(), []string{"--target", "skills", "--plan", "--out", planPath}); code != 0 {
+		t.Fatalf("plan failed: %s", out.String())
+	}
+	if !strings.Contains(out.String(), "create   a.md") || !strings.Contains(out.String(), "plan: 2 actions in 1 destinations (saved to "+planPath+")") {
+		t.Fatalf("unexpected plan output: %s", out.String())
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Fatalf("plan must not write")
+	}
+	plan, err := readSyncPlan(planPath)
+	if err != nil || len(plan.Entries) != 1 || len(plan.Entries[0].Actions) != 2 || plan.Entries[0].SourceDigest == "" {
+		t.Fatalf("unexpected plan: %+v %v", plan, err)
+	}
+
+	out.Reset()
+	if code := app.runSkillsSync(context.Background(), []string{"--apply", planPath}); code != 0 {
+		t.Fatalf("apply failed: %s", out.String())
+	}
+	if data, err := os.ReadFile(filepath.Join(dest, "a.md")); err != nil || string(data) != "a" {
+		t.Fatalf("expected applied file: %v", err)
+	}
+	out.Reset()
+	if code := app.runSkillsSync(context.Background(), []string{"--apply", planPath}); code != 1 || !strings.Contains(out.String(), "destination changed") {
+		t.Fatalf("expected stale destination: %s", out.String())
+	}
+
+	if code := app.runSkillsSync(context.Background(), []string{"--target", "skills", "--dry-run", "--out", planPath}); code != 0 {
+		t.Fatalf("plan failed")
+	}
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "a.md"), []byte("changed"), 0o644)
+	out.Reset()
+	if code := app.runSkillsSync(context.Background(), []string{"--apply", planPath}); code != 1 || !strings.Contains(out.String(), "source changed") {
+		t.Fatalf("expected stale source: %s", out.String())
+	}
+	if data, _ := os.ReadFile(filepath.Join(dest, "a.md")); string(data) != "a" {
+		t.Fatalf("stale plan must not be applied")
+	}
+
+	out.Reset()
+	app.Out.JSON = true
+	if code := app.runSkillsSync(context.Background(), []string{"--target", "skills", "--plan"}); code != 0 {
+		t.Fatalf("plan failed: %s", out.String())
+	}
+	var env struct {
+		Data syncPlan `json:"data"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &env); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if acts := env.Data.Entries[0].Actions; len(acts) != 1 || acts[0] != (planAction{Path: "a.md", Action: "update"}) {
+		t.Fatalf("unexpected json plan: %+v", env.Data)
+	}
+}
+
+func TestSkillsSyncPlanErrors(t *testing.T) {
+	app, cfg := newTestApp(t)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "a.md"), []byte("a"), 0o644)
+	repoPath := initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	dest := filepath.Join(repoPath, ".codex", "skills")
+	_ = os.MkdirAll(dest, 0o755)
+	_ = os.WriteFile(filepath.Join(dest, "a.md"), []byte("local"), 0o644)
+	cfg.ConflictPolicy = "fail"
+	writeConfig(t, cfg)
+	planPath := filepath.Join(t.TempDir(), "plan.json")
+
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runSkillsSync(context.Background(), []string{"--target", "skills", "--plan", "--out", planPath}); code != 1 || !strings.Contains(out.String(), "conflict detected") {
+		t.Fatalf("expected conflict in plan: %s", out.String())
+	}
+	if code := app.runSkillsSync(context.Background(), []string{"--apply", planPath}); code != 1 {
+		t.Fatalf("expected apply refusal for plan with errors")
+	}
+	if code := app.runSkillsSync(context.Background(), []string{"--apply", planPath, "--force"}); code != 1 {
+		t.Fatalf("expected flag combination error")
+	}
+	if code := app.runSkillsSync(context.Background(), []string{"--apply", filepath.Join(t.TempDir(), "missing.json")}); code != 1 {
+		t.Fatalf("expected missing plan error")
+	}
+	bad := filepath.Join(t.TempDir(), "bad.json")
+	_ = os.WriteFile(bad, []byte("{"), 0o644)
+	if code := app.runSkillsSync(context.Background(), []string{"--apply", bad}); code != 1 {
+		t.Fatalf("expected invalid plan error")
+	}
+	_ = os.WriteFile(bad, []byte(`{"version":99}`), 0o644)
+	out.Reset()
+	if code := app.runSkillsSync(context.Background(), []string{"--apply", bad}); code != 1 || !strings.Contains(out.String(), "unsupported plan version") {
+		t.Fatalf("expected version error: %s", out.String())
+	}
+	_ = os.WriteFile(bad, []byte(`{"version":1,"entries":[{"repo":"alpha","target":"missing","dest":"x"}]}`), 0o644)
+	if code := app.runSkillsSync(context.Background(), []string{"--apply", bad}); code != 1 {
+		t.Fatalf("expected unknown target error")
+	}
+	_ = os.WriteFile(bad, []byte(`{"version":1,"entries":[{"repo":"alpha","target":"skills","dest":"/elsewhere"}]}`), 0o644)
+	out.Reset()
+	if code := app.runSkillsSync(context.Background(), []string{"--apply", bad}); code != 1 || !strings.Contains(out.String(), "no longer matches") {
+		t.Fatalf("expected unmatched entry error: %s", out.String())
+	}
+	if code := app.runSkillsSync(context.Background(), []string{"--plan", "--out", filepath.Join(t.TempDir(), "missing", "plan.json"), "--force"}); code != 1 {
+		t.Fatalf("expected write error")
+	}
+}
+
+func TestSkillsLinkDryRunPlansLinks(t *testing.T) {
+	app, cfg := newTestApp(t)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "a.md"), []byte("a"), 0o644)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "n.md.tmpl"), []byte("{{ .Repo.Name }}"), 0o644)
+	_ = initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	cfg.SyncTargets[0].Template = true
+	writeConfig(t, cfg)
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runSkillsLink(context.Background(), []string{"--target", "skills", "--dry-run"}); code != 0 {
+		t.Fatalf("dry run failed: %s", out.String())
+	}
+	if !strings.Contains(out.String(), "link     a.md") || !strings.Contains(out.String(), "create   n.md") {
+		t.Fatalf("expected link verbs: %s", out.String())
+	}
+}
+
+func TestStaleReason(t *testing.T) {
+	base := planEntry{Src: "s", Mode: "copy", ConflictPolicy: "fail", SourceDigest: "x", DestDigest: "y", Actions: []planAction{{Path: "a", Action: "create"}}}
+	changed := base
+	changed.Mode = "link"
+	if staleReason(base, changed) != "target settings changed" {
+		t.Fatalf("expected settings change")
+	}
+	changed = base
+	changed.Actions = []planAction{{Path: "a", Action: "update"}}
+	if staleReason(base, changed) != "actions changed" {
+		t.Fatalf("expected action change")
+	}
+	changed.Actions = nil
+	if staleReason(base, changed) != "actions changed" {
+		t.Fatalf("expected action count change")
+	}
+	changed = base
+	changed.Error = "boom"
+	if staleReason(base, changed) != "boom" || staleReason(base, base) != "" {
+		t.Fatalf("unexpected stale reason")
+	}
+	if planVerb(syncJob{}, fsutil.FileChange{Action: fsutil.ActionConflict}) != "conflict" {
+		t.Fatalf("unexpected verb")
+	}
+}
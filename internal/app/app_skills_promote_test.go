@@ -0,0 +1,184 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TT-AIXion/github-kanri/internal/output"
+)
+
+func TestSkillsPromote(t *testing.T) {
+	app, cfg := newTestApp(t)
+	initGitRepo(t, cfg.SkillsRoot, true)
+	writeSkillManifest(t, filepath.Join(cfg.SkillsRoot, "review"), "---\nname: review\ndescription: Review code\n---\n")
+	_ = runGit(cfg.SkillsRoot, "add", ".")
+	_ = runGit(cfg.SkillsRoot, "commit", "-m", "review")
+	alpha := initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runSkillsSync(context.Background(), nil); code != 0 {
+		t.Fatalf("sync failed: %s", out.String())
+	}
+	dest := filepath.Join(alpha, ".codex", "skills")
+	edited := "---\nname: review\ndescription: Review code carefully\n---\n"
+	_ = os.WriteFile(filepath.Join(dest, "review", "SKILL.md"), []byte(edited), 0o644)
+	_ = os.WriteFile(filepath.Join(dest, "review", "notes.md"), []byte("notes"), 0o644)
+	srcSkill := filepath.Join(cfg.SkillsRoot, "review", "SKILL.md")
+
+	out.Reset()
+	if code := app.runSkillsPromote(context.Background(), []string{"alpha", "--dry-run"}); code != 0 || !strings.Contains(out.String(), "would promote alpha skills review/SKILL.md") {
+		t.Fatalf("dry-run failed: %d %s", code, out.String())
+	}
+	if data, _ := os.ReadFile(srcSkill); string(data) == edited {
+		t.Fatalf("dry-run changed the source")
+	}
+
+	out.Reset()
+	if code := app.runSkillsPromote(context.Background(), []string{"alpha", "--branch", "promote/alpha", "--commit"}); code != 0 {
+		t.Fatalf("promote failed: %s", out.String())
+	}
+	if data, _ := os.ReadFile(srcSkill); string(data) != edited {
+		t.Fatalf("source not updated: %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.SkillsRoot, "review", "notes.md")); !os.IsNotExist(err) {
+		t.Fatalf("dest-only file promoted without --files: %v", err)
+	}
+	if !strings.Contains(out.String(), "committed") || !strings.Contains(out.String(), "promote/alpha") {
+		t.Fatalf("unexpected output: %s", out.String())
+	}
+	msg, _ := exec.Command("git", "-C", cfg.SkillsRoot, "log", "-1", "--format=%s").Output()
+	if strings.TrimSpace(string(msg)) != "Promote skill changes from alpha" {
+		t.Fatalf("unexpected commit message: %q", msg)
+	}
+	if branch, _ := exec.Command("git", "-C", cfg.SkillsRoot, "rev-parse", "--abbrev-ref", "HEAD").Output(); strings.TrimSpace(string(branch)) != "promote/alpha" {
+		t.Fatalf("unexpected branch: %q", branch)
+	}
+
+	out.Reset()
+	if code := app.runSkillsStatus(context.Background(), nil); code != 0 || statusCell(out.String(), "alpha", "skills", "LOCAL") != "0" || statusCell(out.String(), "alpha", "skills", "UPSTREAM") != "0" {
+		t.Fatalf("expected no local edits after promote: %s", out.String())
+	}
+
+	out.Reset()
+	if code := app.runSkillsPromote(context.Background(), []string{"alpha", "--files", "review/notes.md"}); code != 0 {
+		t.Fatalf("promote --files failed: %s", out.String())
+	}
+	if data, _ := os.ReadFile(filepath.Join(cfg.SkillsRoot, "review", "notes.md")); string(data) != "notes" {
+		t.Fatalf("dest-only file not promoted: %q", data)
+	}
+
+	out.Reset()
+	if code := app.runSkillsPromote(context.Background(), []string{"alpha"}); code != 0 || !strings.Contains(out.String(), "nothing to promote") {
+		t.Fatalf("expected nothing to promote: %d %s", code, out.String())
+	}
+
+	_ = os.WriteFile(srcSkill, []byte("upstream"), 0o644)
+	_ = os.WriteFile(filepath.Join(dest, "review", "SKILL.md"), []byte("local"), 0o644)
+	out.Reset()
+	if code := app.runSkillsPromote(context.Background(), []string{"alpha"}); code != 1 || !strings.Contains(out.String(), "also changed in the source") {
+		t.Fatalf("expected conflict: %d %s", code, out.String())
+	}
+	if data, _ := os.ReadFile(srcSkill); string(data) != "upstream" {
+		t.Fatalf("conflict changed the source: %q", data)
+	}
+	out.Reset()
+	if code := app.runSkillsPromote(context.Background(), []string{"alpha", "--force"}); code != 0 {
+		t.Fatalf("forced promote failed: %s", out.String())
+	}
+	if data, _ := os.ReadFile(srcSkill); string(data) != "local" {
+		t.Fatalf("forced promote did not update source: %q", data)
+	}
+
+	_ = os.WriteFile(filepath.Join(dest, "review", "SKILL.md"), []byte("again"), 0o644)
+	out.Reset()
+	if code := app.runSkillsPromote(context.Background(), []string{"alpha", "--branch", "promote/alpha"}); code != 1 || !strings.Contains(out.String(), "branch promote/alpha already exists") {
+		t.Fatalf("expected existing branch error: %d %s", code, out.String())
+	}
+	if data, _ := os.ReadFile(srcSkill); string(data) != "local" {
+		t.Fatalf("existing branch error changed the source: %q", data)
+	}
+
+	hook := filepath.Join(cfg.SkillsRoot, ".git", "hooks", "pre-commit")
+	_ = os.MkdirAll(filepath.Dir(hook), 0o755)
+	_ = os.WriteFile(hook, []byte("#!/bin/sh\nexit 1\n"), 0o755)
+	out.Reset()
+	if code := app.runSkillsPromote(context.Background(), []string{"alpha", "--branch", "promote/hook", "--commit"}); code != 1 || !strings.Contains(out.String(), "commit failed") {
+		t.Fatalf("expected commit error: %d %s", code, out.String())
+	}
+	if branch, _ := exec.Command("git", "-C", cfg.SkillsRoot, "rev-parse", "--abbrev-ref", "HEAD").Output(); strings.TrimSpace(string(branch)) != "promote/alpha" {
+		t.Fatalf("original branch not restored: %q", branch)
+	}
+	if err := exec.Command("git", "-C", cfg.SkillsRoot, "rev-parse", "--verify", "--quiet", "refs/heads/promote/hook").Run(); err == nil {
+		t.Fatalf("failed promote kept its branch")
+	}
+}
+
+func TestSkillsPromoteUnmanagedAndErrors(t *testing.T) {
+	app, cfg := newTestApp(t)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "a.md"), []byte("a"), 0o644)
+	alpha := initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpine"), true)
+	dest := filepath.Join(alpha, ".codex", "skills")
+	_ = os.MkdirAll(dest, 0o755)
+	_ = os.WriteFile(filepath.Join(dest, "a.md"), []byte("local"), 0o644)
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+
+	if code := app.runSkillsPromote(context.Background(), nil); code != 1 {
+		t.Fatalf("expected pattern error")
+	}
+	out.Reset()
+	if code := app.runSkillsPromote(context.Background(), []string{"alp"}); code != 2 {
+		t.Fatalf("expected multiple matches: %d %s", code, out.String())
+	}
+	out.Reset()
+	if code := app.runSkillsPromote(context.Background(), []string{"alpha", "--target", "missing"}); code != 1 {
+		t.Fatalf("expected target error")
+	}
+
+	out.Reset()
+	if code := app.runSkillsPromote(context.Background(), []string{"alpha"}); code != 0 || !strings.Contains(out.String(), "unmanaged destination") {
+		t.Fatalf("expected unmanaged skip: %d %s", code, out.String())
+	}
+	out.Reset()
+	if code := app.runSkillsPromote(context.Background(), []string{"alpha", "--files", "a.md", "--commit"}); code != 1 || !strings.Contains(out.String(), "not a git repository") {
+		t.Fatalf("expected git error: %d %s", code, out.String())
+	}
+	if data, _ := os.ReadFile(filepath.Join(cfg.SkillsRoot, "a.md")); string(data) != "a" {
+		t.Fatalf("failed promote changed the source: %q", data)
+	}
+
+	out.Reset()
+	app.Out = output.Writer{Out: &out, ErrW: &out, JSON: true}
+	if code := app.runSkillsPromote(context.Background(), []string{"alpha", "--files", "a.md"}); code != 0 {
+		t.Fatalf("promote failed: %s", out.String())
+	}
+	var env struct {
+		Message string         `json:"message"`
+		Data    promoteSummary `json:"data"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &env); err != nil {
+		t.Fatalf("json: %v %s", err, out.String())
+	}
+	if env.Message != "skills promote" || env.Data.Repo != "alpha" || env.Data.Run == "" || len(env.Data.Results) != 1 || len(env.Data.Results[0].Files) != 1 {
+		t.Fatalf("unexpected summary: %+v", env.Data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(cfg.SkillsRoot, "a.md")); string(data) != "local" {
+		t.Fatalf("source not updated: %q", data)
+	}
+
+	out.Reset()
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runSkillsRollback(context.Background(), []string{"--run", env.Data.Run}); code != 0 {
+		t.Fatalf("rollback failed: %s", out.String())
+	}
+	if data, _ := os.ReadFile(filepath.Join(cfg.SkillsRoot, "a.md")); string(data) != "a" {
+		t.Fatalf("rollback did not restore source: %q", data)
+	}
+}
@@ -0,0 +1,161 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TT-AIXion/github-kanri/internal/config"
+	"github.com/TT-AIXion/github-kanri/internal/fsutil"
+	"github.com/TT-AIXion/github-kanri/internal/output"
+)
+
+func TestSkillsPrune(t *testing.T) {
+	app, cfg := newTestApp(t)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "a.md"), []byte("a"), 0o644)
+	alpha := initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	beta := initGitRepo(t, filepath.Join(cfg.ReposRoot, "beta"), true)
+	cfg.SyncTargets = append(cfg.SyncTargets, config.SyncTarget{Name: "old", Src: cfg.SkillsRoot, Dest: []string{".old/skills"}})
+	writeConfig(t, cfg)
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runSkillsSync(context.Background(), nil); code != 0 {
+		t.Fatalf("sync failed: %s", out.String())
+	}
+	_ = os.WriteFile(filepath.Join(alpha, ".old", "skills", "notes.md"), []byte("mine"), 0o644)
+
+	out.Reset()
+	if code := app.runSkillsPrune(context.Background(), nil); code != 0 || !strings.Contains(out.String(), "no orphaned destinations") {
+		t.Fatalf("expected nothing to prune: %d %s", code, out.String())
+	}
+
+	cfg.SyncTargets = cfg.SyncTargets[:1]
+	cfg.SyncTargets[0].Dest = []string{".codex/skills", ".agents/skills"}
+	writeConfig(t, cfg)
+	out.Reset()
+	if code := app.runSkillsPrune(context.Background(), nil); code != 0 {
+		t.Fatalf("dry-run prune failed: %s", out.String())
+	}
+	text := out.String()
+	if !strings.Contains(text, "would prune alpha .old/skills files=1 target=old") || !strings.Contains(text, "would prune beta .old/skills") || !strings.Contains(text, "kept     notes.md") || !strings.Contains(text, "rerun with --force") {
+		t.Fatalf("unexpected dry-run output: %s", text)
+	}
+	if _, err := os.Stat(filepath.Join(beta, ".old", "skills", "a.md")); err != nil {
+		t.Fatalf("dry-run removed files: %v", err)
+	}
+	if code := app.runSkillsPrune(context.Background(), []string{"--force", "--dry-run"}); code != 1 {
+		t.Fatalf("expected flag conflict")
+	}
+
+	out.Reset()
+	app.Out = output.Writer{Out: &out, ErrW: &out, JSON: true}
+	if code := app.runSkillsPrune(context.Background(), []string{"--force", "--only", "beta"}); code != 0 {
+		t.Fatalf("prune failed: %s", out.String())
+	}
+	var env struct {
+		Data []pruneResult `json:"data"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &env); err != nil {
+		t.Fatalf("json: %v %s", err, out.String())
+	}
+	if len(env.Data) != 1 || env.Data[0].Repo != "beta" || env.Data[0].Path != ".old/skills" || env.Data[0].Run == "" || env.Data[0].SyncedAt == nil || len(env.Data[0].Removed) != 1 {
+		t.Fatalf("unexpected prune result: %+v", env.Data)
+	}
+	if _, err := os.Stat(filepath.Join(beta, ".old")); !os.IsNotExist(err) {
+		t.Fatalf("expected orphaned destination removed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(beta, ".codex", "skills", "a.md")); err != nil {
+		t.Fatalf("configured destination touched: %v", err)
+	}
+
+	gamma := initGitRepo(t, filepath.Join(cfg.ReposRoot, "gamma"), true)
+	_ = os.MkdirAll(filepath.Join(gamma, ".old", "skills"), 0o755)
+	_ = os.WriteFile(filepath.Join(gamma, ".old", "skills", "a.md"), []byte("edited"), 0o644)
+	hash, _ := fsutil.FileHash(filepath.Join(cfg.SkillsRoot, "a.md"))
+	_ = fsutil.WriteManifest(filepath.Join(gamma, ".old", "skills"), fsutil.Manifest{Target: "old", Mode: fsutil.ModeCopy, Files: map[string]string{"a.md": hash}}, false)
+
+	out.Reset()
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runSkillsPrune(context.Background(), []string{"--force"}); code != 0 {
+		t.Fatalf("prune failed: %s", out.String())
+	}
+	if _, err := os.Stat(filepath.Join(alpha, ".old", "skills", "a.md")); !os.IsNotExist(err) {
+		t.Fatalf("managed file kept: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(alpha, ".old", "skills", "notes.md")); string(data) != "mine" {
+		t.Fatalf("unmanaged file removed: %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(gamma, ".old", "skills", "a.md")); string(data) != "edited" {
+		t.Fatalf("locally edited file removed: %q", data)
+	}
+	if !strings.Contains(out.String(), "pruned gamma .old/skills files=0") {
+		t.Fatalf("unexpected prune output: %s", out.String())
+	}
+
+	out.Reset()
+	if code := app.runSkillsRollback(context.Background(), nil); code != 0 {
+		t.Fatalf("rollback failed: %s", out.String())
+	}
+	if _, ok, _ := fsutil.ReadManifest(filepath.Join(alpha, ".old", "skills")); !ok {
+		t.Fatalf("rollback did not restore the manifest")
+	}
+}
+
+func TestSkillsPruneOverlapAndGuard(t *testing.T) {
+	app, cfg := newTestApp(t)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "a.md"), []byte("a"), 0o644)
+	alpha := initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runSkillsSync(context.Background(), nil); code != 0 {
+		t.Fatalf("sync failed: %s", out.String())
+	}
+	cfg.SyncTargets[0].Dest = []string{".codex"}
+	writeConfig(t, cfg)
+	out.Reset()
+	if code := app.runSkillsPrune(context.Background(), []string{"--force"}); code != 0 || !strings.Contains(out.String(), "alpha .codex/skills skipped: overlaps configured destination .codex") {
+		t.Fatalf("expected overlap skip: %d %s", code, out.String())
+	}
+	if _, err := os.Stat(filepath.Join(alpha, ".codex", "skills", "a.md")); err != nil {
+		t.Fatalf("overlapping destination touched: %v", err)
+	}
+
+	cfg.SyncTargets[0].Dest = []string{".agents"}
+	cfg.DenyPaths = []string{filepath.Join(alpha, ".codex", "**")}
+	writeConfig(t, cfg)
+	out.Reset()
+	if code := app.runSkillsPrune(context.Background(), []string{"--force"}); code != 1 || !strings.Contains(out.String(), "ERR alpha .codex/skills:") {
+		t.Fatalf("expected guard error: %d %s", code, out.String())
+	}
+	if _, err := os.Stat(filepath.Join(alpha, ".codex", "skills", "a.md")); err != nil {
+		t.Fatalf("guarded destination touched: %v", err)
+	}
+}
+
+func TestSkillsPruneSkipsNestedRepos(t *testing.T) {
+	app, cfg := newTestApp(t)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "a.md"), []byte("a"), 0o644)
+	outer := initGitRepo(t, filepath.Join(cfg.ReposRoot, "outer"), true)
+	inner := initGitRepo(t, filepath.Join(outer, "packages", "inner"), true)
+	cfg.SyncTargets[0].ExcludeRepos = []string{"outer"}
+	writeConfig(t, cfg)
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runSkillsSync(context.Background(), nil); code != 0 {
+		t.Fatalf("sync failed: %s", out.String())
+	}
+	if _, ok, _ := fsutil.ReadManifest(filepath.Join(inner, ".codex", "skills")); !ok {
+		t.Fatalf("expected nested repo to be synced: %s", out.String())
+	}
+	out.Reset()
+	if code := app.runSkillsPrune(context.Background(), []string{"--force"}); code != 0 || !strings.Contains(out.String(), "no orphaned destinations") {
+		t.Fatalf("nested repo destination reported as orphan: %d %s", code, out.String())
+	}
+	if _, err := os.Stat(filepath.Join(inner, ".codex", "skills", "a.md")); err != nil {
+		t.Fatalf("nested repo destination removed: %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TT-AIXion/github-kanri/internal/config"
+	"github.com/TT-AIXion/github-kanri/internal/output"
+)
+
+func TestSkillsRepoConfigOverrides(t *testing.T) {
+	app, cfg := newTestApp(t)
+	for _, rel := range []string{"review/SKILL.md", "review/drafts/wip.md", "style/SKILL.md", "legacy/SKILL.md", "notes.md"} {
+		path := filepath.Join(cfg.SkillsRoot, rel)
+		_ = os.MkdirAll(filepath.Dir(path), 0o755)
+		_ = os.WriteFile(path, []byte(rel), 0o644)
+	}
+	ciSrc := filepath.Join(cfg.ProjectsRoot, "shared-ci")
+	_ = os.MkdirAll(ciSrc, 0o755)
+	_ = os.WriteFile(filepath.Join(ciSrc, "ci.yml"), []byte("ci"), 0o644)
+	cfg.SyncTargets = append(cfg.SyncTargets, config.SyncTarget{Name: "ci", Src: ciSrc, Dest: []string{".github/workflows"}, Repos: []string{"api-*"}})
+	writeConfig(t, cfg)
+	api := initGitRepo(t, filepath.Join(cfg.ReposRoot, "api-users"), true)
+	web := initGitRepo(t, filepath.Join(cfg.ReposRoot, "web"), true)
+	_ = os.WriteFile(filepath.Join(api, config.RepoConfigName), []byte(`{"excludeTargets": ["ci"]}`), 0o644)
+	webConfig := `{
+  "targets": ["ci"],
+  "skills": ["review", "style"],
+  "excludeSkills": ["style"],
+  "exclude": ["**/drafts/**"],
+  "overrides": {"skills": {"dest": [".claude/skills"]}}
+}`
+	_ = os.WriteFile(filepath.Join(web, config.RepoConfigName), []byte(webConfig), 0o644)
+
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runSkillsSync(context.Background(), nil); code != 0 {
+		t.Fatalf("sync failed: %s", out.String())
+	}
+	exists := func(path string) bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}
+	if !exists(filepath.Join(api, ".codex", "skills", "legacy", "SKILL.md")) || !exists(filepath.Join(api, ".codex", "skills", "review", "drafts", "wip.md")) || exists(filepath.Join(api, ".github")) {
+		t.Fatalf("unexpected api sync: %s", out.String())
+	}
+	webDest := filepath.Join(web, ".claude", "skills")
+	if !exists(filepath.Join(webDest, "review", "SKILL.md")) || !exists(filepath.Join(web, ".github", "workflows", "ci.yml")) {
+		t.Fatalf("expected review skill and opted-in ci in web: %s", out.String())
+	}
+	if !exists(filepath.Join(webDest, "notes.md")) {
+		t.Fatalf("files outside skill directories should still sync")
+	}
+	for _, rel := range []string{"style", "legacy", "review/drafts"} {
+		if exists(filepath.Join(webDest, rel)) {
+			t.Fatalf("%s should not be synced into web", rel)
+		}
+	}
+	if exists(filepath.Join(web, ".codex")) {
+		t.Fatalf("dest override should replace the global dest")
+	}
+
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "style", "extra.md"), []byte("extra"), 0o644)
+	out.Reset()
+	if code := app.runSkillsVerify(context.Background(), []string{"--only", "web"}); code != 0 {
+		t.Fatalf("expected web to ignore excluded skills: %s", out.String())
+	}
+	out.Reset()
+	if code := app.runSkillsDiff(context.Background(), []string{"--only", "web"}); code != 0 || strings.Contains(out.String(), "added=") {
+		t.Fatalf("unexpected web diff: %s", out.String())
+	}
+
+	_ = os.WriteFile(filepath.Join(web, config.RepoConfigName), []byte(`{"skills": ["legacy"], "overrides": {"skills": {"dest": [".claude/skills"]}}}`), 0o644)
+	out.Reset()
+	if code := app.runSkillsClean(context.Background(), []string{"--target", "skills", "--only", "web"}); code != 0 {
+		t.Fatalf("clean failed: %s", out.String())
+	}
+	if exists(filepath.Join(webDest, "review", "SKILL.md")) || !exists(filepath.Join(webDest, "notes.md")) {
+		t.Fatalf("clean should remove skills the repo opted out of")
+	}
+
+	_ = os.WriteFile(filepath.Join(web, config.RepoConfigName), []byte(`{"overrides": {"nope": {}}}`), 0o644)
+	out.Reset()
+	if code := app.runSkillsSync(context.Background(), nil); code != 1 || !strings.Contains(out.String(), "web: .gkn.json: unknown target nope") {
+		t.Fatalf("expected unknown target error: %s", out.String())
+	}
+	out.Reset()
+	if code := app.runSkillsDiff(context.Background(), nil); code != exitPartial || !strings.Contains(out.String(), "web: .gkn.json: unknown target nope") {
+		t.Fatalf("expected partial diff failure: %s", out.String())
+	}
+	if code := app.runSkillsVerify(context.Background(), []string{"--only", "web"}); code != 1 {
+		t.Fatalf("expected verify failure")
+	}
+	if code := app.runSkillsClean(context.Background(), []string{"--only", "web"}); code != 1 {
+		t.Fatalf("expected clean failure")
+	}
+	_ = os.WriteFile(filepath.Join(web, config.RepoConfigName), []byte(`{"overrides": {"skills": {"dest": ["../elsewhere"]}}}`), 0o644)
+	out.Reset()
+	if code := app.runSkillsSync(context.Background(), nil); code != 1 || !strings.Contains(out.String(), "dest must be a path inside the repo") {
+		t.Fatalf("expected dest escape error: %s", out.String())
+	}
+}
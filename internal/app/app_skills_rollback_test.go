@@ -0,0 +1,162 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TT-AIXion/github-kanri/internal/config"
+	"github.com/TT-AIXion/github-kanri/internal/fsutil"
+	"github.com/TT-AIXion/github-kanri/internal/output"
+)
+
+func TestSkillsSyncAtomicRollsBack(t *testing.T) {
+	app, cfg := newTestApp(t)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "a.txt"), []byte("a"), 0o644)
+	repoPath := initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	cfg.SyncTargets = append(cfg.SyncTargets, config.SyncTarget{Name: "nested", Src: cfg.SkillsRoot, Dest: []string{".codex/skills/a.txt/nested"}})
+	writeConfig(t, cfg)
+
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runSkillsSync(context.Background(), []string{"--atomic"}); code != 1 {
+		t.Fatalf("expected failure: %s", out.String())
+	}
+	if !strings.Contains(out.String(), "rolled back run") {
+		t.Fatalf("expected rollback report: %s", out.String())
+	}
+	if _, err := os.Lstat(filepath.Join(repoPath, ".codex")); !os.IsNotExist(err) {
+		t.Fatalf("expected earlier changes rolled back: %v", err)
+	}
+
+	out.Reset()
+	if code := app.runSkillsSync(context.Background(), nil); code != 1 {
+		t.Fatalf("expected failure: %s", out.String())
+	}
+	if !strings.Contains(out.String(), "gkn skills rollback --run") {
+		t.Fatalf("expected rollback hint: %s", out.String())
+	}
+	if _, err := os.Stat(filepath.Join(repoPath, ".codex", "skills", "a.txt")); err != nil {
+		t.Fatalf("expected partial sync without --atomic: %v", err)
+	}
+	out.Reset()
+	if code := app.runSkillsRollback(context.Background(), nil); code != 0 {
+		t.Fatalf("rollback failed: %s", out.String())
+	}
+	if _, err := os.Lstat(filepath.Join(repoPath, ".codex")); !os.IsNotExist(err) {
+		t.Fatalf("expected partial sync undone: %v", err)
+	}
+	out.Reset()
+	if code := app.runSkillsRollback(context.Background(), nil); code != 1 || !strings.Contains(out.String(), "no sync run") {
+		t.Fatalf("expected nothing left to roll back: %s", out.String())
+	}
+}
+
+func TestSkillsSyncAtomicPreflight(t *testing.T) {
+	app, cfg := newTestApp(t)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "a.txt"), []byte("upstream"), 0o644)
+	alpha := initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	beta := initGitRepo(t, filepath.Join(cfg.ReposRoot, "beta"), true)
+	_ = os.MkdirAll(filepath.Join(beta, ".codex", "skills"), 0o755)
+	_ = os.WriteFile(filepath.Join(beta, ".codex", "skills", "a.txt"), []byte("local"), 0o644)
+	cfg.ConflictPolicy = "fail"
+	writeConfig(t, cfg)
+
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runSkillsSync(context.Background(), []string{"--atomic"}); code != 1 || !strings.Contains(out.String(), "nothing was changed") {
+		t.Fatalf("expected preflight conflict: %s", out.String())
+	}
+	if _, err := os.Lstat(filepath.Join(alpha, ".codex")); !os.IsNotExist(err) {
+		t.Fatalf("expected alpha untouched: %v", err)
+	}
+	root, _ := journalRoot()
+	if journals, _ := fsutil.ListJournals(root); len(journals) != 0 {
+		t.Fatalf("expected no journal: %+v", journals)
+	}
+}
+
+func TestSkillsRollbackRestoresOverwrittenFiles(t *testing.T) {
+	app, cfg := newTestApp(t)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "a.txt"), []byte("v1"), 0o644)
+	repoPath := initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	dest := filepath.Join(repoPath, ".codex", "skills", "a.txt")
+
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runSkillsSync(context.Background(), nil); code != 0 {
+		t.Fatalf("sync failed: %s", out.String())
+	}
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "a.txt"), []byte("v2"), 0o644)
+	app.Out.JSON = true
+	out.Reset()
+	if code := app.runSkillsSync(context.Background(), nil); code != 0 {
+		t.Fatalf("sync failed: %s", out.String())
+	}
+	var synced struct {
+		Data []syncResult `json:"data"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &synced); err != nil || len(synced.Data) != 1 || synced.Data[0].Run == "" {
+		t.Fatalf("expected run id: %s %v", out.String(), err)
+	}
+	run := synced.Data[0].Run
+	synced.Data = nil
+	out.Reset()
+	if code := app.runSkillsSync(context.Background(), nil); code != 0 {
+		t.Fatalf("sync failed: %s", out.String())
+	}
+	if err := json.Unmarshal(out.Bytes(), &synced); err != nil || len(synced.Data) != 1 || synced.Data[0].Run != "" {
+		t.Fatalf("expected no run for a sync without changes: %s %v", out.String(), err)
+	}
+
+	out.Reset()
+	if code := app.runSkillsRollback(context.Background(), []string{"--list"}); code != 0 {
+		t.Fatalf("list failed: %s", out.String())
+	}
+	var listed struct {
+		Data []fsutil.JournalMeta `json:"data"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &listed); err != nil || len(listed.Data) != 2 || listed.Data[0].ID != run {
+		t.Fatalf("unexpected list: %s %v", out.String(), err)
+	}
+	app.Out.JSON = false
+
+	_ = os.WriteFile(dest, []byte("edited"), 0o644)
+	out.Reset()
+	if code := app.runSkillsRollback(context.Background(), []string{"--run", run}); code != 1 || !strings.Contains(out.String(), "changed  "+dest) {
+		t.Fatalf("expected drift refusal: %s", out.String())
+	}
+	if data, _ := os.ReadFile(dest); string(data) != "edited" {
+		t.Fatalf("expected edit kept, got %q", data)
+	}
+	out.Reset()
+	if code := app.runSkillsRollback(context.Background(), []string{"--run", run, "--force"}); code != 0 {
+		t.Fatalf("rollback failed: %s", out.String())
+	}
+	if data, _ := os.ReadFile(dest); string(data) != "v1" {
+		t.Fatalf("expected v1 restored, got %q", data)
+	}
+	if code := app.runSkillsRollback(context.Background(), []string{"--run", run}); code != 1 {
+		t.Fatalf("expected already rolled back error")
+	}
+	if code := app.runSkillsRollback(context.Background(), []string{"--run", "missing"}); code != 1 {
+		t.Fatalf("expected unknown run error")
+	}
+	out.Reset()
+	if code := app.runSkillsRollback(context.Background(), []string{"--list"}); code != 0 || !strings.Contains(out.String(), "rolled-back") {
+		t.Fatalf("unexpected list: %s", out.String())
+	}
+
+	cfg.DenyPaths = []string{repoPath + "/**"}
+	writeConfig(t, cfg)
+	if code := app.runSkillsRollback(context.Background(), nil); code != 1 {
+		t.Fatalf("expected guard refusal")
+	}
+	if code := app.runSkillsRollback(context.Background(), []string{"--bogus"}); code != 1 {
+		t.Fatalf("expected flag error")
+	}
+}
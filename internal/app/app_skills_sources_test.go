@@ -0,0 +1,124 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TT-AIXion/github-kanri/internal/config"
+	"github.com/TT-AIXion/github-kanri/internal/output"
+)
+
+func commitSkill(t *testing.T, repoPath, content, tag string) {
+	t.Helper()
+	_ = os.MkdirAll(filepath.Join(repoPath, "skills"), 0o755)
+	_ = os.WriteFile(filepath.Join(repoPath, "skills", "a.md"), []byte(content), 0o644)
+	if err := runGit(repoPath, "add", "."); err != nil {
+		t.Fatalf("git add: %v", err)
+	}
+	if err := runGit(repoPath, "commit", "-m", content); err != nil {
+		t.Fatalf("git commit: %v", err)
+	}
+	if err := runGit(repoPath, "tag", tag); err != nil {
+		t.Fatalf("git tag: %v", err)
+	}
+}
+
+func TestSkillsPerTargetSources(t *testing.T) {
+	app, cfg := newTestApp(t)
+	upstream := initGitRepo(t, filepath.Join(t.TempDir(), "upstream"), true)
+	commitSkill(t, upstream, "v1", "v1")
+	commitSkill(t, upstream, "v2", "v2")
+	alpha := initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	cfg.SyncTargets = []config.SyncTarget{
+		{Name: "stable", Remote: upstream, Ref: "v1", Src: "skills", Dest: []string{".codex/stable"}},
+		{Name: "edge", Remote: upstream, Dest: []string{".codex/edge"}},
+	}
+	writeConfig(t, cfg)
+
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runSkillsClone(context.Background(), nil); code != 0 {
+		t.Fatalf("clone failed: %s", out.String())
+	}
+	if strings.Count(out.String(), " cloned (") != 2 {
+		t.Fatalf("expected two source checkouts: %s", out.String())
+	}
+	if code := app.runSkillsSync(context.Background(), nil); code != 0 {
+		t.Fatalf("sync failed: %s", out.String())
+	}
+	read := func(rel string) string {
+		data, _ := os.ReadFile(filepath.Join(alpha, ".codex", rel))
+		return string(data)
+	}
+	if read("stable/a.md") != "v1" || read("edge/skills/a.md") != "v2" {
+		t.Fatalf("unexpected sources: %q %q", read("stable/a.md"), read("edge/skills/a.md"))
+	}
+	if _, err := os.Stat(filepath.Join(alpha, ".codex", "edge", ".git")); !os.IsNotExist(err) {
+		t.Fatalf("checkout .git must not be synced")
+	}
+
+	out.Reset()
+	if code := app.runSkillsPin(context.Background(), []string{"--target", "stable", "--ref", "v2"}); code != 0 {
+		t.Fatalf("pin failed: %s", out.String())
+	}
+	path, _ := config.DefaultConfigPath()
+	saved, err := config.Load(path)
+	if err != nil || saved.SyncTargets[0].Ref != "v2" || saved.SyncTargets[1].Ref != "" || saved.SyncTargets[0].Src != "skills" {
+		t.Fatalf("unexpected saved config: %+v %v", saved.SyncTargets, err)
+	}
+	if code := app.runSkillsSync(context.Background(), []string{"--target", "stable"}); code != 0 {
+		t.Fatalf("sync failed: %s", out.String())
+	}
+	if read("stable/a.md") != "v2" {
+		t.Fatalf("expected pinned source: %q", read("stable/a.md"))
+	}
+
+	out.Reset()
+	if code := app.runSkillsClone(context.Background(), nil); code != 0 || strings.Count(out.String(), " updated (") != 2 {
+		t.Fatalf("expected sources updated: %s", out.String())
+	}
+	if !strings.Contains(out.String(), "@v1 removed") || strings.Contains(out.String(), "@v2 removed") {
+		t.Fatalf("expected only the unused checkout pruned: %s", out.String())
+	}
+
+	cfg.SyncTargets = append(cfg.SyncTargets, config.SyncTarget{Name: "broken", Remote: filepath.Join(t.TempDir(), "missing"), Dest: []string{".codex/broken"}})
+	writeConfig(t, cfg)
+	out.Reset()
+	if code := app.runSkillsClone(context.Background(), nil); code != exitPartial || !strings.Contains(out.String(), "1 of 3 sources failed") {
+		t.Fatalf("expected partial clone failure: %s", out.String())
+	}
+	if code := app.runSkillsPin(context.Background(), []string{"--target", "broken", "--ref", "v1"}); code != 1 {
+		t.Fatalf("expected pin clone error")
+	}
+}
+
+func TestSkillsSourceBranchRefFollowsRemote(t *testing.T) {
+	app, cfg := newTestApp(t)
+	upstream := initGitRepo(t, filepath.Join(t.TempDir(), "upstream"), true)
+	if err := runGit(upstream, "checkout", "-b", "release"); err != nil {
+		t.Fatalf("git checkout: %v", err)
+	}
+	commitSkill(t, upstream, "r1", "r1")
+	alpha := initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	cfg.SyncTargets = []config.SyncTarget{{Name: "release", Remote: upstream, Ref: "release", Src: "skills", Dest: []string{".codex/release"}}}
+	writeConfig(t, cfg)
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runSkillsClone(context.Background(), nil); code != 0 {
+		t.Fatalf("clone failed: %s", out.String())
+	}
+	commitSkill(t, upstream, "r2", "r2")
+	if code := app.runSkillsClone(context.Background(), nil); code != 0 {
+		t.Fatalf("update failed: %s", out.String())
+	}
+	if code := app.runSkillsSync(context.Background(), nil); code != 0 {
+		t.Fatalf("sync failed: %s", out.String())
+	}
+	if data, _ := os.ReadFile(filepath.Join(alpha, ".codex", "release", "a.md")); string(data) != "r2" {
+		t.Fatalf("expected the fetched branch head: %q", data)
+	}
+}
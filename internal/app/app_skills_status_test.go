@@ -0,0 +1,148 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TT-AIXion/github-kanri/internal/config"
+	"github.com/TT-AIXion/github-kanri/internal/output"
+)
+
+func tableCell(out, first, key, second, column string) string {
+	var header string
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == first {
+			header = line
+			continue
+		}
+		if header == "" || fields[0] != key || (second != "" && (len(fields) < 2 || fields[1] != second)) {
+			continue
+		}
+		start := strings.Index(header, column)
+		if start < 0 || start >= len(line) {
+			return ""
+		}
+		end := len(line)
+		if rest := strings.TrimLeft(header[start+len(column):], " "); rest != "" {
+			end = min(len(line), len(header)-len(rest))
+		}
+		return strings.TrimSpace(line[start:end])
+	}
+	return ""
+}
+
+func statusCell(out, repoName, target, column string) string {
+	return tableCell(out, "REPO", repoName, target, column)
+}
+
+func TestSkillsStatusSourcesAndDestinations(t *testing.T) {
+	app, cfg := newTestApp(t)
+	upstream := initGitRepo(t, filepath.Join(t.TempDir(), "upstream"), true)
+	commitSkill(t, upstream, "v1", "v1")
+	commitSkill(t, upstream, "v2", "v2")
+	_ = os.RemoveAll(cfg.SkillsRoot)
+	if err := runGit(cfg.ProjectsRoot, "clone", upstream, cfg.SkillsRoot); err != nil {
+		t.Fatalf("clone: %v", err)
+	}
+	_ = runGit(cfg.SkillsRoot, "config", "user.email", "test@example.com")
+	_ = runGit(cfg.SkillsRoot, "config", "user.name", "Tester")
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "local.md"), []byte("local"), 0o644)
+	_ = runGit(cfg.SkillsRoot, "add", ".")
+	_ = runGit(cfg.SkillsRoot, "commit", "-m", "local")
+	alpha := initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	cfg.SyncTargets = append(cfg.SyncTargets, config.SyncTarget{Name: "stable", Remote: upstream, Ref: "v1", Src: "skills", Dest: []string{".codex/stable"}})
+	writeConfig(t, cfg)
+
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runSkillsClone(context.Background(), nil); code != 0 {
+		t.Fatalf("clone failed: %s", out.String())
+	}
+	if code := app.runSkillsSync(context.Background(), nil); code != 0 {
+		t.Fatalf("sync failed: %s", out.String())
+	}
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "dirty.md"), []byte("dirty"), 0o644)
+	_ = os.WriteFile(filepath.Join(alpha, ".codex", "stable", "a.md"), []byte("edited"), 0o644)
+	_ = os.MkdirAll(filepath.Join(initGitRepo(t, filepath.Join(cfg.ReposRoot, "beta"), true), ".codex", "skills"), 0o755)
+
+	out.Reset()
+	if code := app.runSkillsStatus(context.Background(), nil); code != 0 {
+		t.Fatalf("status failed: %s", out.String())
+	}
+	text := out.String()
+	source := func(name, column string) string { return tableCell(text, "SOURCE", name, "", column) }
+	head := revParse(t, cfg.SkillsRoot, "HEAD")
+	if source("skillsRoot", "COMMIT") != head[:7] || source("skillsRoot", "TREE") != "dirty" || source("skillsRoot", "AHEAD") != "1" || source("skillsRoot", "BEHIND") != "0" || source("skillsRoot", "TARGETS") != "skills" {
+		t.Fatalf("unexpected skillsRoot row: %s", text)
+	}
+	if !strings.Contains(text, "detached v1") || source(upstream+"@v1", "PIN") != "v1" || source(upstream+"@v1", "TREE") != "clean" || source(upstream+"@v1", "AHEAD") != "-" {
+		t.Fatalf("unexpected pinned source row: %s", text)
+	}
+	if statusCell(text, "alpha", "skills", "STATE") != "drift" || statusCell(text, "alpha", "skills", "ADDED") != "1" || statusCell(text, "alpha", "skills", "REV") != head[:7] || statusCell(text, "alpha", "skills", "SYNCED") == "-" {
+		t.Fatalf("unexpected alpha skills row: %s", text)
+	}
+	if statusCell(text, "alpha", "skills", "DEST") != ".codex/skills" || !strings.HasSuffix(statusCell(text, "alpha", "skills", "REF"), "@"+head[:7]) || statusCell(text, "alpha", "stable", "REF") != "v1@"+revParse(t, upstream, "v1")[:7] {
+		t.Fatalf("unexpected dest or ref columns: %s", text)
+	}
+	if statusCell(text, "alpha", "stable", "STATE") != "drift" || statusCell(text, "alpha", "stable", "LOCAL") != "1" || statusCell(text, "alpha", "stable", "CHANGED") != "1" {
+		t.Fatalf("unexpected alpha stable row: %s", text)
+	}
+	if statusCell(text, "beta", "skills", "REV") != "unmanaged" || statusCell(text, "beta", "skills", "SYNCED") != "-" {
+		t.Fatalf("unexpected beta row: %s", text)
+	}
+
+	defaults, err := config.DefaultConfig()
+	if err != nil {
+		t.Fatalf("default config: %v", err)
+	}
+	cfg.AllowCommands = defaults.AllowCommands
+	writeConfig(t, cfg)
+	out.Reset()
+	app.Out = output.Writer{Out: &out, ErrW: &out, JSON: true}
+	if code := app.runSkillsStatus(context.Background(), []string{"--target", "stable", "--only", "alpha"}); code != 0 {
+		t.Fatalf("status json failed: %s", out.String())
+	}
+	var env struct {
+		Data skillsStatus `json:"data"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &env); err != nil {
+		t.Fatalf("json: %v %s", err, out.String())
+	}
+	if len(env.Data.Sources) != 1 || len(env.Data.Destinations) != 1 {
+		t.Fatalf("unexpected status: %+v", env.Data)
+	}
+	s, d := env.Data.Sources[0], env.Data.Destinations[0]
+	if !s.Git || !s.Detached || s.Branch != "v1" || s.Pin != "v1" || s.Dirty || s.Ahead != nil || s.Commit != revParse(t, upstream, "v1") {
+		t.Fatalf("unexpected source: %+v", s)
+	}
+	if d.Repo != "alpha" || d.Ref != "v1@"+s.Commit[:7] || d.State != "drift" || !d.Managed || d.Revision != s.Commit || d.SyncedAt == nil || d.Counts.LocalModified != 1 || d.Counts.Changed != 1 {
+		t.Fatalf("unexpected destination: %+v", d)
+	}
+}
+
+func TestSkillsStatusPlainSource(t *testing.T) {
+	app, cfg := newTestApp(t)
+	initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runSkillsStatus(context.Background(), nil); code != 0 {
+		t.Fatalf("status failed: %s", out.String())
+	}
+	if tableCell(out.String(), "SOURCE", "skillsRoot", "", "TREE") != "not git" || tableCell(out.String(), "SOURCE", "skillsRoot", "", "COMMIT") != "-" {
+		t.Fatalf("expected plain source: %s", out.String())
+	}
+	_ = os.RemoveAll(cfg.SkillsRoot)
+	out.Reset()
+	if code := app.runSkillsStatus(context.Background(), nil); code != 1 || tableCell(out.String(), "SOURCE", "skillsRoot", "", "TREE") != "missing" || statusCell(out.String(), "alpha", "skills", "STATE") != "error" {
+		t.Fatalf("expected missing source: %d %s", code, out.String())
+	}
+}
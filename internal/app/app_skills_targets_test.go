@@ -0,0 +1,141 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TT-AIXion/github-kanri/internal/config"
+	"github.com/TT-AIXion/github-kanri/internal/output"
+	"github.com/TT-AIXion/github-kanri/internal/repo"
+)
+
+func TestTargetsForRepo(t *testing.T) {
+	cfg := config.Config{RepoTags: map[string][]string{"service": {"api-*", "billing"}}}
+	targets := []config.SyncTarget{
+		{Name: "skills"},
+		{Name: "ci", Repos: []string{"tag:service"}, ExcludeRepos: []string{"api-legacy"}},
+		{Name: "docs", Repos: []string{"web"}},
+		{Name: "none", Repos: []string{"tag:missing"}},
+	}
+	names := func(r string) string {
+		var out []string
+		repoTargets, err := targetsForRepo(cfg, targets, repo.Repo{Name: r})
+		if err != nil {
+			t.Fatalf("targets for %s: %v", r, err)
+		}
+		for _, t := range repoTargets {
+			out = append(out, t.Name)
+		}
+		return strings.Join(out, ",")
+	}
+	cases := map[string]string{
+		"api-users":  "skills,ci",
+		"billing":    "skills,ci",
+		"api-legacy": "skills",
+		"web":        "skills,docs",
+	}
+	for r, want := range cases {
+		if got := names(r); got != want {
+			t.Fatalf("%s: got %s want %s", r, got, want)
+		}
+	}
+}
+
+func TestSelectTargetsSkipsDisabled(t *testing.T) {
+	off := false
+	cfg := config.Config{SyncTargets: []config.SyncTarget{{Name: "a"}, {Name: "b", Enabled: &off}}}
+	targets, err := selectTargets(cfg, "")
+	if err != nil || len(targets) != 1 || targets[0].Name != "a" {
+		t.Fatalf("unexpected targets: %v %v", targets, err)
+	}
+	targets, err = selectTargets(cfg, "b")
+	if err != nil || len(targets) != 1 || targets[0].Name != "b" {
+		t.Fatalf("expected explicit disabled target: %v %v", targets, err)
+	}
+}
+
+func TestTargetModeAndPolicy(t *testing.T) {
+	cfg := config.Config{SyncMode: "copy", ConflictPolicy: "fail"}
+	target := config.SyncTarget{Mode: "mirror", ConflictPolicy: "overwrite"}
+	if targetMode(cfg, config.SyncTarget{}, "") != "copy" || targetMode(cfg, target, "") != "mirror" || targetMode(cfg, target, "link") != "link" {
+		t.Fatalf("unexpected mode resolution")
+	}
+	if targetPolicy(cfg, config.SyncTarget{}, false) != "fail" || targetPolicy(cfg, target, false) != "overwrite" || targetPolicy(cfg, config.SyncTarget{ConflictPolicy: "merge"}, true) != "overwrite" {
+		t.Fatalf("unexpected policy resolution")
+	}
+}
+
+func TestSkillsSyncPerTargetSelectors(t *testing.T) {
+	app, cfg := newTestApp(t)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "skill.md"), []byte("skill"), 0o644)
+	ciSrc := filepath.Join(cfg.ProjectsRoot, "shared-ci")
+	_ = os.MkdirAll(ciSrc, 0o755)
+	_ = os.WriteFile(filepath.Join(ciSrc, "ci.yml"), []byte("ci"), 0o644)
+	api := initGitRepo(t, filepath.Join(cfg.ReposRoot, "api-users"), true)
+	web := initGitRepo(t, filepath.Join(cfg.ReposRoot, "web"), true)
+	off := false
+	cfg.RepoTags = map[string][]string{"service": {"api-*"}}
+	cfg.SyncTargets = []config.SyncTarget{
+		{Name: "skills", Src: cfg.SkillsRoot, Dest: []string{".codex/skills"}, Exclude: []string{".git/**"}, Mode: "link"},
+		{Name: "ci", Src: ciSrc, Dest: []string{".github/workflows"}, Mode: "mirror", ConflictPolicy: "overwrite", Repos: []string{"tag:service"}},
+		{Name: "off", Src: ciSrc, Dest: []string{".off"}, Enabled: &off},
+	}
+	cfg.ConflictPolicy = "fail"
+	writeConfig(t, cfg)
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runSkillsSync(context.Background(), nil); code != 0 {
+		t.Fatalf("sync failed: %s", out.String())
+	}
+	for _, r := range []string{api, web} {
+		info, err := os.Lstat(filepath.Join(r, ".codex", "skills", "skill.md"))
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			t.Fatalf("expected skill link in %s: %v", r, err)
+		}
+		if _, err := os.Stat(filepath.Join(r, ".off")); !os.IsNotExist(err) {
+			t.Fatalf("disabled target synced into %s", r)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(api, ".github", "workflows", "ci.yml")); err != nil {
+		t.Fatalf("expected ci in service repo: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(web, ".github")); !os.IsNotExist(err) {
+		t.Fatalf("ci should not reach web")
+	}
+
+	out.Reset()
+	if code := app.runSkillsStatus(context.Background(), nil); code != 0 {
+		t.Fatalf("status failed: %s", out.String())
+	}
+	if strings.Count(out.String(), " ci ") != 1 {
+		t.Fatalf("expected ci status only for service repo: %s", out.String())
+	}
+
+	cfg.SyncTargets[1].Mode = "bogus"
+	writeConfig(t, cfg)
+	if code := app.runSkillsSync(context.Background(), nil); code == 0 {
+		t.Fatalf("expected invalid mode error")
+	}
+}
+
+func TestSkillsCleanPerTargetPolicy(t *testing.T) {
+	app, cfg := newTestApp(t)
+	_ = initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	cfg.ConflictPolicy = "fail"
+	writeConfig(t, cfg)
+	if code := app.runSkillsSync(context.Background(), []string{"--target", "skills"}); code != 0 {
+		t.Fatalf("sync failed")
+	}
+	if code := app.runSkillsClean(context.Background(), []string{"--target", "skills"}); code == 0 {
+		t.Fatalf("expected clean refusal")
+	}
+	cfg.SyncTargets[0].ConflictPolicy = "overwrite"
+	writeConfig(t, cfg)
+	if code := app.runSkillsClean(context.Background(), []string{"--target", "skills"}); code != 0 {
+		t.Fatalf("expected clean with target overwrite policy")
+	}
+}
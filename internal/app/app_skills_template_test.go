@@ -0,0 +1,106 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TT-AIXion/github-kanri/internal/config"
+	"github.com/TT-AIXion/github-kanri/internal/output"
+	"github.com/TT-AIXion/github-kanri/internal/repo"
+)
+
+func TestSkillsSyncRendersTemplates(t *testing.T) {
+	app, cfg := newTestApp(t)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "AGENTS.md.tmpl"), []byte("# {{ .Repo.Name }} {{ .Target }} {{ .Vars.team }} {{ .Vars.env }}\n"), 0o644)
+	repoPath := initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	cfg.TemplateVars = map[string]string{"team": "core", "env": "dev"}
+	cfg.SyncTargets[0].Vars = map[string]string{"env": "prod"}
+	cfg.SyncTargets[0].Template = true
+	writeConfig(t, cfg)
+
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runSkillsSync(context.Background(), []string{"--target", "skills"}); code != 0 {
+		t.Fatalf("sync failed: %s", out.String())
+	}
+	dest := filepath.Join(repoPath, ".codex", "skills")
+	data, err := os.ReadFile(filepath.Join(dest, "AGENTS.md"))
+	if err != nil || string(data) != "# alpha skills core prod\n" {
+		t.Fatalf("unexpected render: %q %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "AGENTS.md.tmpl")); !os.IsNotExist(err) {
+		t.Fatalf("template source should not be copied")
+	}
+
+	for _, run := range []func(context.Context, []string) int{app.runSkillsVerify, app.runSkillsStatus} {
+		out.Reset()
+		if code := run(context.Background(), []string{"--target", "skills"}); code != 0 {
+			t.Fatalf("expected clean after render: %s", out.String())
+		}
+	}
+	out.Reset()
+	if code := app.runSkillsDiff(context.Background(), []string{"--target", "skills"}); code != 0 || strings.Contains(out.String(), "AGENTS.md") {
+		t.Fatalf("expected empty diff: %s", out.String())
+	}
+
+	cfg.SyncTargets[0].Vars = map[string]string{"env": "stage"}
+	writeConfig(t, cfg)
+	out.Reset()
+	if code := app.runSkillsDiff(context.Background(), []string{"--target", "skills"}); code != 0 || !strings.Contains(out.String(), "upstream AGENTS.md") {
+		t.Fatalf("expected rendered drift: %s", out.String())
+	}
+
+	if code := app.runSkillsClean(context.Background(), []string{"--target", "skills"}); code != 0 {
+		t.Fatalf("clean failed")
+	}
+	if _, err := os.Stat(filepath.Join(dest, "AGENTS.md")); err != nil {
+		t.Fatalf("rendered file should be kept by clean: %v", err)
+	}
+}
+
+func TestSkillsSyncTemplateError(t *testing.T) {
+	app, cfg := newTestApp(t)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "x.tmpl"), []byte("{{ .Vars.missing }}"), 0o644)
+	repoPath := initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runSkillsSync(context.Background(), []string{"--target", "skills"}); code != 0 {
+		t.Fatalf("expected verbatim sync without opt-in: %s", out.String())
+	}
+	if data, err := os.ReadFile(filepath.Join(repoPath, ".codex", "skills", "x.tmpl")); err != nil || string(data) != "{{ .Vars.missing }}" {
+		t.Fatalf("expected .tmpl copied verbatim: %q %v", data, err)
+	}
+	cfg.SyncTargets[0].Template = true
+	writeConfig(t, cfg)
+	out.Reset()
+	if code := app.runSkillsSync(context.Background(), []string{"--target", "skills"}); code == 0 {
+		t.Fatalf("expected render error: %s", out.String())
+	}
+}
+
+func TestTemplateCacheForTarget(t *testing.T) {
+	_, cfg := newTestApp(t)
+	repoPath := initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	_ = runGit(repoPath, "remote", "add", "origin", "git@github.com:acme/alpha.git")
+	cache := newTemplateCache(context.Background(), cfg, buildRunner(cfg, false))
+	r := repo.Repo{Name: "alpha", Path: repoPath}
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "x.tmpl"), []byte("x"), 0o644)
+	if tmpl := cache.forTarget(r, config.SyncTarget{Name: "plain", Src: cfg.SkillsRoot}); tmpl != nil {
+		t.Fatalf("expected no template without opt-in")
+	}
+	if tmpl := cache.forTarget(r, config.SyncTarget{Name: "tmpl", Src: cfg.SkillsRoot, Template: true}); tmpl == nil || tmpl.All {
+		t.Fatalf("expected template for .tmpl files only")
+	}
+	tmpl := cache.forTarget(r, config.SyncTarget{Name: "all", Src: cfg.SkillsRoot, TemplateAll: true})
+	if tmpl == nil || !tmpl.All {
+		t.Fatalf("expected template for all files")
+	}
+	data := tmpl.Data.(templateData)
+	if data.Repo.Owner != "acme" || data.Repo.Host != "github.com" || data.Repo.DefaultBranch == "" {
+		t.Fatalf("unexpected facts: %+v", data.Repo)
+	}
+}
@@ -0,0 +1,212 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TT-AIXion/github-kanri/internal/config"
+	"github.com/TT-AIXion/github-kanri/internal/output"
+	"github.com/TT-AIXion/github-kanri/internal/watch"
+)
+
+type fakeWatcher struct {
+	events chan string
+	errors chan error
+}
+
+func (w *fakeWatcher) Events() <-chan string { return w.events }
+
+func (w *fakeWatcher) Errors() <-chan error { return w.errors }
+
+func (w *fakeWatcher) Close() error { return nil }
+
+func useFakeWatcher(t *testing.T) *fakeWatcher {
+	t.Helper()
+	fw := &fakeWatcher{events: make(chan string, 16), errors: make(chan error, 1)}
+	orig := newWatcher
+	newWatcher = func([]string, time.Duration, bool) (watch.Watcher, error) { return fw, nil }
+	t.Cleanup(func() { newWatcher = orig })
+	return fw
+}
+
+func readWatchLog(t *testing.T, path string, n int) []watchEvent {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var events []watchEvent
+		if f, err := os.Open(path); err == nil {
+			scanner := bufio.NewScanner(f)
+			for scanner.Scan() {
+				var e watchEvent
+				if json.Unmarshal(scanner.Bytes(), &e) == nil {
+					events = append(events, e)
+				}
+			}
+			_ = f.Close()
+		}
+		if len(events) >= n {
+			return events
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d watch events, got %d", n, len(events))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSkillsWatchSyncsChangedFiles(t *testing.T) {
+	app, cfg := newTestApp(t)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "a.md"), []byte("a"), 0o644)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "b.md"), []byte("b"), 0o644)
+	alpha := initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	if code := app.runSkillsSync(context.Background(), nil); code != 0 {
+		t.Fatalf("sync failed: %s", out.String())
+	}
+	fw := useFakeWatcher(t)
+	logPath := filepath.Join(t.TempDir(), "watch.jsonl")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan int, 1)
+	go func() {
+		done <- app.runSkillsWatch(ctx, []string{"--debounce", "1ms", "--log", logPath})
+	}()
+
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "a.md"), []byte("a2"), 0o644)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "b.md"), []byte("b2"), 0o644)
+	fw.events <- filepath.Join(cfg.SkillsRoot, ".git", "index")
+	fw.events <- filepath.Join(cfg.ProjectsRoot, "elsewhere.md")
+	fw.events <- filepath.Join(cfg.SkillsRoot, "a.md")
+	events := readWatchLog(t, logPath, 1)
+	e := events[0]
+	if e.Target != "skills" || len(e.Paths) != 1 || e.Paths[0] != "a.md" || e.Full || len(e.Results) != 1 || e.Results[0].Updated != 1 || e.Error != "" {
+		t.Fatalf("unexpected watch event: %+v", e)
+	}
+	dest := filepath.Join(alpha, ".codex", "skills")
+	if data, _ := os.ReadFile(filepath.Join(dest, "a.md")); string(data) != "a2" {
+		t.Fatalf("expected a.md to be synced: %s", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dest, "b.md")); string(data) != "b" {
+		t.Fatalf("b.md should wait for its own event: %s", data)
+	}
+
+	fw.events <- cfg.SkillsRoot
+	events = readWatchLog(t, logPath, 2)
+	if !events[1].Full || events[1].Results[0].Updated != 1 {
+		t.Fatalf("expected full sync: %+v", events[1])
+	}
+	cancel()
+	if code := <-done; code != 0 || !strings.Contains(out.String(), "watch stopped") {
+		t.Fatalf("expected clean stop: %d %s", code, out.String())
+	}
+	if data, _ := os.ReadFile(filepath.Join(dest, "b.md")); string(data) != "b2" {
+		t.Fatalf("expected full sync to update b.md: %s", data)
+	}
+}
+
+func TestSkillsWatchJSONAndSyncError(t *testing.T) {
+	app, cfg := newTestApp(t)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "a.md"), []byte("a"), 0o644)
+	_ = initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	cfg.DenyPaths = []string{"**/alpha/**"}
+	writeConfig(t, cfg)
+	fw := useFakeWatcher(t)
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out, JSON: true}
+	logPath := filepath.Join(t.TempDir(), "watch.jsonl")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan int, 1)
+	go func() {
+		done <- app.runSkillsWatch(ctx, []string{"--debounce", "1ms", "--log", logPath})
+	}()
+	fw.events <- filepath.Join(cfg.SkillsRoot, "a.md")
+	if e := readWatchLog(t, logPath, 1)[0]; e.Error == "" || len(e.Results) != 0 {
+		t.Fatalf("expected logged error: %+v", e)
+	}
+	fw.events <- filepath.Join(cfg.SkillsRoot, "a.md")
+	readWatchLog(t, logPath, 2)
+	cancel()
+	if code := <-done; code != 0 || !strings.Contains(out.String(), `"level":"ERR","message":"skills watch"`) || !strings.Contains(out.String(), "watch stopped") {
+		t.Fatalf("expected watch to keep running after sync errors: %d %s", code, out.String())
+	}
+}
+
+func TestSkillsWatchWatcherFailures(t *testing.T) {
+	app, cfg := newTestApp(t)
+	fw := useFakeWatcher(t)
+	fw.errors <- errors.New("boom")
+	if code := app.runSkillsWatch(context.Background(), nil); code != 1 {
+		t.Fatalf("expected watcher error")
+	}
+	fw.errors = make(chan error)
+	close(fw.events)
+	if code := app.runSkillsWatch(context.Background(), nil); code != 1 {
+		t.Fatalf("expected closed watcher error")
+	}
+	newWatcher = func([]string, time.Duration, bool) (watch.Watcher, error) { return nil, errors.New("no watcher") }
+	if code := app.runSkillsWatch(context.Background(), nil); code != 1 {
+		t.Fatalf("expected watcher setup error")
+	}
+	if code := app.runSkillsWatch(context.Background(), []string{"--log", filepath.Join(cfg.SkillsRoot, "missing", "x.jsonl")}); code != 1 {
+		t.Fatalf("expected log open error")
+	}
+	if code := app.runSkillsWatch(context.Background(), []string{"--bad"}); code != 1 {
+		t.Fatalf("expected flag error")
+	}
+}
+
+func TestSkillsWatchPolling(t *testing.T) {
+	app, cfg := newTestApp(t)
+	alpha := initGitRepo(t, filepath.Join(cfg.ReposRoot, "alpha"), true)
+	var out bytes.Buffer
+	app.Out = output.Writer{Out: &out, ErrW: &out}
+	var gotInterval time.Duration
+	var gotPoll bool
+	orig := newWatcher
+	t.Cleanup(func() { newWatcher = orig })
+	newWatcher = func(roots []string, interval time.Duration, poll bool) (watch.Watcher, error) {
+		gotInterval, gotPoll = interval, poll
+		return watch.NewPoller(roots, 10*time.Millisecond)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan int, 1)
+	go func() { done <- app.runSkillsWatch(ctx, []string{"--poll", "--interval", "0", "--debounce", "1ms"}) }()
+	time.Sleep(50 * time.Millisecond)
+	_ = os.WriteFile(filepath.Join(cfg.SkillsRoot, "new.md"), []byte("x"), 0o644)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(filepath.Join(alpha, ".codex", "skills", "new.md")); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected polling watch to sync new.md")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if code := <-done; code != 0 || gotInterval != 5*time.Second || !gotPoll {
+		t.Fatalf("unexpected watch setup: %d %v %v", code, gotInterval, gotPoll)
+	}
+}
+
+func TestChangedPaths(t *testing.T) {
+	target := config.SyncTarget{Name: "skills", Src: "/src", Exclude: []string{".git/**", "drafts"}}
+	paths, full, changed := changedPaths(target, []string{"/src/a.md", "/src/.git/HEAD", "/src/drafts/x.md", "/other/b.md", "/srcx/c.md"})
+	if !changed || full || len(paths) != 1 || paths[0] != "a.md" {
+		t.Fatalf("unexpected paths: %v %v %v", paths, full, changed)
+	}
+	if _, full, changed := changedPaths(target, []string{"/src/a.md", "/src"}); !full || !changed {
+		t.Fatalf("expected full sync")
+	}
+	if _, _, changed := changedPaths(target, []string{"/src/drafts"}); changed {
+		t.Fatalf("expected excluded change to be ignored")
+	}
+}
@@ -1,6 +1,9 @@
 package app
 
-import "strings"
+import (
+	"flag"
+	"strings"
+)
 
 type multiFlag []string
 
@@ -19,3 +22,18 @@ func (m *multiFlag) Set(value string) error {
 	}
 	return nil
 }
+
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
@@ -0,0 +1,136 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/TT-AIXion/github-kanri/internal/gitutil"
+	"github.com/TT-AIXion/github-kanri/internal/repo"
+)
+
+type grepResult struct {
+	Repo string `json:"repo"`
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+type grepRepoResult struct {
+	matches []gitutil.GrepMatch
+	err     error
+}
+
+func (a App) runGrep(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("grep", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+	parallel := fs.Int("parallel", 4, "parallelism")
+	limit := fs.Int("limit", 0, "max results (0 = unlimited)")
+	maxPerRepo := fs.Int("max-per-repo", 0, "max results per repo (0 = unlimited)")
+	ignoreCase := fs.Bool("ignore-case", false, "case insensitive")
+	fixed := fs.Bool("fixed-strings", false, "treat pattern as a literal string")
+	var only multiFlag
+	var exclude multiFlag
+	var globs multiFlag
+	fs.Var(&only, "only", "only patterns")
+	fs.Var(&exclude, "exclude", "exclude patterns")
+	fs.Var(&globs, "glob", "pathspec globs")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	if len(positional) == 0 || strings.TrimSpace(positional[0]) == "" {
+		a.Out.Err("pattern required", nil)
+		return 1
+	}
+	if len(positional) > 1 {
+		a.Out.Err(fmt.Sprintf("unexpected argument: %s", positional[1]), nil)
+		return 1
+	}
+	pattern := positional[0]
+	cfg, _, err := loadConfig()
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	repos, err := repo.Scan(cfg.ReposRoot)
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	repos = repo.Filter(repos, only, exclude)
+	runner := buildRunner(cfg, false)
+	guard := guardFromConfig(cfg)
+	opts := gitutil.GrepOptions{Globs: globs, IgnoreCase: *ignoreCase, Fixed: *fixed}
+
+	perRepo := make([]grepRepoResult, len(repos))
+	if *parallel < 1 {
+		*parallel = 1
+	}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	worker := func() {
+		defer wg.Done()
+		for idx := range jobs {
+			r := repos[idx]
+			if err := guard.CheckPath(r.Path); err != nil {
+				perRepo[idx] = grepRepoResult{err: err}
+				continue
+			}
+			matches, err := gitutil.Grep(ctx, runner, r.Path, pattern, opts)
+			perRepo[idx] = grepRepoResult{matches: matches, err: err}
+		}
+	}
+	for i := 0; i < *parallel; i++ {
+		wg.Add(1)
+		go worker()
+	}
+	for i := range repos {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	hadError := false
+	truncated := false
+	var results []grepResult
+	for i, pr := range perRepo {
+		if pr.err != nil {
+			hadError = true
+			a.Out.Err(fmt.Sprintf("%s: %v", repos[i].Name, pr.err), nil)
+			continue
+		}
+		for j, m := range pr.matches {
+			if *maxPerRepo > 0 && j >= *maxPerRepo {
+				truncated = true
+				break
+			}
+			if *limit > 0 && len(results) >= *limit {
+				truncated = true
+				break
+			}
+			results = append(results, grepResult{Repo: repos[i].Name, Path: m.Path, Line: m.Line, Text: m.Text})
+		}
+	}
+	if a.Out.JSON {
+		a.Out.OK("grep", map[string]interface{}{"matches": results, "truncated": truncated})
+	} else {
+		for _, r := range results {
+			a.Out.Raw(fmt.Sprintf("%s:%s:%d:%s", r.Repo, r.Path, r.Line, r.Text))
+		}
+		if truncated {
+			a.Out.Warn(fmt.Sprintf("results truncated at %d", len(results)), nil)
+		}
+		if len(results) == 0 && !hadError {
+			a.Out.Warn("no matches", nil)
+		}
+	}
+	if hadError {
+		return 1
+	}
+	return 0
+}
@@ -8,6 +8,21 @@ import (
 	"github.com/TT-AIXion/github-kanri/internal/safety"
 )
 
+const exitPartial = 3
+
+func (a App) failureCode(failed, total int, noun string) int {
+	if failed == 0 {
+		return 0
+	}
+	if !a.Out.JSON && total > 1 {
+		a.Out.Err(fmt.Sprintf("%d of %d %s failed", failed, total, noun), nil)
+	}
+	if failed == total {
+		return 1
+	}
+	return exitPartial
+}
+
 func loadConfig() (config.Config, string, error) {
 	path, err := config.DefaultConfigPath()
 	if err != nil {
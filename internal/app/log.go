@@ -0,0 +1,235 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TT-AIXion/github-kanri/internal/gitutil"
+	"github.com/TT-AIXion/github-kanri/internal/repo"
+)
+
+type logEntry struct {
+	Repo      string    `json:"repo"`
+	Hash      string    `json:"hash"`
+	Author    string    `json:"author"`
+	Email     string    `json:"email"`
+	Timestamp time.Time `json:"timestamp"`
+	Subject   string    `json:"subject"`
+}
+
+type logCount struct {
+	Key     string `json:"key"`
+	Commits int    `json:"commits"`
+}
+
+type logReport struct {
+	Entries  []logEntry `json:"entries"`
+	ByRepo   []logCount `json:"byRepo"`
+	ByAuthor []logCount `json:"byAuthor"`
+	Errors   []logError `json:"errors,omitempty"`
+}
+
+type logError struct {
+	Repo  string `json:"repo"`
+	Error string `json:"error"`
+}
+
+var sincePattern = regexp.MustCompile(`^(\d+)([hdw])$`)
+
+func (a App) runLog(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("log", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+	since := fs.String("since", "7d", "since (e.g. 24h, 7d, 2w, 2026-01-01)")
+	author := fs.String("author", "", "author pattern (me = git user.email)")
+	format := fs.String("format", "table", "table|markdown|json")
+	groupBy := fs.String("group-by", "", "repo|author")
+	limit := fs.Int("limit", 0, "limit")
+	var only multiFlag
+	var exclude multiFlag
+	fs.Var(&only, "only", "only patterns")
+	fs.Var(&exclude, "exclude", "exclude patterns")
+	if err := fs.Parse(args); err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	if *format != "table" && *format != "markdown" && *format != "json" {
+		a.Out.Err(fmt.Sprintf("invalid format: %s", *format), nil)
+		return 1
+	}
+	if *groupBy != "" && *groupBy != "repo" && *groupBy != "author" {
+		a.Out.Err(fmt.Sprintf("invalid group-by: %s", *groupBy), nil)
+		return 1
+	}
+	cfg, _, err := loadConfig()
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	repos, err := repo.Scan(cfg.ReposRoot)
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	repos = repo.Filter(repos, only, exclude)
+	runner := buildRunner(cfg, false)
+	sinceArg := parseSince(*since)
+	var entries []logEntry
+	var failures []logError
+	for _, r := range repos {
+		authorArg := strings.TrimSpace(*author)
+		if authorArg == "me" {
+			email, err := gitutil.ConfigValue(ctx, runner, r.Path, "user.email")
+			if err != nil || email == "" {
+				failures = append(failures, logError{Repo: r.Name, Error: "--author me: user.email not set"})
+				continue
+			}
+			authorArg = email
+		}
+		commits, err := gitutil.Log(ctx, runner, r.Path, sinceArg, authorArg)
+		if err != nil {
+			if has, herr := gitutil.HasCommits(ctx, runner, r.Path); herr == nil && !has {
+				continue
+			}
+			failures = append(failures, logError{Repo: r.Name, Error: err.Error()})
+			continue
+		}
+		for _, c := range commits {
+			entries = append(entries, logEntry{
+				Repo:      r.Name,
+				Hash:      c.Hash,
+				Author:    c.Author,
+				Email:     c.Email,
+				Timestamp: time.Unix(c.Unix, 0),
+				Subject:   c.Subject,
+			})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Timestamp.After(entries[j].Timestamp) })
+	if *limit > 0 && len(entries) > *limit {
+		entries = entries[:*limit]
+	}
+	report := logReport{
+		Entries:  entries,
+		ByRepo:   countLog(entries, func(e logEntry) string { return e.Repo }),
+		ByAuthor: countLog(entries, func(e logEntry) string { return e.Author }),
+		Errors:   failures,
+	}
+	if a.Out.JSON || *format == "json" {
+		a.Out.JSON = true
+		a.Out.OK("log", report)
+		return a.failureCode(len(failures), len(repos), "repos")
+	}
+	switch {
+	case len(entries) == 0:
+		a.Out.Warn("no commits", nil)
+	case *groupBy != "" && *format == "markdown":
+		a.Out.Raw(renderLogCountsMarkdown(*groupBy, pickCounts(report, *groupBy)))
+	case *groupBy != "":
+		a.Out.Raw(renderLogCountsTable(*groupBy, pickCounts(report, *groupBy)))
+	case *format == "markdown":
+		a.Out.Raw(renderLogMarkdown(entries))
+	default:
+		a.Out.Raw(renderLogTable(entries))
+	}
+	for _, f := range failures {
+		a.Out.Err(fmt.Sprintf("%s: %s", f.Repo, f.Error), nil)
+	}
+	return a.failureCode(len(failures), len(repos), "repos")
+}
+
+func parseSince(value string) string {
+	value = strings.TrimSpace(value)
+	m := sincePattern.FindStringSubmatch(value)
+	if m == nil {
+		return value
+	}
+	unit := map[string]string{"h": "hours", "d": "days", "w": "weeks"}[m[2]]
+	return fmt.Sprintf("%s %s ago", m[1], unit)
+}
+
+func countLog(entries []logEntry, key func(logEntry) string) []logCount {
+	counts := map[string]int{}
+	for _, e := range entries {
+		counts[key(e)]++
+	}
+	out := make([]logCount, 0, len(counts))
+	for k, n := range counts {
+		out = append(out, logCount{Key: k, Commits: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Commits != out[j].Commits {
+			return out[i].Commits > out[j].Commits
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out
+}
+
+func pickCounts(report logReport, groupBy string) []logCount {
+	if groupBy == "author" {
+		return report.ByAuthor
+	}
+	return report.ByRepo
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
+
+func renderLogTable(entries []logEntry) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "DATE\tREPO\tHASH\tAUTHOR\tSUBJECT")
+	for _, e := range entries {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", e.Timestamp.Format("2006-01-02 15:04"), e.Repo, shortHash(e.Hash), e.Author, e.Subject)
+	}
+	_ = w.Flush()
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func renderLogMarkdown(entries []logEntry) string {
+	var b strings.Builder
+	day := ""
+	for _, e := range entries {
+		d := e.Timestamp.Format("2006-01-02")
+		if d != day {
+			if day != "" {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(&b, "## %s\n\n", d)
+			day = d
+		}
+		fmt.Fprintf(&b, "- **%s** `%s` %s (%s)\n", e.Repo, shortHash(e.Hash), e.Subject, e.Author)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func renderLogCountsTable(groupBy string, counts []logCount) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "%s\tCOMMITS\n", strings.ToUpper(groupBy))
+	for _, c := range counts {
+		_, _ = fmt.Fprintf(w, "%s\t%d\n", c.Key, c.Commits)
+	}
+	_ = w.Flush()
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func renderLogCountsMarkdown(groupBy string, counts []logCount) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "| %s | commits |\n| --- | ---: |\n", groupBy)
+	for _, c := range counts {
+		fmt.Fprintf(&b, "| %s | %d |\n", c.Key, c.Commits)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
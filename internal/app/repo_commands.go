@@ -22,6 +22,11 @@ Commands:
   graph <pattern> [--pick n] [--limit n]
   clone <url> [--name repo]
   exec --cmd "<command>" [--parallel n] [--timeout sec] [--require-clean]
+  du [--sort total|worktree|git|artifacts] [--limit n]
+  gc [--dry-run] [--force] [--no-clean]
+  discover <dir...> [--move|--clone] [--dry-run] [--force]
+  remote <list|rewrite>
+  duplicates
 
 Common flags:
   --only <glob> (repeatable)
@@ -51,6 +56,16 @@ Common flags:
 		return a.runRepoClone(ctx, args[1:])
 	case "exec":
 		return a.runRepoExec(ctx, args[1:])
+	case "du":
+		return a.runRepoDu(ctx, args[1:])
+	case "gc":
+		return a.runRepoGc(ctx, args[1:])
+	case "discover":
+		return a.runRepoDiscover(ctx, args[1:])
+	case "remote":
+		return a.runRepoRemote(ctx, args[1:])
+	case "duplicates":
+		return a.runRepoDuplicates(ctx, args[1:])
 	case "--help", "-h":
 		fs := flag.NewFlagSet("repo", flag.ContinueOnError)
 		fs.SetOutput(os.Stdout)
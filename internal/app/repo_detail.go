@@ -7,8 +7,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 
+	"github.com/TT-AIXion/github-kanri/internal/config"
+	"github.com/TT-AIXion/github-kanri/internal/executil"
 	"github.com/TT-AIXion/github-kanri/internal/gitutil"
 	"github.com/TT-AIXion/github-kanri/internal/repo"
 )
@@ -19,6 +22,7 @@ func (a App) runRepoOpen(ctx context.Context, args []string) int {
 	fs := flag.NewFlagSet("repo open", flag.ContinueOnError)
 	fs.SetOutput(os.Stdout)
 	pick := fs.Int("pick", 0, "pick index")
+	web := fs.Bool("web", false, "open the origin repository in the browser")
 	if err := fs.Parse(args); err != nil {
 		a.Out.Err(err.Error(), nil)
 		return 1
@@ -48,6 +52,9 @@ func (a App) runRepoOpen(ctx context.Context, args []string) int {
 		return 1
 	}
 	runner := buildRunner(cfg, false)
+	if *web {
+		return a.openWeb(ctx, runner, selected)
+	}
 	_, err = runner.Run(ctx, "", "code", selected.Path)
 	if err != nil {
 		a.Out.Err(err.Error(), nil)
@@ -57,6 +64,38 @@ func (a App) runRepoOpen(ctx context.Context, args []string) int {
 	return 0
 }
 
+func (a App) openWeb(ctx context.Context, runner executil.Runner, r repo.Repo) int {
+	origin, err := gitutil.OriginURL(ctx, runner, r.Path)
+	if err != nil || origin == "" {
+		a.Out.Err(fmt.Sprintf("%s: no origin remote", r.Name), nil)
+		return 1
+	}
+	remote, err := gitutil.ParseRemoteURL(origin)
+	if err != nil {
+		a.Out.Err(fmt.Sprintf("%s: %v", r.Name, err), nil)
+		return 1
+	}
+	url := remote.WebURL()
+	name, args := browserCommand(runtime.GOOS, url)
+	if _, err := runner.Run(ctx, "", name, args...); err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	a.Out.OK(fmt.Sprintf("opened %s", url), map[string]string{"repo": r.Name, "url": url})
+	return 0
+}
+
+func browserCommand(goos, url string) (string, []string) {
+	switch goos {
+	case "darwin":
+		return "open", []string{url}
+	case "windows":
+		return "rundll32", []string{"url.dll,FileProtocolHandler", url}
+	default:
+		return "xdg-open", []string{url}
+	}
+}
+
 func (a App) runRepoPath(_ context.Context, args []string) int {
 	fs := flag.NewFlagSet("repo path", flag.ContinueOnError)
 	fs.SetOutput(os.Stdout)
@@ -134,10 +173,15 @@ func (a App) runRepoInfo(ctx context.Context, args []string) int {
 	current, _ := gitutil.CurrentBranch(ctx, runner, selected.Path)
 	def, _ := gitutil.DefaultBranch(ctx, runner, selected.Path)
 	clean, _ := gitutil.IsClean(ctx, runner, selected.Path)
+	identity := ""
+	if origin != "" {
+		identity = gitutil.NormalizeRemote(origin)
+	}
 	info := repoInfo{
 		Name:          selected.Name,
 		Path:          selected.Path,
 		Origin:        origin,
+		Identity:      identity,
 		CurrentBranch: current,
 		DefaultBranch: def,
 		Dirty:         !clean,
@@ -239,23 +283,42 @@ func (a App) runRepoClone(ctx context.Context, args []string) int {
 	if repoName == "" {
 		repoName = nameFromArgs
 	}
-	if repoName == "" {
-		repoName = strings.TrimSuffix(filepath.Base(url), ".git")
-	}
-	dest := filepath.Join(cfg.ReposRoot, repoName)
-	if _, err := os.Stat(dest); err == nil {
-		a.Out.Err("destination exists", nil)
+	dest, err := repoCloneDest(cfg, url, repoName)
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
 		return 1
 	}
-	runner := buildRunner(cfg, false)
-	if err := gitutil.Clone(ctx, runner, url, dest); err != nil {
+	if err := cloneRepo(ctx, buildRunner(cfg, false), cfg, url, dest); err != nil {
 		a.Out.Err(err.Error(), nil)
 		return 1
 	}
-	a.Out.OK(fmt.Sprintf("cloned %s", repoName), nil)
+	a.Out.OK(fmt.Sprintf("cloned %s", filepath.Base(dest)), nil)
 	return 0
 }
 
+var errDestinationExists = errors.New("destination exists")
+
+func repoCloneDest(cfg config.Config, url, name string) (string, error) {
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(url), ".git")
+	}
+	dest := filepath.Join(cfg.ReposRoot, name)
+	if err := guardFromConfig(cfg).CheckPath(dest); err != nil {
+		return dest, err
+	}
+	if _, err := os.Stat(dest); err == nil {
+		return dest, errDestinationExists
+	}
+	return dest, nil
+}
+
+func cloneRepo(ctx context.Context, runner executil.Runner, cfg config.Config, url, dest string) error {
+	if err := os.MkdirAll(cfg.ReposRoot, 0o755); err != nil {
+		return err
+	}
+	return gitutil.Clone(ctx, runner, url, dest)
+}
+
 func parseCloneNameFromArgs(args []string) (string, error) {
 	name := ""
 	for i := 0; i < len(args); i++ {
@@ -0,0 +1,184 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/TT-AIXion/github-kanri/internal/config"
+	"github.com/TT-AIXion/github-kanri/internal/fsutil"
+	"github.com/TT-AIXion/github-kanri/internal/gitutil"
+)
+
+type discoveredRepo struct {
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	Origin string `json:"origin,omitempty"`
+	Dest   string `json:"dest,omitempty"`
+	Action string `json:"action"`
+	Error  string `json:"error,omitempty"`
+}
+
+var moveDir = fsutil.MoveDir
+
+func (a App) runRepoDiscover(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("repo discover", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+	move := fs.Bool("move", false, "move repos into reposRoot")
+	clone := fs.Bool("clone", false, "re-clone repos into reposRoot from origin")
+	dryRun := fs.Bool("dry-run", false, "dry run")
+	force := fs.Bool("force", false, "force")
+	dirs, err := parseInterspersed(fs, args)
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	if len(dirs) == 0 {
+		a.Out.Err("dir required", nil)
+		return 1
+	}
+	if *move && *clone {
+		a.Out.Err("use only one of --move or --clone", nil)
+		return 1
+	}
+	cfg, _, err := loadConfig()
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	runner := buildRunner(cfg, false)
+	guard := guardFromConfig(cfg)
+	var found, unreadable []discoveredRepo
+	hadError := false
+	for _, dir := range dirs {
+		root, err := config.ExpandPath(dir)
+		if err != nil {
+			a.Out.Err(err.Error(), nil)
+			return 1
+		}
+		paths, denied, err := fsutil.FindGitRepos(root)
+		if err != nil {
+			hadError = true
+			a.Out.Err(fmt.Sprintf("%s: %v", dir, err), nil)
+			continue
+		}
+		for _, path := range denied {
+			unreadable = append(unreadable, discoveredRepo{Name: filepath.Base(path), Path: path, Action: "skipped", Error: "permission denied"})
+		}
+		for _, path := range paths {
+			if isUnder(path, cfg.ReposRoot) || isUnder(path, cfg.SkillsRoot) {
+				continue
+			}
+			origin, _ := gitutil.OriginURL(ctx, runner, path)
+			found = append(found, discoveredRepo{Name: filepath.Base(path), Path: path, Origin: origin, Action: "found"})
+		}
+	}
+	for i := range found {
+		d := &found[i]
+		if !*move && !*clone {
+			continue
+		}
+		if *clone && d.Origin == "" {
+			d.Action = "skipped"
+			d.Error = "no origin"
+			continue
+		}
+		name := ""
+		if *move {
+			name = d.Name
+		}
+		d.Dest, err = repoCloneDest(cfg, d.Origin, name)
+		if err != nil {
+			d.Action = "skipped"
+			d.Error = err.Error()
+			continue
+		}
+		if *move {
+			if err := guard.CheckPath(d.Path); err != nil {
+				d.Action = "skipped"
+				d.Error = err.Error()
+				continue
+			}
+			if *dryRun {
+				d.Action = "would-move"
+				continue
+			}
+			if err := os.MkdirAll(cfg.ReposRoot, 0o755); err != nil {
+				hadError = true
+				d.Error = err.Error()
+				continue
+			}
+			if err := moveDir(d.Path, d.Dest); err != nil {
+				hadError = true
+				d.Error = err.Error()
+				continue
+			}
+			d.Action = "moved"
+			continue
+		}
+		if !*force {
+			if clean, err := gitutil.IsClean(ctx, runner, d.Path); err != nil || !clean {
+				d.Action = "skipped"
+				d.Error = "dirty (use --force)"
+				continue
+			}
+		}
+		if *dryRun {
+			d.Action = "would-clone"
+			continue
+		}
+		if err := cloneRepo(ctx, runner, cfg, d.Origin, d.Dest); err != nil {
+			hadError = true
+			d.Error = err.Error()
+			continue
+		}
+		d.Action = "cloned"
+	}
+	none := len(found) == 0
+	found = append(found, unreadable...)
+	if a.Out.JSON {
+		a.Out.OK("repo discover", found)
+		if hadError {
+			return 1
+		}
+		return 0
+	}
+	if none {
+		a.Out.Warn("no unmanaged repos found", nil)
+	}
+	for _, d := range found {
+		line := fmt.Sprintf("%s %s %s", d.Action, d.Name, d.Path)
+		if d.Origin != "" {
+			line += " origin=" + d.Origin
+		}
+		if d.Dest != "" {
+			line += " -> " + d.Dest
+		}
+		switch {
+		case d.Error != "" && d.Action == "skipped":
+			a.Out.Warn(fmt.Sprintf("%s (%s)", line, d.Error), nil)
+		case d.Error != "":
+			a.Out.Err(fmt.Sprintf("%s: %s", line, d.Error), nil)
+		default:
+			a.Out.OK(line, nil)
+		}
+	}
+	if hadError {
+		return 1
+	}
+	return 0
+}
+
+func isUnder(path, root string) bool {
+	if strings.TrimSpace(root) == "" {
+		return false
+	}
+	rel, err := filepath.Rel(filepath.Clean(root), filepath.Clean(path))
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
@@ -0,0 +1,311 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/TT-AIXion/github-kanri/internal/executil"
+	"github.com/TT-AIXion/github-kanri/internal/fsutil"
+	"github.com/TT-AIXion/github-kanri/internal/gitutil"
+	"github.com/TT-AIXion/github-kanri/internal/repo"
+)
+
+type repoDiskUsage struct {
+	Name          string   `json:"name"`
+	Path          string   `json:"path"`
+	Total         int64    `json:"total"`
+	Worktree      int64    `json:"worktree"`
+	Git           int64    `json:"git"`
+	Artifacts     int64    `json:"artifacts"`
+	ArtifactPaths []string `json:"artifactPaths,omitempty"`
+	Error         string   `json:"error,omitempty"`
+	ignored       []string
+	ignoredSize   int64
+}
+
+type repoGCResult struct {
+	Name      string   `json:"name"`
+	Path      string   `json:"path"`
+	Before    int64    `json:"before"`
+	After     int64    `json:"after"`
+	Reclaimed int64    `json:"reclaimed"`
+	Removed   []string `json:"removed,omitempty"`
+	DryRun    bool     `json:"dryRun,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+var dirSize = fsutil.DirSize
+
+var artifactDirs = map[string]bool{
+	"node_modules": true,
+	"target":       true,
+	"dist":         true,
+	"build":        true,
+	".venv":        true,
+	"venv":         true,
+	"__pycache__":  true,
+	".next":        true,
+	".gradle":      true,
+	".tox":         true,
+}
+
+func (a App) runRepoDu(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("repo du", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+	sortBy := fs.String("sort", "total", "total|worktree|git|artifacts")
+	limit := fs.Int("limit", 0, "limit")
+	var only multiFlag
+	var exclude multiFlag
+	fs.Var(&only, "only", "only patterns")
+	fs.Var(&exclude, "exclude", "exclude patterns")
+	if err := fs.Parse(args); err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	key, ok := duSortKeys[*sortBy]
+	if !ok {
+		a.Out.Err(fmt.Sprintf("invalid sort: %s", *sortBy), nil)
+		return 1
+	}
+	cfg, _, err := loadConfig()
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	repos, err := repo.Scan(cfg.ReposRoot)
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	repos = repo.Filter(repos, only, exclude)
+	runner := buildRunner(cfg, false)
+	var usages []repoDiskUsage
+	hadError := false
+	for _, r := range repos {
+		usage, err := measureRepo(ctx, runner, r)
+		if err != nil {
+			hadError = true
+			usage.Error = err.Error()
+		}
+		usages = append(usages, usage)
+	}
+	sort.SliceStable(usages, func(i, j int) bool { return key(usages[i]) > key(usages[j]) })
+	if *limit > 0 && len(usages) > *limit {
+		usages = usages[:*limit]
+	}
+	if a.Out.JSON {
+		a.Out.OK("repo du", usages)
+		if hadError {
+			return 1
+		}
+		return 0
+	}
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', tabwriter.AlignRight)
+	_, _ = fmt.Fprintln(w, "TOTAL\tWORKTREE\tGIT\tARTIFACTS\t REPO")
+	var sum repoDiskUsage
+	for _, u := range usages {
+		if u.Error != "" {
+			continue
+		}
+		sum.Total += u.Total
+		sum.Worktree += u.Worktree
+		sum.Git += u.Git
+		sum.Artifacts += u.Artifacts
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t %s\n", formatBytes(u.Total), formatBytes(u.Worktree), formatBytes(u.Git), formatBytes(u.Artifacts), u.Name)
+	}
+	_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t %s\n", formatBytes(sum.Total), formatBytes(sum.Worktree), formatBytes(sum.Git), formatBytes(sum.Artifacts), "(total)")
+	_ = w.Flush()
+	a.Out.Raw(strings.TrimRight(b.String(), "\n"))
+	for _, u := range usages {
+		if u.Error != "" {
+			a.Out.Err(fmt.Sprintf("%s: %s", u.Name, u.Error), nil)
+		}
+	}
+	if hadError {
+		return 1
+	}
+	return 0
+}
+
+func (a App) runRepoGc(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("repo gc", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+	dryRun := fs.Bool("dry-run", false, "dry run")
+	force := fs.Bool("force", false, "force")
+	noClean := fs.Bool("no-clean", false, "skip git clean -X")
+	var only multiFlag
+	var exclude multiFlag
+	fs.Var(&only, "only", "only patterns")
+	fs.Var(&exclude, "exclude", "exclude patterns")
+	if err := fs.Parse(args); err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	if !*dryRun && !*force {
+		a.Out.Err("gc requires --force (or use --dry-run)", nil)
+		return 1
+	}
+	cfg, _, err := loadConfig()
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	repos, err := repo.Scan(cfg.ReposRoot)
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	repos = repo.Filter(repos, only, exclude)
+	runner := buildRunner(cfg, false)
+	guard := guardFromConfig(cfg)
+	var results []repoGCResult
+	hadError := false
+	for _, r := range repos {
+		result := repoGCResult{Name: r.Name, Path: r.Path, DryRun: *dryRun}
+		if err := guard.CheckPath(r.Path); err != nil {
+			hadError = true
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+		before, err := measureRepo(ctx, runner, r)
+		if err != nil {
+			hadError = true
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+		result.Before = before.Total
+		if !*noClean {
+			result.Removed = before.ignored
+		}
+		if *dryRun {
+			if !*noClean {
+				result.Reclaimed = before.ignoredSize
+			}
+			results = append(results, result)
+			continue
+		}
+		if !*noClean {
+			if err := gitutil.CleanIgnored(ctx, runner, r.Path); err != nil {
+				hadError = true
+				result.Error = err.Error()
+				results = append(results, result)
+				continue
+			}
+		}
+		if err := gitutil.GC(ctx, runner, r.Path); err != nil {
+			hadError = true
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+		after, err := dirSize(r.Path)
+		if err != nil {
+			hadError = true
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+		result.After = after
+		result.Reclaimed = result.Before - after
+		results = append(results, result)
+	}
+	if a.Out.JSON {
+		a.Out.OK("repo gc", results)
+		if hadError {
+			return 1
+		}
+		return 0
+	}
+	var total int64
+	for _, r := range results {
+		if r.Error != "" {
+			a.Out.Err(fmt.Sprintf("%s: %s", r.Name, r.Error), nil)
+			continue
+		}
+		total += r.Reclaimed
+		if r.DryRun {
+			a.Out.Warn(fmt.Sprintf("%s would remove %d paths (%s) and run git gc", r.Name, len(r.Removed), formatBytes(r.Reclaimed)), nil)
+			continue
+		}
+		a.Out.OK(fmt.Sprintf("%s reclaimed %s", r.Name, formatBytes(r.Reclaimed)), nil)
+	}
+	a.Out.OK(fmt.Sprintf("total %s", formatBytes(total)), nil)
+	if hadError {
+		return 1
+	}
+	return 0
+}
+
+var duSortKeys = map[string]func(repoDiskUsage) int64{
+	"total":     func(u repoDiskUsage) int64 { return u.Total },
+	"worktree":  func(u repoDiskUsage) int64 { return u.Worktree },
+	"git":       func(u repoDiskUsage) int64 { return u.Git },
+	"artifacts": func(u repoDiskUsage) int64 { return u.Artifacts },
+}
+
+func measureRepo(ctx context.Context, runner executil.Runner, r repo.Repo) (repoDiskUsage, error) {
+	usage := repoDiskUsage{Name: r.Name, Path: r.Path}
+	total, err := dirSize(r.Path)
+	if err != nil {
+		return usage, err
+	}
+	gitSize, err := dirSize(filepath.Join(r.Path, ".git"))
+	if err != nil {
+		return usage, err
+	}
+	untracked, ignored, err := gitutil.UntrackedPaths(ctx, runner, r.Path)
+	if err != nil {
+		return usage, err
+	}
+	for _, rel := range ignored {
+		rel = strings.TrimSuffix(rel, "/")
+		size, err := dirSize(filepath.Join(r.Path, filepath.FromSlash(rel)))
+		if err != nil {
+			return usage, err
+		}
+		usage.ignored = append(usage.ignored, rel)
+		usage.ignoredSize += size
+	}
+	usage.Artifacts = usage.ignoredSize
+	usage.ArtifactPaths = slices.Clone(usage.ignored)
+	for _, rel := range untracked {
+		if !strings.HasSuffix(rel, "/") || !artifactDirs[path.Base(strings.TrimSuffix(rel, "/"))] {
+			continue
+		}
+		rel = strings.TrimSuffix(rel, "/")
+		size, err := dirSize(filepath.Join(r.Path, filepath.FromSlash(rel)))
+		if err != nil {
+			return usage, err
+		}
+		usage.Artifacts += size
+		usage.ArtifactPaths = append(usage.ArtifactPaths, rel)
+	}
+	usage.Total = total
+	usage.Git = gitSize
+	usage.Worktree = total - gitSize
+	return usage, nil
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
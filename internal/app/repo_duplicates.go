@@ -0,0 +1,228 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TT-AIXion/github-kanri/internal/executil"
+	"github.com/TT-AIXion/github-kanri/internal/gitutil"
+	"github.com/TT-AIXion/github-kanri/internal/repo"
+)
+
+type duplicateMember struct {
+	Name       string    `json:"name"`
+	Path       string    `json:"path"`
+	Origin     string    `json:"origin,omitempty"`
+	Identity   string    `json:"identity,omitempty"`
+	Head       string    `json:"head,omitempty"`
+	Dirty      bool      `json:"dirty"`
+	Ahead      int       `json:"ahead"`
+	NoUpstream bool      `json:"noUpstream,omitempty"`
+	LastCommit time.Time `json:"lastCommit"`
+	Keep       bool      `json:"keep"`
+}
+
+type duplicateGroup struct {
+	Kind      string            `json:"kind"`
+	Key       string            `json:"key"`
+	Divergent bool              `json:"divergent"`
+	Reason    string            `json:"reason"`
+	Members   []duplicateMember `json:"members"`
+}
+
+func (a App) runRepoDuplicates(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("repo duplicates", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+	var only multiFlag
+	var exclude multiFlag
+	fs.Var(&only, "only", "only patterns")
+	fs.Var(&exclude, "exclude", "exclude patterns")
+	if err := fs.Parse(args); err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	cfg, _, err := loadConfig()
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	repos, err := repo.Scan(cfg.ReposRoot)
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	repos = repo.Filter(repos, only, exclude)
+	runner := buildRunner(cfg, false)
+	members := make([]duplicateMember, 0, len(repos))
+	for _, r := range repos {
+		members = append(members, inspectDuplicate(ctx, runner, r))
+	}
+	groups := findDuplicates(members, cfg.ReposRoot)
+	if a.Out.JSON {
+		a.Out.OK("repo duplicates", groups)
+		return 0
+	}
+	if len(groups) == 0 {
+		a.Out.OK("no duplicates", nil)
+		return 0
+	}
+	for _, g := range groups {
+		line := fmt.Sprintf("%s %s (%s)", g.Kind, g.Key, g.Reason)
+		if g.Divergent {
+			a.Out.Warn(line, nil)
+		} else {
+			a.Out.OK(line, nil)
+		}
+		for _, m := range g.Members {
+			mark := " "
+			if m.Keep {
+				mark = "*"
+			}
+			ahead := strconv.Itoa(m.Ahead)
+			if m.NoUpstream {
+				ahead = "?"
+			}
+			a.Out.Raw(fmt.Sprintf("  %s %s head=%s dirty=%v ahead=%s origin=%s", mark, m.Path, shortHash(m.Head), m.Dirty, ahead, m.Origin))
+		}
+	}
+	return 0
+}
+
+func inspectDuplicate(ctx context.Context, runner executil.Runner, r repo.Repo) duplicateMember {
+	m := duplicateMember{Name: r.Name, Path: r.Path}
+	m.Origin, _ = gitutil.OriginURL(ctx, runner, r.Path)
+	if m.Origin != "" {
+		m.Identity = gitutil.NormalizeRemote(m.Origin)
+	}
+	m.Head, _ = gitutil.HeadCommit(ctx, runner, r.Path)
+	if clean, err := gitutil.IsClean(ctx, runner, r.Path); err == nil {
+		m.Dirty = !clean
+	}
+	if ahead, _, err := gitutil.AheadBehind(ctx, runner, r.Path); err == nil {
+		m.Ahead = ahead
+	} else {
+		m.NoUpstream = true
+	}
+	if unix, err := gitutil.LastCommitUnix(ctx, runner, r.Path); err == nil {
+		m.LastCommit = time.Unix(unix, 0)
+	}
+	return m
+}
+
+func findDuplicates(members []duplicateMember, reposRoot string) []duplicateGroup {
+	byIdentity := map[string][]duplicateMember{}
+	byName := map[string][]duplicateMember{}
+	for _, m := range members {
+		if m.Identity != "" {
+			byIdentity[m.Identity] = append(byIdentity[m.Identity], m)
+		}
+		byName[strings.ToLower(m.Name)] = append(byName[strings.ToLower(m.Name)], m)
+	}
+	var groups []duplicateGroup
+	for key, ms := range byIdentity {
+		if len(ms) < 2 {
+			continue
+		}
+		groups = append(groups, newDuplicateGroup("origin", key, ms, reposRoot))
+	}
+	for key, ms := range byName {
+		if len(ms) < 2 || sameIdentity(ms) {
+			continue
+		}
+		groups = append(groups, duplicateGroup{Kind: "name", Key: key, Members: ms, Reason: nameGroupReason(ms)})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Kind != groups[j].Kind {
+			return groups[i].Kind > groups[j].Kind
+		}
+		return groups[i].Key < groups[j].Key
+	})
+	return groups
+}
+
+func newDuplicateGroup(kind, key string, ms []duplicateMember, reposRoot string) duplicateGroup {
+	g := duplicateGroup{Kind: kind, Key: key, Members: ms}
+	heads := map[string]struct{}{}
+	var unpushed []int
+	unknown := false
+	for i, m := range ms {
+		heads[m.Head] = struct{}{}
+		if m.Dirty || m.Ahead > 0 || m.NoUpstream {
+			unpushed = append(unpushed, i)
+			unknown = unknown || (m.NoUpstream && !m.Dirty)
+		}
+	}
+	g.Divergent = len(heads) > 1 || len(unpushed) > 0
+	keep := -1
+	switch {
+	case len(unpushed) > 1 && unknown:
+		g.Reason = "unpushed or untracked work in several clones; merge manually"
+	case len(unpushed) > 1:
+		g.Reason = "unpushed work in several clones; merge manually"
+	case unknown:
+		keep = unpushed[0]
+		g.Reason = "keep the clone without an upstream branch; its unpushed work is unknown"
+	case len(unpushed) == 1:
+		keep = unpushed[0]
+		g.Reason = "keep the clone with unpushed work"
+	default:
+		keep = 0
+		for i, m := range ms {
+			if inLayout(m.Path, reposRoot) != inLayout(ms[keep].Path, reposRoot) {
+				if inLayout(m.Path, reposRoot) {
+					keep = i
+				}
+				continue
+			}
+			if m.LastCommit.After(ms[keep].LastCommit) {
+				keep = i
+			}
+		}
+		if len(heads) > 1 {
+			g.Reason = "different HEADs; keep the newest"
+		} else {
+			g.Reason = "identical clones"
+		}
+	}
+	if keep >= 0 {
+		g.Members[keep].Keep = true
+	}
+	return g
+}
+
+func nameGroupReason(ms []duplicateMember) string {
+	withOrigin := 0
+	for _, m := range ms {
+		if m.Identity != "" {
+			withOrigin++
+		}
+	}
+	switch withOrigin {
+	case 0:
+		return "same name, no origins"
+	case len(ms):
+		return "same name, different origins"
+	default:
+		return "same name, some without origin"
+	}
+}
+
+func sameIdentity(ms []duplicateMember) bool {
+	for _, m := range ms[1:] {
+		if m.Identity != ms[0].Identity {
+			return false
+		}
+	}
+	return ms[0].Identity != ""
+}
+
+func inLayout(path, reposRoot string) bool {
+	return filepath.Dir(filepath.Clean(path)) == filepath.Clean(reposRoot)
+}
@@ -0,0 +1,175 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/TT-AIXion/github-kanri/internal/gitutil"
+	"github.com/TT-AIXion/github-kanri/internal/repo"
+)
+
+type remoteInfo struct {
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	Origin   string `json:"origin"`
+	Identity string `json:"identity"`
+}
+
+type remoteRewrite struct {
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	Old    string `json:"old"`
+	New    string `json:"new"`
+	DryRun bool   `json:"dryRun,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (a App) runRepoRemote(ctx context.Context, args []string) int {
+	if len(args) == 0 || isHelp(args[0]) {
+		a.Out.Raw(`gkn repo remote <command>
+
+Commands:
+  list
+  rewrite --from <prefix> --to <prefix> [--dry-run]`)
+		return 0
+	}
+	switch args[0] {
+	case "list":
+		return a.runRepoRemoteList(ctx, args[1:])
+	case "rewrite":
+		return a.runRepoRemoteRewrite(ctx, args[1:])
+	default:
+		a.Out.Err(fmt.Sprintf("unknown repo remote command: %s", args[0]), nil)
+		return 1
+	}
+}
+
+func (a App) runRepoRemoteList(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("repo remote list", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+	var only multiFlag
+	var exclude multiFlag
+	fs.Var(&only, "only", "only patterns")
+	fs.Var(&exclude, "exclude", "exclude patterns")
+	if err := fs.Parse(args); err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	cfg, _, err := loadConfig()
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	repos, err := repo.Scan(cfg.ReposRoot)
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	repos = repo.Filter(repos, only, exclude)
+	runner := buildRunner(cfg, false)
+	var out []remoteInfo
+	for _, r := range repos {
+		origin, _ := gitutil.OriginURL(ctx, runner, r.Path)
+		identity := ""
+		if origin != "" {
+			identity = gitutil.NormalizeRemote(origin)
+		}
+		out = append(out, remoteInfo{Name: r.Name, Path: r.Path, Origin: origin, Identity: identity})
+	}
+	if a.Out.JSON {
+		a.Out.OK("repo remote list", out)
+		return 0
+	}
+	for _, r := range out {
+		if r.Origin == "" {
+			a.Out.Warn(fmt.Sprintf("%s no-origin", r.Name), nil)
+			continue
+		}
+		a.Out.OK(fmt.Sprintf("%s %s %s", r.Name, r.Identity, r.Origin), nil)
+	}
+	return 0
+}
+
+func (a App) runRepoRemoteRewrite(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("repo remote rewrite", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+	from := fs.String("from", "", "url prefix to replace")
+	to := fs.String("to", "", "replacement prefix")
+	dryRun := fs.Bool("dry-run", false, "dry run")
+	var only multiFlag
+	var exclude multiFlag
+	fs.Var(&only, "only", "only patterns")
+	fs.Var(&exclude, "exclude", "exclude patterns")
+	if err := fs.Parse(args); err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	if strings.TrimSpace(*from) == "" || strings.TrimSpace(*to) == "" {
+		a.Out.Err("--from and --to required", nil)
+		return 1
+	}
+	cfg, _, err := loadConfig()
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	repos, err := repo.Scan(cfg.ReposRoot)
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	repos = repo.Filter(repos, only, exclude)
+	runner := buildRunner(cfg, false)
+	guard := guardFromConfig(cfg)
+	var results []remoteRewrite
+	hadError := false
+	for _, r := range repos {
+		origin, err := gitutil.OriginURL(ctx, runner, r.Path)
+		if err != nil || origin == "" {
+			continue
+		}
+		next, ok := gitutil.RewriteRemote(origin, *from, *to)
+		if !ok || next == origin {
+			continue
+		}
+		result := remoteRewrite{Name: r.Name, Path: r.Path, Old: origin, New: next, DryRun: *dryRun}
+		if err := guard.CheckPath(r.Path); err != nil {
+			hadError = true
+			result.Error = err.Error()
+		} else if !*dryRun {
+			if err := gitutil.SetRemoteURL(ctx, runner, r.Path, "origin", next); err != nil {
+				hadError = true
+				result.Error = err.Error()
+			}
+		}
+		results = append(results, result)
+	}
+	if a.Out.JSON {
+		a.Out.OK("repo remote rewrite", results)
+		if hadError {
+			return 1
+		}
+		return 0
+	}
+	if len(results) == 0 {
+		a.Out.Warn("no matching remotes", nil)
+	}
+	for _, r := range results {
+		line := fmt.Sprintf("%s %s -> %s", r.Name, r.Old, r.New)
+		switch {
+		case r.Error != "":
+			a.Out.Err(fmt.Sprintf("%s: %s", line, r.Error), nil)
+		case r.DryRun:
+			a.Out.Warn("would rewrite "+line, nil)
+		default:
+			a.Out.OK("rewrote "+line, nil)
+		}
+	}
+	if hadError {
+		return 1
+	}
+	return 0
+}
@@ -16,6 +16,7 @@ type repoInfo struct {
 	Name          string `json:"name"`
 	Path          string `json:"path"`
 	Origin        string `json:"origin"`
+	Identity      string `json:"identity,omitempty"`
 	CurrentBranch string `json:"currentBranch"`
 	DefaultBranch string `json:"defaultBranch"`
 	Dirty         bool   `json:"dirty"`
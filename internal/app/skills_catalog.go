@@ -0,0 +1,196 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/TT-AIXion/github-kanri/internal/config"
+	"github.com/TT-AIXion/github-kanri/internal/skill"
+)
+
+type skillRoot struct {
+	Name  string
+	Dir   string
+	Error string
+}
+
+type skillsLintResult struct {
+	Skills   int           `json:"skills"`
+	Errors   int           `json:"errors"`
+	Warnings int           `json:"warnings"`
+	Issues   []skill.Issue `json:"issues"`
+}
+
+func skillRoots(cfg config.Config, targets []config.SyncTarget) []skillRoot {
+	if len(targets) == 0 {
+		return []skillRoot{{Name: rootSourceName, Dir: cfg.SkillsRoot}}
+	}
+	var out []skillRoot
+	seen := map[string]bool{}
+	for _, t := range targets {
+		if seen[t.Src] {
+			continue
+		}
+		seen[t.Src] = true
+		out = append(out, skillRoot{Name: skillRootName(cfg, t), Dir: t.Src})
+	}
+	return out
+}
+
+func skillRootName(cfg config.Config, t config.SyncTarget) string {
+	base, name := cfg.SkillsRoot, rootSourceName
+	if strings.TrimSpace(t.Remote) != "" {
+		base, name = t.Checkout, t.Remote
+		if t.Ref != "" {
+			name += "@" + t.Ref
+		}
+	}
+	rel, err := filepath.Rel(base, t.Src)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return t.Src
+	}
+	if rel == "." {
+		return name
+	}
+	return name + "/" + filepath.ToSlash(rel)
+}
+
+func discoverSkills(roots []skillRoot) ([]skill.Skill, []skillRoot) {
+	var skills []skill.Skill
+	seen := map[string]bool{}
+	for i, root := range roots {
+		found, err := skill.Discover(root.Dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				err = fmt.Errorf("source not found: %s (run gkn skills clone)", root.Dir)
+			}
+			roots[i].Error = err.Error()
+			continue
+		}
+		for _, s := range found {
+			if seen[s.Dir] {
+				continue
+			}
+			seen[s.Dir] = true
+			s.Source = root.Name
+			skills = append(skills, s)
+		}
+	}
+	return skills, roots
+}
+
+func (a App) loadSkillCatalog(name string, args []string) ([]skill.Skill, []skillRoot, bool) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+	target := fs.String("target", "", "target name")
+	if err := fs.Parse(args); err != nil {
+		a.Out.Err(err.Error(), nil)
+		return nil, nil, false
+	}
+	cfg, _, err := loadConfig()
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return nil, nil, false
+	}
+	targets, err := selectTargets(cfg, *target)
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return nil, nil, false
+	}
+	skills, roots := discoverSkills(skillRoots(cfg, targets))
+	return skills, roots, true
+}
+
+func skillLocation(s skill.Skill) string {
+	if s.Path == "." {
+		return s.Source
+	}
+	return s.Source + ":" + s.Path
+}
+
+func (a App) runSkillsList(_ context.Context, args []string) int {
+	skills, roots, ok := a.loadSkillCatalog("skills list", args)
+	if !ok {
+		return 1
+	}
+	failed := 0
+	for _, root := range roots {
+		if root.Error != "" {
+			failed++
+			a.Out.Err(root.Error, nil)
+		}
+	}
+	if a.Out.JSON {
+		if skills == nil {
+			skills = []skill.Skill{}
+		}
+		a.Out.OK("skills list", skills)
+	} else {
+		for _, s := range skills {
+			if s.Error != "" {
+				a.Out.Warn(fmt.Sprintf("%s: %s", skillLocation(s), s.Error), nil)
+				continue
+			}
+			version := s.Version
+			if version == "" {
+				version = "-"
+			}
+			line := fmt.Sprintf("%s %s %s", s.Name, version, skillLocation(s))
+			if s.Description != "" {
+				line += " - " + s.Description
+			}
+			a.Out.OK(line, nil)
+		}
+	}
+	return a.failureCode(failed, len(roots), "sources")
+}
+
+func (a App) runSkillsLint(_ context.Context, args []string) int {
+	skills, roots, ok := a.loadSkillCatalog("skills lint", args)
+	if !ok {
+		return 1
+	}
+	var issues []skill.Issue
+	for _, root := range roots {
+		if root.Error != "" {
+			issues = append(issues, skill.Issue{Source: root.Name, Path: ".", Level: skill.LevelError, Message: root.Error})
+		}
+	}
+	issues = append(issues, skill.Lint(skills)...)
+	result := skillsLintResult{Skills: len(skills), Issues: issues}
+	if result.Issues == nil {
+		result.Issues = []skill.Issue{}
+	}
+	for _, issue := range issues {
+		if issue.Level == skill.LevelError {
+			result.Errors++
+		} else {
+			result.Warnings++
+		}
+	}
+	if a.Out.JSON {
+		a.Out.OK("skills lint", result)
+	} else {
+		for _, issue := range issues {
+			msg := issue.Message
+			if issue.Field != "" {
+				msg = issue.Field + ": " + msg
+			}
+			msg = fmt.Sprintf("%s: %s", skillLocation(skill.Skill{Source: issue.Source, Path: issue.Path}), msg)
+			if issue.Level == skill.LevelError {
+				a.Out.Err(msg, nil)
+			} else {
+				a.Out.Warn(msg, nil)
+			}
+		}
+		a.Out.OK(fmt.Sprintf("%d skills, %d errors, %d warnings", result.Skills, result.Errors, result.Warnings), nil)
+	}
+	if skill.HasErrors(issues) {
+		return 1
+	}
+	return 0
+}
@@ -2,18 +2,28 @@ package app
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/TT-AIXion/github-kanri/internal/config"
 	"github.com/TT-AIXion/github-kanri/internal/fsutil"
 	"github.com/TT-AIXion/github-kanri/internal/gitutil"
+	"github.com/TT-AIXion/github-kanri/internal/match"
+	"github.com/TT-AIXion/github-kanri/internal/watch"
 )
 
-var timeAfter = time.After
+var newWatcher = watch.New
+var watchSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
 var removeAll = os.RemoveAll
 
 func (a App) runSkillsClone(ctx context.Context, args []string) int {
@@ -34,36 +44,67 @@ func (a App) runSkillsClone(ctx context.Context, args []string) int {
 	if url == "" {
 		url = strings.TrimSpace(cfg.SkillsRemote)
 	}
-	if url == "" {
+	sources := remoteSources(cfg)
+	if url == "" && len(sources) == 0 {
 		a.Out.Err("skillsRemote or --remote required", nil)
 		return 1
 	}
+	total, failed := len(sources), 0
+	if url != "" {
+		total++
+		msg, err := a.cloneSkillsRoot(ctx, cfg, url, *force)
+		if err != nil {
+			failed++
+			a.Out.Err(err.Error(), nil)
+		} else {
+			a.Out.OK(msg, nil)
+		}
+	}
+	failed += a.updateSources(ctx, cfg, sources)
+	a.pruneSourceCache(sources)
+	return a.failureCode(failed, total, "sources")
+}
+
+func (a App) updateSources(ctx context.Context, cfg config.Config, sources []skillSource) int {
+	runner := buildRunner(cfg, false)
+	guard := guardFromConfig(cfg)
+	failed := 0
+	for _, s := range sources {
+		err := guard.CheckPath(s.Dir)
+		if err == nil {
+			s.Action, err = updateSource(ctx, runner, s)
+		}
+		if err != nil {
+			failed++
+			s.Error = err.Error()
+			a.Out.Err(fmt.Sprintf("source %s: %v", s.Name, err), s)
+			continue
+		}
+		a.Out.OK(fmt.Sprintf("source %s %s (%s)", s.Name, s.Action, strings.Join(s.Targets, ",")), s)
+	}
+	return failed
+}
+
+func (a App) cloneSkillsRoot(ctx context.Context, cfg config.Config, url string, force bool) (string, error) {
+	runner := buildRunner(cfg, false)
 	if _, err := os.Stat(cfg.SkillsRoot); err == nil {
 		if fsutil.IsGitRepo(cfg.SkillsRoot) {
-			runner := buildRunner(cfg, false)
 			if err := gitutil.Pull(ctx, runner, cfg.SkillsRoot); err != nil {
-				a.Out.Err(err.Error(), nil)
-				return 1
+				return "", err
 			}
-			a.Out.OK("skills updated", nil)
-			return 0
+			return "skills updated", nil
 		}
-		if !*force {
-			a.Out.Err("skillsRoot exists (use --force)", nil)
-			return 1
+		if !force {
+			return "", errors.New("skillsRoot exists (use --force)")
 		}
 		if err := removeAll(cfg.SkillsRoot); err != nil {
-			a.Out.Err(err.Error(), nil)
-			return 1
+			return "", err
 		}
 	}
-	runner := buildRunner(cfg, false)
 	if err := gitutil.Clone(ctx, runner, url, cfg.SkillsRoot); err != nil {
-		a.Out.Err(err.Error(), nil)
-		return 1
+		return "", err
 	}
-	a.Out.OK("skills cloned", nil)
-	return 0
+	return "skills cloned", nil
 }
 
 func (a App) runSkillsPin(ctx context.Context, args []string) int {
@@ -80,15 +121,19 @@ func (a App) runSkillsPin(ctx context.Context, args []string) int {
 		a.Out.Err("--target and --ref required", nil)
 		return 1
 	}
-	cfg, _, err := loadConfig()
+	cfg, path, err := loadConfig()
 	if err != nil {
 		a.Out.Err(err.Error(), nil)
 		return 1
 	}
-	if _, err := selectTargets(cfg, *target); err != nil {
+	selected, err := selectTargets(cfg, *target)
+	if err != nil {
 		a.Out.Err(err.Error(), nil)
 		return 1
 	}
+	if strings.TrimSpace(selected[0].Remote) != "" {
+		return a.pinTargetSource(ctx, cfg, path, selected[0], strings.TrimSpace(*ref))
+	}
 	runner := buildRunner(cfg, false)
 	clean, err := gitutil.IsClean(ctx, runner, cfg.SkillsRoot)
 	if err != nil {
@@ -107,15 +152,65 @@ func (a App) runSkillsPin(ctx context.Context, args []string) int {
 		a.Out.Err(err.Error(), nil)
 		return 1
 	}
+	a.Out.Warn(fmt.Sprintf("%s has no remote; pinned the shared skillsRoot for every target using it", *target), nil)
 	a.Out.OK(fmt.Sprintf("pinned %s", *target), nil)
-	return 0
+	return a.relock(ctx)
+}
+
+func (a App) pinTargetSource(ctx context.Context, cfg config.Config, path string, t config.SyncTarget, ref string) int {
+	dir, err := config.SourceCheckoutDir(t.Remote, ref)
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	if err := guardFromConfig(cfg).CheckPath(dir); err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	s := skillSource{Remote: t.Remote, Ref: ref, Dir: dir, Targets: []string{t.Name}}
+	if _, err := updateSource(ctx, buildRunner(cfg, false), s); err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	raw, err := config.Load(path)
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	for i := range raw.SyncTargets {
+		if raw.SyncTargets[i].Name == t.Name {
+			raw.SyncTargets[i].Ref = ref
+		}
+	}
+	if err := config.Save(path, raw); err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	a.Out.OK(fmt.Sprintf("pinned %s to %s", t.Name, ref), nil)
+	return a.relock(ctx)
+}
+
+func (a App) relock(ctx context.Context) int {
+	cfg, path, err := loadConfig()
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	lockPath := lockFilePath(path, "")
+	if _, err := os.Stat(lockPath); err != nil {
+		return 0
+	}
+	return a.writeSkillsLock(ctx, cfg, lockPath)
 }
 
 func (a App) runSkillsWatch(ctx context.Context, args []string) int {
 	fs := flag.NewFlagSet("skills watch", flag.ContinueOnError)
 	fs.SetOutput(os.Stdout)
 	target := fs.String("target", "", "target")
-	interval := fs.Int("interval", 5, "interval seconds")
+	interval := fs.Int("interval", 5, "polling interval seconds")
+	poll := fs.Bool("poll", false, "poll instead of using filesystem notifications")
+	debounce := fs.Duration("debounce", 300*time.Millisecond, "wait until changes settle")
+	logPath := fs.String("log", "", "append a JSON line per sync to this file")
 	if err := fs.Parse(args); err != nil {
 		a.Out.Err(err.Error(), nil)
 		return 1
@@ -133,45 +228,124 @@ func (a App) runSkillsWatch(ctx context.Context, args []string) int {
 		a.Out.Err(err.Error(), nil)
 		return 1
 	}
-	state, err := skillsState(ctx, cfg)
+	var roots []string
+	for _, t := range targets {
+		if _, err := os.Stat(t.Src); err != nil {
+			a.Out.Err(fmt.Sprintf("source not found: %s", t.Src), nil)
+			return 1
+		}
+		roots = append(roots, t.Src)
+	}
+	var eventLog io.Writer
+	if *logPath != "" {
+		f, err := os.OpenFile(*logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			a.Out.Err(err.Error(), nil)
+			return 1
+		}
+		defer f.Close()
+		eventLog = f
+	}
+	ctx, stop := signal.NotifyContext(ctx, watchSignals...)
+	defer stop()
+	w, err := newWatcher(roots, time.Duration(*interval)*time.Second, *poll)
 	if err != nil {
 		a.Out.Err(err.Error(), nil)
 		return 1
 	}
+	defer w.Close()
+	batches := watch.Debounce(ctx, w.Events(), *debounce)
 	a.Out.OK("watch started", nil)
 	for {
 		select {
 		case <-ctx.Done():
+			a.Out.OK("watch stopped", nil)
 			return 0
-		case <-timeAfter(time.Duration(*interval) * time.Second):
-			newState, err := skillsState(ctx, cfg)
-			if err != nil {
-				a.Out.Err(err.Error(), nil)
+		case err := <-w.Errors():
+			a.Out.Err(err.Error(), nil)
+			return 1
+		case batch, ok := <-batches:
+			if !ok {
+				if ctx.Err() != nil {
+					a.Out.OK("watch stopped", nil)
+					return 0
+				}
+				a.Out.Err("watcher stopped", nil)
 				return 1
 			}
-			if newState != state {
-				state = newState
-				err := a.syncTargets(ctx, cfg, targets, "", false, false, nil, nil)
-				if err != 0 {
-					return err
+			a.syncChanged(ctx, cfg, targets, batch, eventLog)
+		}
+	}
+}
+
+func (a App) syncChanged(ctx context.Context, cfg config.Config, targets []config.SyncTarget, batch []string, eventLog io.Writer) {
+	for _, t := range targets {
+		paths, full, changed := changedPaths(t, batch)
+		if !changed {
+			continue
+		}
+		event := watchEvent{Time: time.Now().UTC(), Target: t.Name, Paths: paths, Full: full}
+		jobs, err := syncJobs(ctx, cfg, []config.SyncTarget{t}, "", false, false, nil, nil)
+		if err == nil {
+			for i := range jobs {
+				jobs[i].opts.Paths = paths
+			}
+			var failed int
+			event.Results, failed, err = a.executeSyncJobs(jobs, false, false)
+			if err == nil && !a.Out.JSON {
+				a.printSyncResults(event.Results, failed)
+			}
+		}
+		if err != nil {
+			event.Error = err.Error()
+		}
+		switch {
+		case a.Out.JSON && err != nil:
+			a.Out.Err("skills watch", event)
+		case a.Out.JSON:
+			a.Out.OK("skills watch", event)
+		case err != nil:
+			a.Out.Err(fmt.Sprintf("sync %s: %v", t.Name, err), nil)
+		}
+		if eventLog != nil {
+			if data, merr := json.Marshal(event); merr == nil {
+				if _, werr := eventLog.Write(append(data, '\n')); werr != nil {
+					a.Out.Warn(fmt.Sprintf("watch log: %v", werr), nil)
 				}
 			}
 		}
 	}
 }
 
-func skillsState(ctx context.Context, cfg config.Config) (string, error) {
-	if _, err := os.Stat(cfg.SkillsRoot); err != nil {
-		return "", err
+func changedPaths(t config.SyncTarget, batch []string) ([]string, bool, bool) {
+	var paths []string
+	full := false
+	for _, p := range batch {
+		rel, err := filepath.Rel(t.Src, p)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == "." {
+			full = true
+			continue
+		}
+		if excludedPath(t.Exclude, rel) {
+			continue
+		}
+		paths = append(paths, rel)
 	}
-	runner := buildRunner(cfg, false)
-	head, err := runner.Run(ctx, cfg.SkillsRoot, "git", "rev-parse", "HEAD")
-	if err != nil {
-		return "", err
+	if full {
+		return nil, true, true
 	}
-	status, err := runner.Run(ctx, cfg.SkillsRoot, "git", "status", "--porcelain")
-	if err != nil {
-		return "", err
+	return paths, false, len(paths) > 0
+}
+
+func excludedPath(exclude []string, rel string) bool {
+	for p := rel; p != "." && p != "/"; p = path.Dir(p) {
+		if match.Any(exclude, p) || match.Any(exclude, p+"/") {
+			return true
+		}
 	}
-	return strings.TrimSpace(head.Stdout) + "|" + strings.TrimSpace(status.Stdout), nil
+	return false
 }
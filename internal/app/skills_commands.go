@@ -13,14 +13,23 @@ func (a App) runSkills(ctx context.Context, args []string) int {
 
 Commands:
   clone [--remote url]
-  sync [--target name] [--mode copy|mirror|link]
-  watch [--target name] [--interval sec]
-  diff [--target name]
+  list [--target name]
+  lint [--target name]
+  sync [--target name] [--mode copy|mirror|link|link-dir] [--relative]
+       [--plan [--out plan.json]] [--apply plan.json] [--atomic|--keep-going]
+  rollback [--run id] [--list] [--force]
+  watch [--target name] [--poll] [--interval sec] [--debounce 300ms] [--log file]
+  promote <repo-pattern> [--target name] [--files glob] [--pick n]
+          [--branch name] [--commit [--message msg]] [--force]
+  diff [--target name] [--patch] [--stat] [--context n] [--max-size bytes]
   verify [--target name]
   status [--target name]
-  link [--target name]
+  link [--target name] [--dir] [--relative]
   pin --target name --ref <commit|tag>
-  clean [--target name]
+  lock [--file skills.lock]
+  install [--frozen] [--file skills.lock]
+  clean [--target name] [--keep-going]
+  prune [--force]
 
 Common flags:
   --only <glob> (repeatable)
@@ -32,10 +41,18 @@ Common flags:
 	switch args[0] {
 	case "clone":
 		return a.runSkillsClone(ctx, args[1:])
+	case "list":
+		return a.runSkillsList(ctx, args[1:])
+	case "lint":
+		return a.runSkillsLint(ctx, args[1:])
 	case "sync":
 		return a.runSkillsSync(ctx, args[1:])
+	case "rollback":
+		return a.runSkillsRollback(ctx, args[1:])
 	case "watch":
 		return a.runSkillsWatch(ctx, args[1:])
+	case "promote":
+		return a.runSkillsPromote(ctx, args[1:])
 	case "diff":
 		return a.runSkillsDiff(ctx, args[1:])
 	case "verify":
@@ -44,10 +61,16 @@ Common flags:
 		return a.runSkillsStatus(ctx, args[1:])
 	case "link":
 		return a.runSkillsLink(ctx, args[1:])
+	case "lock":
+		return a.runSkillsLock(ctx, args[1:])
+	case "install":
+		return a.runSkillsInstall(ctx, args[1:])
 	case "pin":
 		return a.runSkillsPin(ctx, args[1:])
 	case "clean":
 		return a.runSkillsClean(ctx, args[1:])
+	case "prune":
+		return a.runSkillsPrune(ctx, args[1:])
 	case "--help", "-h":
 		fs := flag.NewFlagSet("skills", flag.ContinueOnError)
 		fs.SetOutput(os.Stdout)
@@ -5,7 +5,9 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/TT-AIXion/github-kanri/internal/config"
 	"github.com/TT-AIXion/github-kanri/internal/fsutil"
 	"github.com/TT-AIXion/github-kanri/internal/repo"
 )
@@ -14,6 +16,10 @@ func (a App) runSkillsDiff(ctx context.Context, args []string) int {
 	fs := flag.NewFlagSet("skills diff", flag.ContinueOnError)
 	fs.SetOutput(os.Stdout)
 	target := fs.String("target", "", "target")
+	patch := fs.Bool("patch", false, "print unified diffs")
+	stat := fs.Bool("stat", false, "print line-count summary")
+	contextLines := fs.Int("context", fsutil.DefaultPatchContext, "context lines")
+	maxSize := fs.Int64("max-size", fsutil.DefaultPatchLimit, "max file size to diff in bytes")
 	var only multiFlag
 	var exclude multiFlag
 	fs.Var(&only, "only", "only patterns")
@@ -38,26 +44,19 @@ func (a App) runSkillsDiff(ctx context.Context, args []string) int {
 		return 1
 	}
 	repos = repo.Filter(repos, only, exclude)
-	var results []diffResult
-	for _, r := range repos {
-		for _, t := range targets {
-			for _, dest := range t.Dest {
-				destPath := fsutil.ResolvePath(r.Path, dest)
-				added, removed, changed, err := fsutil.DiffDir(t.Src, destPath, t.Include, t.Exclude)
-				if err != nil {
-					a.Out.Err(fmt.Sprintf("%s %s: %v", r.Name, t.Name, err), nil)
-					return 1
-				}
-				results = append(results, diffResult{Repo: r.Name, Target: t.Name, Dest: destPath, Added: added, Removed: removed, Changed: changed})
-			}
-		}
-	}
+	templates := newTemplateCache(ctx, cfg, buildRunner(cfg, false))
+	opts := patchOptions{enabled: *patch || *stat || a.Out.JSON, context: *contextLines, limit: *maxSize}
+	results, failed := diffTargets(cfg, repos, targets, templates, opts)
 	if a.Out.JSON {
 		a.Out.OK("skills diff", results)
-		return 0
+		return a.failureCode(failed, len(results), "targets")
 	}
 	for _, r := range results {
-		if len(r.Added)+len(r.Removed)+len(r.Changed) == 0 {
+		if r.Error != "" {
+			a.Out.Err(fmt.Sprintf("%s: %s", resultLabel(r.Repo, r.Target), r.Error), nil)
+			continue
+		}
+		if r.clean() {
 			a.Out.OK(fmt.Sprintf("%s %s clean", r.Repo, r.Target), nil)
 			continue
 		}
@@ -70,8 +69,85 @@ func (a App) runSkillsDiff(ctx context.Context, args []string) int {
 		if len(r.Changed) > 0 {
 			a.Out.Warn(fmt.Sprintf("%s %s changed=%d", r.Repo, r.Target, len(r.Changed)), nil)
 		}
+		if n := len(r.Dangling) + len(r.WrongTarget) + len(r.NotLink); n > 0 {
+			a.Out.Warn(fmt.Sprintf("%s %s links=%d", r.Repo, r.Target, n), nil)
+		}
+		printDrift(a, "local", r.LocalModified)
+		printDrift(a, "upstream", r.UpstreamChanged)
+		printDrift(a, string(fsutil.LinkDangling), r.Dangling)
+		printDrift(a, string(fsutil.LinkWrongTarget), r.WrongTarget)
+		printDrift(a, string(fsutil.LinkNotLink), r.NotLink)
+		if *stat {
+			printStat(a, r.Files)
+		}
+		if *patch {
+			printPatch(a, r.Files)
+		}
 	}
-	return 0
+	return a.failureCode(failed, len(results), "targets")
+}
+
+func printDrift(a App, kind string, files []string) {
+	for _, f := range files {
+		a.Out.Raw(fmt.Sprintf("  %-8s %s", kind, f))
+	}
+}
+
+func diffTargets(cfg config.Config, repos []repo.Repo, targets []config.SyncTarget, templates *templateCache, patch patchOptions) ([]diffResult, int) {
+	var results []diffResult
+	failed := 0
+	for _, r := range repos {
+		repoTargets, err := targetsForRepo(cfg, targets, r)
+		if err != nil {
+			failed++
+			results = append(results, diffResult{Repo: r.Name, Error: err.Error()})
+			continue
+		}
+		for _, t := range repoTargets {
+			for _, dest := range t.Dest {
+				tmpl := templates.forTarget(r, t)
+				result, err := diffTarget(cfg, r, t, fsutil.ResolvePath(r.Path, dest), tmpl)
+				if err == nil && patch.enabled {
+					result.Files, err = fsutil.PatchFiles(t.Src, result.Dest, result.Added, result.Removed, result.Changed, tmpl, patch.context, patch.limit)
+				}
+				if err != nil {
+					failed++
+					result.Error = err.Error()
+				}
+				results = append(results, result)
+			}
+		}
+	}
+	return results, failed
+}
+
+func diffTarget(cfg config.Config, r repo.Repo, t config.SyncTarget, destPath string, tmpl *fsutil.Template) (diffResult, error) {
+	result := diffResult{Repo: r.Name, Target: t.Name, Dest: destPath}
+	d, err := diffFiles(cfg, t, destPath, tmpl)
+	if err != nil {
+		return result, err
+	}
+	result.Added, result.Removed, result.Changed = d.Added, d.Removed, d.Changed
+	result.Dangling, result.WrongTarget, result.NotLink = d.Dangling, d.WrongTarget, d.NotLink
+	m, ok, err := fsutil.ReadManifest(destPath)
+	if err != nil || !ok {
+		return result, err
+	}
+	result.Managed = true
+	result.Revision = m.Commit
+	syncedAt := m.SyncedAt
+	result.SyncedAt = &syncedAt
+	result.LocalModified, result.UpstreamChanged, err = fsutil.ClassifyChanges(t.Src, destPath, d.Changed, m, tmpl)
+	return result, err
+}
+
+func diffFiles(cfg config.Config, t config.SyncTarget, destPath string, tmpl *fsutil.Template) (fsutil.DirDiff, error) {
+	mode := fsutil.SyncMode(targetMode(cfg, t, ""))
+	if mode == fsutil.ModeLink || mode == fsutil.ModeLinkDir {
+		return fsutil.DiffLinks(t.Src, destPath, t.Include, t.Exclude, mode, tmpl)
+	}
+	added, removed, changed, err := fsutil.DiffDirTemplate(t.Src, destPath, t.Include, t.Exclude, tmpl)
+	return fsutil.DirDiff{Added: added, Removed: removed, Changed: changed}, err
 }
 
 func (a App) runSkillsVerify(ctx context.Context, args []string) int {
@@ -102,99 +178,73 @@ func (a App) runSkillsVerify(ctx context.Context, args []string) int {
 		return 1
 	}
 	repos = repo.Filter(repos, only, exclude)
+	templates := newTemplateCache(ctx, cfg, buildRunner(cfg, false))
 	var results []verifyResult
 	ok := true
+	failed := 0
 	for _, r := range repos {
-		for _, t := range targets {
+		repoTargets, err := targetsForRepo(cfg, targets, r)
+		if err != nil {
+			failed++
+			results = append(results, verifyResult{Repo: r.Name, Error: err.Error()})
+			continue
+		}
+		for _, t := range repoTargets {
 			for _, dest := range t.Dest {
 				destPath := fsutil.ResolvePath(r.Path, dest)
-				added, removed, changed, err := fsutil.DiffDir(t.Src, destPath, t.Include, t.Exclude)
+				result := verifyResult{Repo: r.Name, Target: t.Name, Dest: destPath}
+				d, err := diffFiles(cfg, t, destPath, templates.forTarget(r, t))
 				if err != nil {
-					a.Out.Err(fmt.Sprintf("%s %s: %v", r.Name, t.Name, err), nil)
-					return 1
+					failed++
+					result.Error = err.Error()
+					results = append(results, result)
+					continue
 				}
-				match := len(added)+len(removed)+len(changed) == 0
-				if !match {
+				result.Match = d.Clean()
+				result.Dangling, result.WrongTarget, result.NotLink = d.Dangling, d.WrongTarget, d.NotLink
+				if !result.Match {
 					ok = false
 				}
-				results = append(results, verifyResult{Repo: r.Name, Target: t.Name, Dest: destPath, Match: match})
+				results = append(results, result)
 			}
 		}
 	}
 	if a.Out.JSON {
 		a.Out.OK("skills verify", results)
-		if ok {
-			return 0
-		}
-		return 2
+		return verifyCode(a.failureCode(failed, len(results), "targets"), ok)
 	}
 	for _, r := range results {
+		if r.Error != "" {
+			a.Out.Err(fmt.Sprintf("%s: %s", resultLabel(r.Repo, r.Target), r.Error), nil)
+			continue
+		}
 		if r.Match {
 			a.Out.OK(fmt.Sprintf("%s %s ok", r.Repo, r.Target), nil)
 			continue
 		}
-		a.Out.Err(fmt.Sprintf("%s %s mismatch", r.Repo, r.Target), nil)
+		a.Out.Err(fmt.Sprintf("%s %s mismatch%s", r.Repo, r.Target, linkSummary(r.Dangling, r.WrongTarget, r.NotLink)), nil)
 	}
-	if ok {
-		return 0
-	}
-	return 2
+	return verifyCode(a.failureCode(failed, len(results), "targets"), ok)
 }
 
-func (a App) runSkillsStatus(ctx context.Context, args []string) int {
-	fs := flag.NewFlagSet("skills status", flag.ContinueOnError)
-	fs.SetOutput(os.Stdout)
-	target := fs.String("target", "", "target")
-	var only multiFlag
-	var exclude multiFlag
-	fs.Var(&only, "only", "only patterns")
-	fs.Var(&exclude, "exclude", "exclude patterns")
-	if err := fs.Parse(args); err != nil {
-		a.Out.Err(err.Error(), nil)
-		return 1
-	}
-	cfg, _, err := loadConfig()
-	if err != nil {
-		a.Out.Err(err.Error(), nil)
-		return 1
-	}
-	targets, err := selectTargets(cfg, *target)
-	if err != nil {
-		a.Out.Err(err.Error(), nil)
-		return 1
-	}
-	repos, err := repo.Scan(cfg.ReposRoot)
-	if err != nil {
-		a.Out.Err(err.Error(), nil)
-		return 1
-	}
-	repos = repo.Filter(repos, only, exclude)
-	var results []diffResult
-	for _, r := range repos {
-		for _, t := range targets {
-			for _, dest := range t.Dest {
-				destPath := fsutil.ResolvePath(r.Path, dest)
-				added, removed, changed, err := fsutil.DiffDir(t.Src, destPath, t.Include, t.Exclude)
-				if err != nil {
-					a.Out.Err(fmt.Sprintf("%s %s: %v", r.Name, t.Name, err), nil)
-					return 1
-				}
-				results = append(results, diffResult{Repo: r.Name, Target: t.Name, Dest: destPath, Added: added, Removed: removed, Changed: changed})
-			}
-		}
-	}
-	if a.Out.JSON {
-		a.Out.OK("skills status", results)
-		return 0
+func verifyCode(failureCode int, match bool) int {
+	if failureCode == 0 && !match {
+		return 2
 	}
-	for _, r := range results {
-		if len(r.Added)+len(r.Removed)+len(r.Changed) == 0 {
-			a.Out.OK(fmt.Sprintf("%s %s clean", r.Repo, r.Target), nil)
-			continue
+	return failureCode
+}
+
+func linkSummary(dangling, wrongTarget, notLink []string) string {
+	var b strings.Builder
+	for _, c := range []struct {
+		kind  fsutil.LinkIssue
+		files []string
+	}{{fsutil.LinkDangling, dangling}, {fsutil.LinkWrongTarget, wrongTarget}, {fsutil.LinkNotLink, notLink}} {
+		if len(c.files) > 0 {
+			fmt.Fprintf(&b, " %s=%d", c.kind, len(c.files))
 		}
-		a.Out.Warn(fmt.Sprintf("%s %s drift", r.Repo, r.Target), nil)
 	}
-	return 0
+	return b.String()
 }
 
 func (a App) runSkillsClean(ctx context.Context, args []string) int {
@@ -203,6 +253,7 @@ func (a App) runSkillsClean(ctx context.Context, args []string) int {
 	target := fs.String("target", "", "target")
 	force := fs.Bool("force", false, "force")
 	dryRun := fs.Bool("dry-run", false, "dry run")
+	keepGoing := fs.Bool("keep-going", false, "continue past per-repo errors")
 	var only multiFlag
 	var exclude multiFlag
 	fs.Var(&only, "only", "only patterns")
@@ -216,42 +267,78 @@ func (a App) runSkillsClean(ctx context.Context, args []string) int {
 		a.Out.Err(err.Error(), nil)
 		return 1
 	}
-	if !*force && cfg.ConflictPolicy != string(fsutil.ConflictOverwrite) {
-		a.Out.Err("clean requires --force or conflictPolicy=overwrite", nil)
-		return 1
-	}
 	targets, err := selectTargets(cfg, *target)
 	if err != nil {
 		a.Out.Err(err.Error(), nil)
 		return 1
 	}
+	for _, t := range targets {
+		if targetPolicy(cfg, t, *force) != string(fsutil.ConflictOverwrite) {
+			a.Out.Err("clean requires --force or conflictPolicy=overwrite", nil)
+			return 1
+		}
+	}
 	repos, err := repo.Scan(cfg.ReposRoot)
 	if err != nil {
 		a.Out.Err(err.Error(), nil)
 		return 1
 	}
 	repos = repo.Filter(repos, only, exclude)
+	templates := newTemplateCache(ctx, cfg, buildRunner(cfg, false))
 	guard := guardFromConfig(cfg)
+	var results []cleanResult
+	failed := 0
 	for _, r := range repos {
-		for _, t := range targets {
-			files, err := fsutil.ListFiles(t.Src, t.Include, t.Exclude)
-			if err != nil {
-				a.Out.Err(fmt.Sprintf("%s %s: %v", r.Name, t.Name, err), nil)
-				return 1
+		repoTargets, err := targetsForRepo(cfg, targets, r)
+		if err != nil {
+			failed++
+			results = append(results, cleanResult{Repo: r.Name, DryRun: *dryRun, Error: err.Error()})
+			if !*keepGoing {
+				return a.printClean(results, failed)
+			}
+			continue
+		}
+		for _, t := range repoTargets {
+			files, listErr := fsutil.ListFiles(t.Src, t.Include, t.Exclude)
+			keep := fsutil.DestNames(files, templates.forTarget(r, t))
+			if targetMode(cfg, t, "") == string(fsutil.ModeLinkDir) {
+				keep = fsutil.LinkEntries(files)
 			}
 			for _, dest := range t.Dest {
-				destPath := fsutil.ResolvePath(r.Path, dest)
-				if err := guard.CheckPath(destPath); err != nil {
-					a.Out.Err(err.Error(), nil)
-					return 1
+				result := cleanResult{Repo: r.Name, Target: t.Name, Dest: fsutil.ResolvePath(r.Path, dest), DryRun: *dryRun}
+				err := listErr
+				if err == nil {
+					err = guard.CheckPath(result.Dest)
 				}
-				if err := fsutil.CleanDir(destPath, files, *dryRun); err != nil {
-					a.Out.Err(fmt.Sprintf("%s %s: %v", r.Name, t.Name, err), nil)
-					return 1
+				if err == nil {
+					err = fsutil.CleanDir(result.Dest, keep, *dryRun)
+				}
+				if err != nil {
+					failed++
+					result.Error = err.Error()
+				}
+				results = append(results, result)
+				if err != nil && !*keepGoing {
+					return a.printClean(results, failed)
 				}
 			}
 		}
 	}
-	a.Out.OK("skills clean done", nil)
-	return 0
+	return a.printClean(results, failed)
+}
+
+func (a App) printClean(results []cleanResult, failed int) int {
+	if a.Out.JSON {
+		a.Out.OK("skills clean", results)
+		return a.failureCode(failed, len(results), "targets")
+	}
+	for _, r := range results {
+		if r.Error != "" {
+			a.Out.Err(fmt.Sprintf("%s: %s", resultLabel(r.Repo, r.Target), r.Error), nil)
+		}
+	}
+	if failed == 0 {
+		a.Out.OK("skills clean done", nil)
+	}
+	return a.failureCode(failed, len(results), "targets")
 }
@@ -0,0 +1,292 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/TT-AIXion/github-kanri/internal/config"
+	"github.com/TT-AIXion/github-kanri/internal/executil"
+	"github.com/TT-AIXion/github-kanri/internal/fsutil"
+	"github.com/TT-AIXion/github-kanri/internal/gitutil"
+)
+
+const (
+	skillsLockName    = "skills.lock"
+	skillsLockVersion = 1
+	rootSourceName    = "skillsRoot"
+)
+
+type skillsLock struct {
+	Version int            `json:"version"`
+	Sources []lockedSource `json:"sources"`
+}
+
+type lockedSource struct {
+	Name    string   `json:"name"`
+	Remote  string   `json:"remote,omitempty"`
+	Ref     string   `json:"ref,omitempty"`
+	Commit  string   `json:"commit"`
+	Digest  string   `json:"digest"`
+	Targets []string `json:"targets,omitempty"`
+}
+
+func lockSources(cfg config.Config) []skillSource {
+	var out []skillSource
+	var rootTargets []string
+	for _, t := range cfg.SyncTargets {
+		if strings.TrimSpace(t.Remote) == "" {
+			rootTargets = append(rootTargets, t.Name)
+		}
+	}
+	if len(rootTargets) > 0 {
+		out = append(out, skillSource{Name: rootSourceName, Remote: cfg.SkillsRemote, Dir: cfg.SkillsRoot, Targets: rootTargets})
+	}
+	return append(out, remoteSources(cfg)...)
+}
+
+func lockFilePath(cfgPath, override string) string {
+	if strings.TrimSpace(override) != "" {
+		return override
+	}
+	return filepath.Join(filepath.Dir(cfgPath), skillsLockName)
+}
+
+func sourceContentDigest(ctx context.Context, runner executil.Runner, dir string) (string, error) {
+	_, ignored, err := gitutil.UntrackedPaths(ctx, runner, dir)
+	if err != nil {
+		return "", err
+	}
+	exclude := []string{".git", ".git/**"}
+	for _, p := range ignored {
+		exclude = append(exclude, strings.TrimSuffix(p, "/"))
+	}
+	return fsutil.SourceDigest(dir, nil, exclude, nil)
+}
+
+func lockSource(ctx context.Context, runner executil.Runner, s skillSource) (lockedSource, error) {
+	entry := lockedSource{Name: s.Name, Remote: s.Remote, Ref: s.Ref, Targets: s.Targets}
+	if !fsutil.IsGitRepo(s.Dir) {
+		return entry, errors.New("not cloned (run gkn skills clone)")
+	}
+	clean, err := gitutil.IsClean(ctx, runner, s.Dir)
+	if err != nil {
+		return entry, err
+	}
+	if !clean {
+		return entry, errors.New("has local changes")
+	}
+	if entry.Commit, err = gitutil.HeadCommit(ctx, runner, s.Dir); err != nil {
+		return entry, err
+	}
+	if entry.Remote == "" {
+		entry.Remote, _ = gitutil.OriginURL(ctx, runner, s.Dir)
+	}
+	entry.Digest, err = sourceContentDigest(ctx, runner, s.Dir)
+	return entry, err
+}
+
+func readSkillsLock(path string) (skillsLock, error) {
+	var lock skillsLock
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return lock, err
+	}
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return lock, fmt.Errorf("invalid lock file %s: %w", path, err)
+	}
+	if lock.Version != skillsLockVersion {
+		return lock, fmt.Errorf("unsupported lock version: %d", lock.Version)
+	}
+	return lock, nil
+}
+
+func (a App) writeSkillsLock(ctx context.Context, cfg config.Config, path string) int {
+	runner := buildRunner(cfg, false)
+	lock := skillsLock{Version: skillsLockVersion, Sources: []lockedSource{}}
+	sources := lockSources(cfg)
+	failed := 0
+	for _, s := range sources {
+		entry, err := lockSource(ctx, runner, s)
+		if err != nil {
+			failed++
+			a.Out.Err(fmt.Sprintf("source %s: %v", s.Name, err), nil)
+			continue
+		}
+		lock.Sources = append(lock.Sources, entry)
+	}
+	if failed > 0 {
+		a.Out.Err(fmt.Sprintf("%s not updated", path), nil)
+		return 1
+	}
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	if a.Out.JSON {
+		a.Out.OK("skills lock", lock)
+		return 0
+	}
+	for _, s := range lock.Sources {
+		a.Out.OK(fmt.Sprintf("locked %s %s", s.Name, shortHash(s.Commit)), nil)
+	}
+	a.Out.OK(fmt.Sprintf("wrote %s", path), nil)
+	return 0
+}
+
+func (a App) runSkillsLock(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("skills lock", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+	file := fs.String("file", "", "lock file path")
+	if err := fs.Parse(args); err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	cfg, path, err := loadConfig()
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	return a.writeSkillsLock(ctx, cfg, lockFilePath(path, *file))
+}
+
+func (a App) runSkillsInstall(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("skills install", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+	file := fs.String("file", "", "lock file path")
+	frozen := fs.Bool("frozen", false, "check out exactly the locked commits")
+	if err := fs.Parse(args); err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	cfg, path, err := loadConfig()
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	lockPath := lockFilePath(path, *file)
+	if *frozen {
+		return a.installFrozen(ctx, cfg, lockPath)
+	}
+	sources := lockSources(cfg)
+	failed := 0
+	if len(sources) > 0 && sources[0].Name == rootSourceName {
+		if url := strings.TrimSpace(cfg.SkillsRemote); url != "" {
+			msg, err := a.cloneSkillsRoot(ctx, cfg, url, false)
+			if err != nil {
+				failed++
+				a.Out.Err(err.Error(), nil)
+			} else {
+				a.Out.OK(msg, nil)
+			}
+		}
+		sources = sources[1:]
+	}
+	if failed += a.updateSources(ctx, cfg, sources); failed > 0 {
+		return 1
+	}
+	return a.writeSkillsLock(ctx, cfg, lockPath)
+}
+
+func (a App) installFrozen(ctx context.Context, cfg config.Config, lockPath string) int {
+	lock, err := readSkillsLock(lockPath)
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	locked := map[string]lockedSource{}
+	for _, s := range lock.Sources {
+		locked[s.Name] = s
+	}
+	sources := lockSources(cfg)
+	configured := map[string]bool{}
+	for _, s := range sources {
+		configured[s.Name] = true
+		entry, ok := locked[s.Name]
+		switch {
+		case !ok:
+			a.Out.Err(fmt.Sprintf("source %s is not locked (run gkn skills lock)", s.Name), nil)
+			return 1
+		case s.Remote != "" && entry.Remote != s.Remote:
+			a.Out.Err(fmt.Sprintf("source %s: remote changed since locking", s.Name), nil)
+			return 1
+		}
+	}
+	for _, s := range lock.Sources {
+		if !configured[s.Name] {
+			a.Out.Err(fmt.Sprintf("lock has unknown source %s", s.Name), nil)
+			return 1
+		}
+	}
+	runner := buildRunner(cfg, false)
+	guard := guardFromConfig(cfg)
+	failed := 0
+	for _, s := range sources {
+		entry := locked[s.Name]
+		err := guard.CheckPath(s.Dir)
+		if err == nil {
+			err = installLocked(ctx, runner, s.Dir, entry)
+		}
+		if err != nil {
+			failed++
+			a.Out.Err(fmt.Sprintf("source %s: %v", s.Name, err), entry)
+			continue
+		}
+		a.Out.OK(fmt.Sprintf("installed %s %s", s.Name, shortHash(entry.Commit)), entry)
+	}
+	return a.failureCode(failed, len(sources), "sources")
+}
+
+func installLocked(ctx context.Context, runner executil.Runner, dir string, entry lockedSource) error {
+	if !fsutil.IsGitRepo(dir) {
+		if entry.Remote == "" {
+			return errors.New("not cloned and the lock has no remote")
+		}
+		if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+			return err
+		}
+		if err := gitutil.Clone(ctx, runner, entry.Remote, dir); err != nil {
+			return err
+		}
+	}
+	clean, err := gitutil.IsClean(ctx, runner, dir)
+	if err != nil {
+		return err
+	}
+	if !clean {
+		return errors.New("has local changes")
+	}
+	if _, err := gitutil.ResolveCommit(ctx, runner, dir, entry.Commit); err != nil {
+		if err := gitutil.Fetch(ctx, runner, dir); err != nil {
+			return err
+		}
+	}
+	if err := gitutil.Checkout(ctx, runner, dir, entry.Commit); err != nil {
+		return err
+	}
+	head, err := gitutil.HeadCommit(ctx, runner, dir)
+	if err != nil {
+		return err
+	}
+	if head != entry.Commit {
+		return fmt.Errorf("checked out %s, locked %s", shortHash(head), shortHash(entry.Commit))
+	}
+	digest, err := sourceContentDigest(ctx, runner, dir)
+	if err != nil {
+		return err
+	}
+	if digest != entry.Digest {
+		return fmt.Errorf("content hash mismatch at %s", shortHash(entry.Commit))
+	}
+	return nil
+}
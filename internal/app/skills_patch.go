@@ -0,0 +1,65 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/TT-AIXion/github-kanri/internal/fsutil"
+)
+
+type patchOptions struct {
+	enabled bool
+	context int
+	limit   int64
+}
+
+func printStat(a App, files []fsutil.FilePatch) {
+	if len(files) == 0 {
+		return
+	}
+	width := 0
+	for _, f := range files {
+		width = max(width, len(f.Path))
+	}
+	added, deleted := 0, 0
+	for _, f := range files {
+		detail := fmt.Sprintf("+%d -%d", f.Added, f.Deleted)
+		switch {
+		case f.TooLarge:
+			detail = "too large"
+		case f.Binary:
+			detail = "Bin"
+		}
+		a.Out.Raw(fmt.Sprintf("  %-*s | %s", width, f.Path, detail))
+		added += f.Added
+		deleted += f.Deleted
+	}
+	a.Out.Raw(fmt.Sprintf("  %d files changed, %d insertions(+), %d deletions(-)", len(files), added, deleted))
+}
+
+func printPatch(a App, files []fsutil.FilePatch) {
+	for _, f := range files {
+		oldName, newName := "a/"+f.Path, "b/"+f.Path
+		if f.Status == fsutil.PatchAdded {
+			oldName = "/dev/null"
+		}
+		if f.Status == fsutil.PatchRemoved {
+			newName = "/dev/null"
+		}
+		switch {
+		case f.TooLarge:
+			a.Out.Raw(fmt.Sprintf("File %s too large to diff (%s -> %s)", f.Path, shortHash(f.OldHash), shortHash(f.NewHash)))
+			continue
+		case f.Binary:
+			a.Out.Raw(fmt.Sprintf("Binary files %s and %s differ", oldName, newName))
+			continue
+		}
+		a.Out.Raw("--- " + oldName)
+		a.Out.Raw("+++ " + newName)
+		for _, h := range f.Hunks {
+			a.Out.Raw(h.Header())
+			for _, l := range h.Lines {
+				a.Out.Raw(l)
+			}
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/TT-AIXion/github-kanri/internal/config"
+	"github.com/TT-AIXion/github-kanri/internal/fsutil"
+)
+
+const syncPlanVersion = 1
+
+type syncPlan struct {
+	Version   int         `json:"version"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Mode      string      `json:"mode,omitempty"`
+	Force     bool        `json:"force,omitempty"`
+	Relative  bool        `json:"relative,omitempty"`
+	Entries   []planEntry `json:"entries"`
+}
+
+type planEntry struct {
+	Repo           string       `json:"repo"`
+	Target         string       `json:"target"`
+	Src            string       `json:"src"`
+	Dest           string       `json:"dest"`
+	Mode           string       `json:"mode"`
+	ConflictPolicy string       `json:"conflictPolicy"`
+	SourceCommit   string       `json:"sourceCommit,omitempty"`
+	SourceDigest   string       `json:"sourceDigest"`
+	DestDigest     string       `json:"destDigest"`
+	Actions        []planAction `json:"actions"`
+	Error          string       `json:"error,omitempty"`
+}
+
+type planAction struct {
+	Path   string `json:"path"`
+	Action string `json:"action"`
+}
+
+func planVerb(job syncJob, c fsutil.FileChange) string {
+	link := (job.opts.Mode == fsutil.ModeLink || job.opts.Mode == fsutil.ModeLinkDir) && !job.opts.Template.Renders(job.target.Src, c.Path)
+	switch c.Action {
+	case fsutil.ActionCreated:
+		if link {
+			return "link"
+		}
+		return "create"
+	case fsutil.ActionUpdated:
+		if link {
+			return "link"
+		}
+		return "update"
+	case fsutil.ActionRemoved:
+		return "delete"
+	case fsutil.ActionMerged:
+		return "merge"
+	case fsutil.ActionKept:
+		return "keep"
+	default:
+		return string(c.Action)
+	}
+}
+
+func planJob(job syncJob) planEntry {
+	entry := planEntry{
+		Repo:           job.repo.Name,
+		Target:         job.target.Name,
+		Src:            job.target.Src,
+		Dest:           job.dest,
+		Mode:           string(job.opts.Mode),
+		ConflictPolicy: string(job.opts.ConflictPolicy),
+		SourceCommit:   job.opts.Commit,
+		Actions:        []planAction{},
+	}
+	var err error
+	if entry.SourceDigest, err = fsutil.SourceDigest(job.target.Src, job.opts.Include, job.opts.Exclude, job.opts.Template); err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+	if entry.DestDigest, err = fsutil.DestDigest(job.dest); err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+	opts := job.opts
+	opts.DryRun = true
+	report, err := fsutil.SyncDir(job.target.Src, job.dest, opts)
+	if err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+	for _, c := range report.Modified() {
+		entry.Actions = append(entry.Actions, planAction{Path: c.Path, Action: planVerb(job, c)})
+	}
+	return entry
+}
+
+func (a App) planSync(ctx context.Context, cfg config.Config, targets []config.SyncTarget, mode string, force bool, only []string, exclude []string, out string) int {
+	jobs, err := syncJobs(ctx, cfg, targets, mode, force, true, only, exclude)
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	plan := syncPlan{Version: syncPlanVersion, CreatedAt: time.Now().UTC(), Mode: mode, Force: force, Relative: cfg.RelativeLinks, Entries: []planEntry{}}
+	hadError := false
+	for _, job := range jobs {
+		entry := planJob(job)
+		if entry.Error != "" {
+			hadError = true
+		}
+		plan.Entries = append(plan.Entries, entry)
+	}
+	if out != "" {
+		data, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			a.Out.Err(err.Error(), nil)
+			return 1
+		}
+		if err := os.WriteFile(out, append(data, '\n'), 0o644); err != nil {
+			a.Out.Err(err.Error(), nil)
+			return 1
+		}
+	}
+	if a.Out.JSON {
+		a.Out.OK("skills sync plan", plan)
+	} else {
+		total := 0
+		for _, e := range plan.Entries {
+			if e.Error != "" {
+				a.Out.Err(fmt.Sprintf("%s %s %s: %s", e.Repo, e.Target, e.Dest, e.Error), nil)
+				continue
+			}
+			total += len(e.Actions)
+			if len(e.Actions) == 0 {
+				a.Out.OK(fmt.Sprintf("%s %s %s up to date", e.Repo, e.Target, e.Dest), nil)
+				continue
+			}
+			a.Out.Warn(fmt.Sprintf("%s %s %s actions=%d", e.Repo, e.Target, e.Dest, len(e.Actions)), nil)
+			for _, act := range e.Actions {
+				a.Out.Raw(fmt.Sprintf("  %-8s %s", act.Action, act.Path))
+			}
+		}
+		summary := fmt.Sprintf("plan: %d actions in %d destinations", total, len(plan.Entries))
+		if out != "" {
+			summary += fmt.Sprintf(" (saved to %s)", out)
+		}
+		a.Out.OK(summary, nil)
+	}
+	if hadError {
+		return 1
+	}
+	return 0
+}
+
+func readSyncPlan(path string) (syncPlan, error) {
+	var plan syncPlan
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return plan, err
+	}
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return plan, fmt.Errorf("invalid plan: %w", err)
+	}
+	if plan.Version != syncPlanVersion {
+		return plan, fmt.Errorf("unsupported plan version: %d", plan.Version)
+	}
+	return plan, nil
+}
+
+func (a App) applySyncPlan(ctx context.Context, cfg config.Config, path string, atomic bool, keepGoing bool) int {
+	plan, err := readSyncPlan(path)
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	cfg.RelativeLinks = cfg.RelativeLinks || plan.Relative
+	var targets []config.SyncTarget
+	seen := map[string]bool{}
+	for _, e := range plan.Entries {
+		if e.Error != "" {
+			a.Out.Err(fmt.Sprintf("plan has errors: %s %s: %s", e.Repo, e.Target, e.Error), nil)
+			return 1
+		}
+		if seen[e.Target] {
+			continue
+		}
+		seen[e.Target] = true
+		selected, err := selectTargets(cfg, e.Target)
+		if err != nil {
+			a.Out.Err(err.Error(), nil)
+			return 1
+		}
+		targets = append(targets, selected...)
+	}
+	jobs, err := syncJobs(ctx, cfg, targets, plan.Mode, plan.Force, false, nil, nil)
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	byKey := map[string]syncJob{}
+	for _, job := range jobs {
+		byKey[job.repo.Name+"\x00"+job.target.Name+"\x00"+job.dest] = job
+	}
+	var apply []syncJob
+	for _, e := range plan.Entries {
+		job, ok := byKey[e.Repo+"\x00"+e.Target+"\x00"+e.Dest]
+		if !ok {
+			a.Out.Err(fmt.Sprintf("plan is stale: %s %s %s no longer matches the config", e.Repo, e.Target, e.Dest), nil)
+			return 1
+		}
+		if reason := staleReason(e, planJob(job)); reason != "" {
+			a.Out.Err(fmt.Sprintf("plan is stale: %s %s %s: %s", e.Repo, e.Target, e.Dest, reason), nil)
+			return 1
+		}
+		apply = append(apply, job)
+	}
+	return a.runSyncJobs(apply, atomic, keepGoing)
+}
+
+func staleReason(planned, current planEntry) string {
+	switch {
+	case current.Error != "":
+		return current.Error
+	case planned.Src != current.Src || planned.Mode != current.Mode || planned.ConflictPolicy != current.ConflictPolicy:
+		return "target settings changed"
+	case planned.SourceDigest != current.SourceDigest:
+		return "source changed"
+	case planned.DestDigest != current.DestDigest:
+		return "destination changed"
+	case len(planned.Actions) != len(current.Actions):
+		return "actions changed"
+	}
+	for i := range planned.Actions {
+		if planned.Actions[i] != current.Actions[i] {
+			return "actions changed"
+		}
+	}
+	return ""
+}
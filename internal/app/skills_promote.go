@@ -0,0 +1,366 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/TT-AIXion/github-kanri/internal/config"
+	"github.com/TT-AIXion/github-kanri/internal/executil"
+	"github.com/TT-AIXion/github-kanri/internal/fsutil"
+	"github.com/TT-AIXion/github-kanri/internal/gitutil"
+	"github.com/TT-AIXion/github-kanri/internal/match"
+	"github.com/TT-AIXion/github-kanri/internal/repo"
+)
+
+type promoteJob struct {
+	target config.SyncTarget
+	dest   string
+	files  []string
+}
+
+func (a App) runSkillsPromote(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("skills promote", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+	target := fs.String("target", "", "target")
+	pick := fs.Int("pick", 0, "pick index")
+	branch := fs.String("branch", "", "create branch in the source")
+	commit := fs.Bool("commit", false, "commit promoted files in the source")
+	message := fs.String("message", "", "commit message")
+	dryRun := fs.Bool("dry-run", false, "dry run")
+	force := fs.Bool("force", false, "promote files that also changed upstream")
+	var files multiFlag
+	fs.Var(&files, "files", "files to promote (repeatable, globs)")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	if len(positional) == 0 {
+		a.Out.Err("pattern required", nil)
+		return 1
+	}
+	cfg, _, err := loadConfig()
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	targets, err := selectTargets(cfg, *target)
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	repos, err := repo.Scan(cfg.ReposRoot)
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	result := repo.Find(repos, positional[0])
+	selected, err := repo.Pick(result, *pick)
+	if err != nil {
+		if errors.Is(err, repo.ErrMultipleMatches) {
+			return a.handleMultiMatch(result)
+		}
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	repoTargets, err := targetsForRepo(cfg, targets, selected)
+	if err != nil {
+		a.Out.Err(fmt.Sprintf("%s: %v", selected.Name, err), nil)
+		return 1
+	}
+	summary := promoteSummary{Repo: selected.Name, DryRun: *dryRun}
+	jobs, blocked := planPromote(ctx, cfg, selected, repoTargets, files, *force, &summary)
+	if blocked {
+		return a.failPromote(summary, "nothing was promoted")
+	}
+	if len(jobs) == 0 {
+		if a.Out.JSON {
+			a.Out.OK("skills promote", summary)
+			return 0
+		}
+		a.printPromote(summary, true)
+		a.Out.OK("nothing to promote", nil)
+		return 0
+	}
+	groups := promoteGroups(cfg, jobs)
+	if (*branch != "" || *commit) && !*dryRun {
+		for _, g := range groups {
+			if !fsutil.IsGitRepo(g.Dir) {
+				return a.failPromote(summary, fmt.Sprintf("source is not a git repository: %s (nothing was promoted)", g.Dir))
+			}
+		}
+	}
+	runner := buildRunner(cfg, false)
+	if *branch != "" && !*dryRun {
+		for _, g := range groups {
+			if _, err := gitutil.ResolveCommit(ctx, runner, g.Dir, "refs/heads/"+*branch); err == nil {
+				return a.failPromote(summary, fmt.Sprintf("%s: branch %s already exists (nothing was promoted)", g.Dir, *branch))
+			}
+		}
+	}
+	var journal *fsutil.Journal
+	if !*dryRun {
+		root, err := journalRoot()
+		if err == nil {
+			journal, err = fsutil.NewJournal(root)
+		}
+		if err != nil {
+			a.Out.Err(fmt.Sprintf("journal: %v", err), nil)
+			return 1
+		}
+		summary.Run = journal.ID()
+	}
+	for _, job := range jobs {
+		opts := fsutil.SyncOptions{DryRun: *dryRun, Journal: journal}
+		if err := fsutil.PromoteFiles(job.target.Src, job.dest, job.files, opts); err != nil {
+			a.Out.Err(fmt.Sprintf("%s %s: %v", selected.Name, job.target.Name, err), nil)
+			a.abortJournal(journal, true)
+			return 1
+		}
+	}
+	var created []promoteBranch
+	if *branch != "" && !*dryRun {
+		for _, g := range groups {
+			prev, err := originalBranch(ctx, runner, g.Dir)
+			if err == nil {
+				err = gitutil.CreateBranch(ctx, runner, g.Dir, *branch)
+			}
+			if err != nil {
+				a.abortJournal(journal, true)
+				a.restoreBranches(ctx, runner, created, *branch)
+				return a.failPromote(summary, fmt.Sprintf("%s: %v (nothing was promoted)", g.Dir, err))
+			}
+			created = append(created, promoteBranch{Dir: g.Dir, Prev: prev})
+		}
+	}
+	a.commitJournal(journal)
+	if *commit && !*dryRun {
+		msg := *message
+		if msg == "" {
+			msg = fmt.Sprintf("Promote skill changes from %s", selected.Name)
+		}
+		for i := range groups {
+			g := &groups[i]
+			g.Branch = *branch
+			if err := gitutil.CommitPaths(ctx, runner, g.Dir, msg, g.Files); err != nil {
+				if len(created) > 0 {
+					a.restoreBranches(ctx, runner, created[i:], *branch)
+				}
+				return a.failPromote(summary, fmt.Sprintf("%s: commit failed: %v", g.Dir, err))
+			}
+			if g.Branch == "" {
+				g.Branch, _ = gitutil.CurrentBranch(ctx, runner, g.Dir)
+			}
+			g.Commit, _ = gitutil.HeadCommit(ctx, runner, g.Dir)
+			summary.Commits = append(summary.Commits, *g)
+		}
+	}
+	if a.Out.JSON {
+		a.Out.OK("skills promote", summary)
+		return 0
+	}
+	a.printPromote(summary, true)
+	for _, c := range summary.Commits {
+		a.Out.OK(fmt.Sprintf("committed %s on %s in %s", shortHash(c.Commit), c.Branch, c.Dir), nil)
+	}
+	return 0
+}
+
+func planPromote(ctx context.Context, cfg config.Config, r repo.Repo, targets []config.SyncTarget, files []string, force bool, summary *promoteSummary) ([]promoteJob, bool) {
+	guard := guardFromConfig(cfg)
+	templates := newTemplateCache(ctx, cfg, buildRunner(cfg, false))
+	var jobs []promoteJob
+	hashes := map[string]string{}
+	blocked := false
+	for _, t := range targets {
+		mode := fsutil.SyncMode(targetMode(cfg, t, ""))
+		for _, dest := range t.Dest {
+			destPath := fsutil.ResolvePath(r.Path, dest)
+			res := promoteResult{Target: t.Name, Dest: destPath}
+			if mode == fsutil.ModeLink || mode == fsutil.ModeLinkDir {
+				res.Skipped = append(res.Skipped, promoteSkip{Path: ".", Reason: "linked to the source"})
+				summary.Results = append(summary.Results, res)
+				continue
+			}
+			err := guard.CheckPath(t.Src)
+			if err == nil {
+				err = guard.CheckPath(destPath)
+			}
+			var candidates []string
+			if err == nil {
+				candidates, err = promoteCandidates(cfg, t, destPath, templates.forTarget(r, t), files, force, &res)
+			}
+			if err != nil {
+				res.Error = err.Error()
+				blocked = true
+				summary.Results = append(summary.Results, res)
+				continue
+			}
+			var keep []string
+			for _, rel := range candidates {
+				src := filepath.Join(t.Src, filepath.FromSlash(rel))
+				hash, err := fsutil.FileHash(filepath.Join(destPath, filepath.FromSlash(rel)))
+				if err != nil {
+					res.Error = err.Error()
+					blocked = true
+					break
+				}
+				if prev, ok := hashes[src]; ok && prev != hash {
+					res.Conflicts = append(res.Conflicts, promoteSkip{Path: rel, Reason: "edited differently in another destination"})
+					continue
+				}
+				hashes[src] = hash
+				keep = append(keep, rel)
+			}
+			if len(res.Conflicts) > 0 {
+				blocked = true
+			}
+			res.Files = keep
+			if len(keep) > 0 {
+				jobs = append(jobs, promoteJob{target: t, dest: destPath, files: keep})
+			}
+			summary.Results = append(summary.Results, res)
+		}
+	}
+	return jobs, blocked
+}
+
+func promoteCandidates(cfg config.Config, t config.SyncTarget, destPath string, tmpl *fsutil.Template, files []string, force bool, res *promoteResult) ([]string, error) {
+	d, err := diffFiles(cfg, t, destPath, tmpl)
+	if err != nil {
+		return nil, err
+	}
+	m, managed, err := fsutil.ReadManifest(destPath)
+	if err != nil {
+		return nil, err
+	}
+	var local, upstream []string
+	if managed {
+		local, upstream, err = fsutil.ClassifyChanges(t.Src, destPath, d.Changed, m, tmpl)
+		if err != nil {
+			return nil, err
+		}
+	}
+	selected := func(rel string) bool {
+		return len(files) > 0 && match.Any(files, rel)
+	}
+	var out []string
+	for _, rel := range d.Changed {
+		switch {
+		case len(files) > 0 && !selected(rel):
+			continue
+		case tmpl.Renders(t.Src, rel):
+			res.Skipped = append(res.Skipped, promoteSkip{Path: rel, Reason: "rendered from a template"})
+			continue
+		case managed && !slices.Contains(local, rel):
+			continue
+		case !managed && !selected(rel):
+			res.Skipped = append(res.Skipped, promoteSkip{Path: rel, Reason: "unmanaged destination; name it with --files"})
+			continue
+		case slices.Contains(upstream, rel) && !force:
+			res.Conflicts = append(res.Conflicts, promoteSkip{Path: rel, Reason: "also changed in the source; use --force"})
+			continue
+		}
+		out = append(out, rel)
+	}
+	for _, rel := range d.Removed {
+		if selected(rel) {
+			out = append(out, rel)
+		}
+	}
+	slices.Sort(out)
+	return out, nil
+}
+
+func promoteGroups(cfg config.Config, jobs []promoteJob) []promoteCommit {
+	var groups []promoteCommit
+	index := map[string]int{}
+	for _, job := range jobs {
+		dir := cfg.SkillsRoot
+		if job.target.Checkout != "" {
+			dir = job.target.Checkout
+		}
+		i, ok := index[dir]
+		if !ok {
+			i = len(groups)
+			index[dir] = i
+			groups = append(groups, promoteCommit{Dir: dir})
+		}
+		for _, rel := range job.files {
+			path := filepath.Join(job.target.Src, filepath.FromSlash(rel))
+			if r, err := filepath.Rel(dir, path); err == nil && !strings.HasPrefix(r, "..") {
+				path = r
+			}
+			if !slices.Contains(groups[i].Files, path) {
+				groups[i].Files = append(groups[i].Files, path)
+			}
+		}
+	}
+	return groups
+}
+
+type promoteBranch struct {
+	Dir  string
+	Prev string
+}
+
+func originalBranch(ctx context.Context, runner executil.Runner, dir string) (string, error) {
+	branch, err := gitutil.CurrentBranch(ctx, runner, dir)
+	if err != nil || branch != "HEAD" {
+		return branch, err
+	}
+	return gitutil.HeadCommit(ctx, runner, dir)
+}
+
+func (a App) restoreBranches(ctx context.Context, runner executil.Runner, created []promoteBranch, name string) {
+	for _, b := range created {
+		err := gitutil.Checkout(ctx, runner, b.Dir, b.Prev)
+		if err == nil {
+			err = gitutil.DeleteBranch(ctx, runner, b.Dir, name)
+		}
+		if err != nil {
+			a.Out.Warn(fmt.Sprintf("%s: could not restore %s and delete branch %s: %v", b.Dir, b.Prev, name, err), nil)
+		}
+	}
+}
+
+func (a App) failPromote(summary promoteSummary, msg string) int {
+	if a.Out.JSON {
+		a.Out.Err(msg, summary)
+		return 1
+	}
+	a.printPromote(summary, false)
+	a.Out.Err(msg, nil)
+	return 1
+}
+
+func (a App) printPromote(summary promoteSummary, files bool) {
+	verb := "promoted"
+	if summary.DryRun {
+		verb = "would promote"
+	}
+	for _, r := range summary.Results {
+		label := resultLabel(summary.Repo, r.Target)
+		if r.Error != "" {
+			a.Out.Err(fmt.Sprintf("%s: %s", label, r.Error), nil)
+		}
+		for _, c := range r.Conflicts {
+			a.Out.Err(fmt.Sprintf("%s %s: %s", label, c.Path, c.Reason), nil)
+		}
+		for _, s := range r.Skipped {
+			a.Out.Warn(fmt.Sprintf("%s %s skipped: %s", label, s.Path, s.Reason), nil)
+		}
+		if !files {
+			continue
+		}
+		for _, f := range r.Files {
+			a.Out.OK(fmt.Sprintf("%s %s %s", verb, label, f), nil)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/TT-AIXion/github-kanri/internal/config"
+	"github.com/TT-AIXion/github-kanri/internal/fsutil"
+	"github.com/TT-AIXion/github-kanri/internal/repo"
+)
+
+func (a App) runSkillsPrune(_ context.Context, args []string) int {
+	fs := flag.NewFlagSet("skills prune", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+	force := fs.Bool("force", false, "delete orphaned destinations")
+	dryRun := fs.Bool("dry-run", false, "only list orphaned destinations (default)")
+	var only multiFlag
+	var exclude multiFlag
+	fs.Var(&only, "only", "only patterns")
+	fs.Var(&exclude, "exclude", "exclude patterns")
+	if err := fs.Parse(args); err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	if *force && *dryRun {
+		a.Out.Err("--force cannot be combined with --dry-run", nil)
+		return 1
+	}
+	cfg, _, err := loadConfig()
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	repos, err := repo.Scan(cfg.ReposRoot)
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	repos = repo.Filter(repos, only, exclude)
+	results, failed := findOrphans(cfg, repos)
+	pending := 0
+	for _, r := range results {
+		if r.Error == "" && r.Skipped == "" {
+			pending++
+		}
+	}
+	var journal *fsutil.Journal
+	if *force && pending > 0 {
+		root, err := journalRoot()
+		if err == nil {
+			journal, err = fsutil.NewJournal(root)
+		}
+		if err != nil {
+			a.Out.Err(fmt.Sprintf("journal: %v", err), nil)
+			return 1
+		}
+	}
+	for i := range results {
+		r := &results[i]
+		r.DryRun = !*force
+		if r.Error != "" || r.Skipped != "" {
+			continue
+		}
+		report, err := fsutil.PruneDest(r.Dest, fsutil.SyncOptions{DryRun: !*force, Journal: journal})
+		r.Removed, r.Kept, r.Run = report.Removed, report.Kept, journal.ID()
+		if err != nil {
+			failed++
+			r.Error = err.Error()
+			continue
+		}
+		if *force {
+			removeEmptyParents(r.Dest, r.root)
+		}
+	}
+	if failed > 0 {
+		a.abortJournal(journal, false)
+	} else {
+		a.commitJournal(journal)
+	}
+	if a.Out.JSON {
+		a.Out.OK("skills prune", results)
+		return a.failureCode(failed, len(results), "destinations")
+	}
+	verb := "pruned"
+	if !*force {
+		verb = "would prune"
+	}
+	for _, r := range results {
+		label := resultLabel(r.Repo, r.Path)
+		switch {
+		case r.Error != "":
+			a.Out.Err(fmt.Sprintf("%s: %s", label, r.Error), nil)
+		case r.Skipped != "":
+			a.Out.Warn(fmt.Sprintf("%s skipped: %s", label, r.Skipped), nil)
+		default:
+			msg := fmt.Sprintf("%s %s files=%d", verb, label, len(r.Removed))
+			if r.Target != "" {
+				msg += " target=" + r.Target
+			}
+			a.Out.OK(msg, nil)
+			for _, k := range r.Kept {
+				a.Out.Raw(fmt.Sprintf("  %-8s %s", "kept", k))
+			}
+		}
+	}
+	switch {
+	case pending == 0 && failed == 0:
+		a.Out.OK("no orphaned destinations", nil)
+	case !*force && pending > 0:
+		a.Out.Warn("dry run; rerun with --force to delete", nil)
+	}
+	return a.failureCode(failed, len(results), "destinations")
+}
+
+func findOrphans(cfg config.Config, repos []repo.Repo) ([]pruneResult, int) {
+	guard := guardFromConfig(cfg)
+	var results []pruneResult
+	failed := 0
+	for _, r := range repos {
+		targets, err := targetsForRepo(cfg, cfg.SyncTargets, r)
+		if err != nil {
+			failed++
+			results = append(results, pruneResult{Repo: r.Name, Error: err.Error()})
+			continue
+		}
+		var configured []string
+		for _, t := range targets {
+			for _, dest := range t.Dest {
+				configured = append(configured, filepath.Clean(fsutil.ResolvePath(r.Path, dest)))
+			}
+		}
+		dirs, err := fsutil.FindManifests(r.Path)
+		if err != nil {
+			failed++
+			results = append(results, pruneResult{Repo: r.Name, Error: err.Error()})
+			continue
+		}
+		for _, dir := range dirs {
+			result := pruneResult{Repo: r.Name, Dest: dir, Path: dir, root: r.Path}
+			if rel, err := filepath.Rel(r.Path, dir); err == nil {
+				result.Path = filepath.ToSlash(rel)
+			}
+			overlap, ok := destOverlap(configured, dir)
+			if ok && overlap == dir {
+				continue
+			}
+			if ok {
+				rel, _ := filepath.Rel(r.Path, overlap)
+				result.Skipped = "overlaps configured destination " + filepath.ToSlash(rel)
+			}
+			m, _, err := fsutil.ReadManifest(dir)
+			if err == nil {
+				err = guard.CheckPath(dir)
+			}
+			if err != nil {
+				failed++
+				result.Error = err.Error()
+			}
+			result.Target, result.Source = m.Target, m.Source
+			if !m.SyncedAt.IsZero() {
+				syncedAt := m.SyncedAt
+				result.SyncedAt = &syncedAt
+			}
+			results = append(results, result)
+		}
+	}
+	return results, failed
+}
+
+func destOverlap(configured []string, dir string) (string, bool) {
+	sep := string(filepath.Separator)
+	for _, c := range configured {
+		if c == dir {
+			return c, true
+		}
+	}
+	for _, c := range configured {
+		if strings.HasPrefix(dir, c+sep) || strings.HasPrefix(c, dir+sep) {
+			return c, true
+		}
+	}
+	return "", false
+}
+
+func removeEmptyParents(dir, root string) {
+	for dir = filepath.Dir(dir); strings.HasPrefix(dir, root+string(filepath.Separator)); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/TT-AIXion/github-kanri/internal/config"
+	"github.com/TT-AIXion/github-kanri/internal/repo"
+	"github.com/TT-AIXion/github-kanri/internal/skill"
+)
+
+func loadRepoConfig(cfg config.Config, r repo.Repo) (config.RepoConfig, error) {
+	if r.Path == "" {
+		return config.RepoConfig{}, nil
+	}
+	rc, ok, err := config.LoadRepoConfig(r.Path)
+	if err != nil || !ok {
+		return rc, err
+	}
+	known := func(name string) bool {
+		for _, t := range cfg.SyncTargets {
+			if t.Name == name {
+				return true
+			}
+		}
+		return false
+	}
+	names := append(append([]string{}, rc.Targets...), rc.ExcludeTargets...)
+	for name := range rc.Overrides {
+		names = append(names, name)
+	}
+	for _, name := range names {
+		if !known(name) {
+			return rc, fmt.Errorf("%s: unknown target %s", config.RepoConfigName, name)
+		}
+	}
+	return rc, nil
+}
+
+func applyRepoConfig(t config.SyncTarget, rc config.RepoConfig) config.SyncTarget {
+	o := rc.Overrides[t.Name]
+	if len(t.Include) > 0 {
+		t.Include = append(append(append([]string{}, t.Include...), rc.Include...), o.Include...)
+	}
+	exclude := append(append(append([]string{}, t.Exclude...), rc.Exclude...), o.Exclude...)
+	for _, name := range append(append([]string{}, rc.ExcludeSkills...), o.ExcludeSkills...) {
+		exclude = append(exclude, name, name+"/**")
+	}
+	skills := rc.Skills
+	if len(o.Skills) > 0 {
+		skills = o.Skills
+	}
+	if len(skills) > 0 {
+		entries, _ := os.ReadDir(t.Src)
+		for _, e := range entries {
+			if !e.IsDir() || slices.Contains(skills, e.Name()) {
+				continue
+			}
+			if _, err := os.Stat(filepath.Join(t.Src, e.Name(), skill.ManifestName)); err == nil {
+				exclude = append(exclude, e.Name(), e.Name()+"/**")
+			}
+		}
+	}
+	t.Exclude = exclude
+	if len(o.Dest) > 0 {
+		t.Dest = append([]string{}, o.Dest...)
+	}
+	return t
+}
+
+func resultLabel(repoName, target string) string {
+	return strings.TrimSpace(repoName + " " + target)
+}
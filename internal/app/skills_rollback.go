@@ -0,0 +1,157 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/TT-AIXion/github-kanri/internal/config"
+	"github.com/TT-AIXion/github-kanri/internal/fsutil"
+)
+
+const journalKeep = 20
+
+func journalRoot() (string, error) {
+	state, err := config.DefaultStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(state, "journal"), nil
+}
+
+func (a App) abortJournal(journal *fsutil.Journal, atomic bool) {
+	if journal == nil {
+		return
+	}
+	if !atomic {
+		if journal.Trivial() {
+			_ = journal.Discard()
+			return
+		}
+		_ = journal.Finish(fsutil.JournalFailed)
+		if a.Out.JSON {
+			return
+		}
+		a.Out.Warn(fmt.Sprintf("changes of this run were kept; undo them with 'gkn skills rollback --run %s'", journal.ID()), nil)
+		return
+	}
+	if err := journal.Rollback(); err != nil {
+		_ = journal.Finish(fsutil.JournalFailed)
+		a.Out.Err(fmt.Sprintf("rollback of run %s failed: %v", journal.ID(), err), nil)
+		return
+	}
+	a.Out.Warn(fmt.Sprintf("rolled back run %s", journal.ID()), nil)
+}
+
+func (a App) commitJournal(journal *fsutil.Journal) {
+	if journal == nil {
+		return
+	}
+	if journal.Trivial() {
+		if err := journal.Discard(); err != nil {
+			a.Out.Warn(fmt.Sprintf("journal: %v", err), nil)
+		}
+		return
+	}
+	if err := journal.Finish(fsutil.JournalCommitted); err != nil {
+		a.Out.Warn(fmt.Sprintf("journal: %v", err), nil)
+	}
+	_ = fsutil.PruneJournals(filepath.Dir(journal.Dir), journalKeep)
+}
+
+func (a App) runSkillsRollback(_ context.Context, args []string) int {
+	fs := flag.NewFlagSet("skills rollback", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+	run := fs.String("run", "", "run id")
+	list := fs.Bool("list", false, "list journaled runs")
+	force := fs.Bool("force", false, "restore paths changed since the run")
+	if err := fs.Parse(args); err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	cfg, _, err := loadConfig()
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	root, err := journalRoot()
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	journals, err := fsutil.ListJournals(root)
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	if *list {
+		if a.Out.JSON {
+			a.Out.OK("skills rollback", journals)
+			return 0
+		}
+		for _, j := range journals {
+			a.Out.Raw(fmt.Sprintf("%s %-11s entries=%d %s", j.ID, j.Status, j.Entries, j.CreatedAt.Local().Format("2006-01-02 15:04:05")))
+		}
+		return 0
+	}
+	id := *run
+	if id == "" {
+		for _, j := range journals {
+			if j.Status != fsutil.JournalRolledBack {
+				id = j.ID
+				break
+			}
+		}
+		if id == "" {
+			a.Out.Err("no sync run to roll back", nil)
+			return 1
+		}
+	}
+	journal, err := fsutil.OpenJournal(root, id)
+	if err != nil {
+		a.Out.Err(fmt.Sprintf("run %s: %v", id, err), nil)
+		return 1
+	}
+	if journal.Meta.Status == fsutil.JournalRolledBack {
+		a.Out.Err(fmt.Sprintf("run %s was already rolled back", id), nil)
+		return 1
+	}
+	guard := guardFromConfig(cfg)
+	for _, e := range journal.Entries() {
+		if err := guard.CheckPath(e.Path); err != nil {
+			a.Out.Err(err.Error(), nil)
+			return 1
+		}
+	}
+	if !*force {
+		drifted, err := journal.Drifted()
+		if err != nil {
+			a.Out.Err(fmt.Sprintf("run %s: %v", id, err), nil)
+			return 1
+		}
+		if len(drifted) > 0 {
+			msg := fmt.Sprintf("run %s: %d paths changed since the run; rerun with --force to overwrite them", id, len(drifted))
+			if a.Out.JSON {
+				a.Out.Err(msg, drifted)
+				return 1
+			}
+			for _, p := range drifted {
+				a.Out.Raw(fmt.Sprintf("  %-8s %s", "changed", p))
+			}
+			a.Out.Err(msg, nil)
+			return 1
+		}
+	}
+	if err := journal.Rollback(); err != nil {
+		a.Out.Err(fmt.Sprintf("rollback of run %s: %v", id, err), nil)
+		return 1
+	}
+	if a.Out.JSON {
+		a.Out.OK("skills rollback", journal.Meta)
+		return 0
+	}
+	a.Out.OK(fmt.Sprintf("rolled back run %s (%d paths restored)", id, len(journal.Entries())), nil)
+	return 0
+}
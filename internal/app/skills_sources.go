@@ -0,0 +1,112 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/TT-AIXion/github-kanri/internal/config"
+	"github.com/TT-AIXion/github-kanri/internal/executil"
+	"github.com/TT-AIXion/github-kanri/internal/fsutil"
+	"github.com/TT-AIXion/github-kanri/internal/gitutil"
+)
+
+type skillSource struct {
+	Name    string   `json:"name"`
+	Remote  string   `json:"remote"`
+	Ref     string   `json:"ref,omitempty"`
+	Dir     string   `json:"dir"`
+	Targets []string `json:"targets"`
+	Action  string   `json:"action,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+func remoteSources(cfg config.Config) []skillSource {
+	var out []skillSource
+	index := map[string]int{}
+	for _, t := range cfg.SyncTargets {
+		if strings.TrimSpace(t.Remote) == "" {
+			continue
+		}
+		if i, ok := index[t.Checkout]; ok {
+			out[i].Targets = append(out[i].Targets, t.Name)
+			continue
+		}
+		name := t.Remote
+		if t.Ref != "" {
+			name += "@" + t.Ref
+		}
+		index[t.Checkout] = len(out)
+		out = append(out, skillSource{Name: name, Remote: t.Remote, Ref: t.Ref, Dir: t.Checkout, Targets: []string{t.Name}})
+	}
+	return out
+}
+
+func targetRemote(cfg config.Config, t config.SyncTarget) string {
+	if remote := strings.TrimSpace(t.Remote); remote != "" {
+		return remote
+	}
+	return strings.TrimSpace(cfg.SkillsRemote)
+}
+
+func updateSource(ctx context.Context, runner executil.Runner, s skillSource) (string, error) {
+	action := "updated"
+	if fsutil.IsGitRepo(s.Dir) {
+		var err error
+		if s.Ref == "" {
+			err = gitutil.Pull(ctx, runner, s.Dir)
+		} else {
+			err = gitutil.Fetch(ctx, runner, s.Dir)
+		}
+		if err != nil {
+			return "", err
+		}
+	} else {
+		action = "cloned"
+		if err := os.MkdirAll(filepath.Dir(s.Dir), 0o755); err != nil {
+			return "", err
+		}
+		if err := gitutil.Clone(ctx, runner, s.Remote, s.Dir); err != nil {
+			return "", err
+		}
+	}
+	if s.Ref != "" {
+		ref := s.Ref
+		if _, err := gitutil.ResolveCommit(ctx, runner, s.Dir, "refs/remotes/origin/"+s.Ref); err == nil {
+			ref = "origin/" + s.Ref
+		}
+		if err := gitutil.Checkout(ctx, runner, s.Dir, ref); err != nil {
+			return "", err
+		}
+	}
+	return action, nil
+}
+
+func (a App) pruneSourceCache(sources []skillSource) {
+	cache, err := config.DefaultCacheDir()
+	if err != nil {
+		return
+	}
+	root := filepath.Join(cache, "sources")
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return
+	}
+	used := map[string]bool{}
+	for _, s := range sources {
+		used[filepath.Clean(s.Dir)] = true
+	}
+	for _, e := range entries {
+		dir := filepath.Join(root, e.Name())
+		if !e.IsDir() || used[dir] {
+			continue
+		}
+		if err := removeAll(dir); err != nil {
+			a.Out.Warn(fmt.Sprintf("source cache %s: %v", e.Name(), err), nil)
+			continue
+		}
+		a.Out.OK(fmt.Sprintf("source cache %s removed", e.Name()), nil)
+	}
+}
@@ -0,0 +1,251 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/TT-AIXion/github-kanri/internal/config"
+	"github.com/TT-AIXion/github-kanri/internal/executil"
+	"github.com/TT-AIXion/github-kanri/internal/fsutil"
+	"github.com/TT-AIXion/github-kanri/internal/gitutil"
+	"github.com/TT-AIXion/github-kanri/internal/repo"
+)
+
+func (a App) runSkillsStatus(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("skills status", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+	target := fs.String("target", "", "target")
+	var only multiFlag
+	var exclude multiFlag
+	fs.Var(&only, "only", "only patterns")
+	fs.Var(&exclude, "exclude", "exclude patterns")
+	if err := fs.Parse(args); err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	cfg, _, err := loadConfig()
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	targets, err := selectTargets(cfg, *target)
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	repos, err := repo.Scan(cfg.ReposRoot)
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	repos = repo.Filter(repos, only, exclude)
+	runner := buildRunner(cfg, false)
+	report := skillsStatus{Sources: statusSources(ctx, runner, cfg, targets)}
+	templates := newTemplateCache(ctx, cfg, runner)
+	results, failed := diffTargets(cfg, repos, targets, templates, patchOptions{})
+	refs := map[string]string{}
+	for _, s := range report.Sources {
+		for _, name := range s.Targets {
+			refs[name] = s.refLabel()
+		}
+	}
+	for _, r := range results {
+		d := statusDestination(r)
+		d.Ref = refs[r.Target]
+		report.Destinations = append(report.Destinations, d)
+	}
+	if a.Out.JSON {
+		a.Out.OK("skills status", report)
+		return a.failureCode(failed, len(results), "targets")
+	}
+	a.Out.Raw(renderSourceTable(report.Sources))
+	if len(report.Destinations) > 0 {
+		a.Out.Raw("")
+		roots := map[string]string{}
+		for _, r := range repos {
+			roots[r.Name] = r.Path
+		}
+		a.Out.Raw(renderDestinationTable(report.Destinations, roots))
+	}
+	for _, d := range report.Destinations {
+		if d.Error != "" {
+			a.Out.Err(fmt.Sprintf("%s: %s", resultLabel(d.Repo, d.Target), d.Error), nil)
+		}
+	}
+	return a.failureCode(failed, len(results), "targets")
+}
+
+func statusSources(ctx context.Context, runner executil.Runner, cfg config.Config, targets []config.SyncTarget) []sourceStatus {
+	var out []sourceStatus
+	for _, s := range lockSources(cfg) {
+		var names []string
+		for _, name := range s.Targets {
+			if slices.ContainsFunc(targets, func(t config.SyncTarget) bool { return t.Name == name }) {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			continue
+		}
+		out = append(out, sourceState(ctx, runner, skillSource{Name: s.Name, Remote: s.Remote, Ref: s.Ref, Dir: s.Dir, Targets: names}))
+	}
+	return out
+}
+
+func sourceState(ctx context.Context, runner executil.Runner, s skillSource) sourceStatus {
+	st := sourceStatus{Name: s.Name, Dir: s.Dir, Remote: s.Remote, Pin: s.Ref, Targets: s.Targets}
+	if _, err := os.Stat(s.Dir); err != nil {
+		st.Error = "missing"
+		return st
+	}
+	if !fsutil.IsGitRepo(s.Dir) {
+		return st
+	}
+	st.Git = true
+	var err error
+	if st.Commit, err = gitutil.HeadCommit(ctx, runner, s.Dir); err != nil {
+		st.Error = err.Error()
+		return st
+	}
+	branch, err := gitutil.CurrentBranch(ctx, runner, s.Dir)
+	if err != nil {
+		st.Error = err.Error()
+		return st
+	}
+	if branch == "HEAD" {
+		st.Detached = true
+		st.Branch, _ = gitutil.ExactTag(ctx, runner, s.Dir)
+	} else {
+		st.Branch = branch
+	}
+	clean, err := gitutil.IsClean(ctx, runner, s.Dir)
+	if err != nil {
+		st.Error = err.Error()
+		return st
+	}
+	st.Dirty = !clean
+	if !st.Detached {
+		if ahead, behind, err := gitutil.AheadBehind(ctx, runner, s.Dir); err == nil {
+			st.Ahead, st.Behind = &ahead, &behind
+		}
+	}
+	return st
+}
+
+func statusDestination(r diffResult) destinationStatus {
+	d := destinationStatus{
+		Repo:     r.Repo,
+		Target:   r.Target,
+		Dest:     r.Dest,
+		State:    "clean",
+		Managed:  r.Managed,
+		Revision: r.Revision,
+		SyncedAt: r.SyncedAt,
+		Counts: driftCounts{
+			Added:           len(r.Added),
+			Removed:         len(r.Removed),
+			Changed:         len(r.Changed),
+			LocalModified:   len(r.LocalModified),
+			UpstreamChanged: len(r.UpstreamChanged),
+			Dangling:        len(r.Dangling),
+			WrongTarget:     len(r.WrongTarget),
+			NotLink:         len(r.NotLink),
+		},
+		Error: r.Error,
+	}
+	switch {
+	case r.Error != "":
+		d.State = "error"
+	case !r.clean():
+		d.State = "drift"
+	}
+	return d
+}
+
+func renderSourceTable(sources []sourceStatus) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "SOURCE\tCOMMIT\tREF\tPIN\tTREE\tAHEAD\tBEHIND\tTARGETS")
+	for _, s := range sources {
+		commit, ref, tree, ahead, behind := "-", "-", "-", "-", "-"
+		switch {
+		case s.Error != "":
+			tree = s.Error
+		case !s.Git:
+			tree = "not git"
+		default:
+			commit = shortHash(s.Commit)
+			ref = s.Branch
+			if s.Detached {
+				ref = "detached"
+				if s.Branch != "" {
+					ref += " " + s.Branch
+				}
+			}
+			tree = "clean"
+			if s.Dirty {
+				tree = "dirty"
+			}
+		}
+		if s.Ahead != nil {
+			ahead, behind = strconv.Itoa(*s.Ahead), strconv.Itoa(*s.Behind)
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", s.Name, commit, ref, dash(s.Pin), tree, ahead, behind, strings.Join(s.Targets, ","))
+	}
+	_ = w.Flush()
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func (s sourceStatus) refLabel() string {
+	if !s.Git || s.Commit == "" {
+		return ""
+	}
+	if s.Branch == "" {
+		return shortHash(s.Commit)
+	}
+	return s.Branch + "@" + shortHash(s.Commit)
+}
+
+func renderDestinationTable(dests []destinationStatus, roots map[string]string) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "REPO\tTARGET\tDEST\tREF\tSTATE\tREV\tSYNCED\tADDED\tREMOVED\tCHANGED\tLOCAL\tUPSTREAM\tLINKS")
+	for _, d := range dests {
+		rev, synced := "unmanaged", "-"
+		if d.Managed {
+			rev = "unknown"
+			if d.Revision != "" {
+				rev = shortHash(d.Revision)
+			}
+			if d.SyncedAt != nil {
+				synced = d.SyncedAt.Local().Format("2006-01-02 15:04")
+			}
+		}
+		c := d.Counts
+		if d.State == "error" {
+			rev = "-"
+		}
+		dest := d.Dest
+		if rel, err := filepath.Rel(roots[d.Repo], d.Dest); err == nil && roots[d.Repo] != "" && !strings.HasPrefix(rel, "..") {
+			dest = filepath.ToSlash(rel)
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%d\t%d\t%d\t%d\t%d\t%d\n", d.Repo, dash(d.Target), dash(dest), dash(d.Ref), d.State, rev, synced,
+			c.Added, c.Removed, c.Changed, c.LocalModified, c.UpstreamChanged, c.Dangling+c.WrongTarget+c.NotLink)
+	}
+	_ = w.Flush()
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
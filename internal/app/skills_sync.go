@@ -5,10 +5,14 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/TT-AIXion/github-kanri/internal/config"
+	"github.com/TT-AIXion/github-kanri/internal/executil"
 	"github.com/TT-AIXion/github-kanri/internal/fsutil"
+	"github.com/TT-AIXion/github-kanri/internal/gitutil"
+	"github.com/TT-AIXion/github-kanri/internal/match"
 	"github.com/TT-AIXion/github-kanri/internal/repo"
 	"github.com/TT-AIXion/github-kanri/internal/safety"
 )
@@ -18,8 +22,14 @@ func (a App) runSkillsSync(ctx context.Context, args []string) int {
 	fs.SetOutput(os.Stdout)
 	target := fs.String("target", "", "target")
 	mode := fs.String("mode", "", "mode")
+	relative := fs.Bool("relative", false, "relative symlinks")
 	force := fs.Bool("force", false, "force")
 	dryRun := fs.Bool("dry-run", false, "dry run")
+	plan := fs.Bool("plan", false, "print the action plan without syncing")
+	planOut := fs.String("out", "", "save the plan as JSON")
+	apply := fs.String("apply", "", "apply a saved plan")
+	atomic := fs.Bool("atomic", false, "roll back every repo if any sync fails")
+	keepGoing := fs.Bool("keep-going", false, "continue past per-repo errors")
 	var only multiFlag
 	var exclude multiFlag
 	fs.Var(&only, "only", "only patterns")
@@ -33,18 +43,40 @@ func (a App) runSkillsSync(ctx context.Context, args []string) int {
 		a.Out.Err(err.Error(), nil)
 		return 1
 	}
+	cfg.RelativeLinks = cfg.RelativeLinks || *relative
+	if *atomic && *keepGoing {
+		a.Out.Err("--atomic cannot be combined with --keep-going", nil)
+		return 1
+	}
+	if *apply != "" {
+		if *plan || *dryRun || *target != "" || *mode != "" || *force || len(only)+len(exclude) > 0 {
+			a.Out.Err("--apply cannot be combined with other sync options", nil)
+			return 1
+		}
+		return a.applySyncPlan(ctx, cfg, *apply, *atomic, *keepGoing)
+	}
 	targets, err := selectTargets(cfg, *target)
 	if err != nil {
 		a.Out.Err(err.Error(), nil)
 		return 1
 	}
-	return a.syncTargets(ctx, cfg, targets, *mode, *force, *dryRun, only, exclude)
+	if *plan || *dryRun || *planOut != "" {
+		return a.planSync(ctx, cfg, targets, *mode, *force, only, exclude, *planOut)
+	}
+	jobs, err := syncJobs(ctx, cfg, targets, *mode, *force, false, only, exclude)
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	return a.runSyncJobs(jobs, *atomic, *keepGoing)
 }
 
 func (a App) runSkillsLink(ctx context.Context, args []string) int {
 	fs := flag.NewFlagSet("skills link", flag.ContinueOnError)
 	fs.SetOutput(os.Stdout)
 	target := fs.String("target", "", "target")
+	dir := fs.Bool("dir", false, "link top-level directories")
+	relative := fs.Bool("relative", false, "relative symlinks")
 	force := fs.Bool("force", false, "force")
 	dryRun := fs.Bool("dry-run", false, "dry run")
 	var only multiFlag
@@ -65,12 +97,26 @@ func (a App) runSkillsLink(ctx context.Context, args []string) int {
 		a.Out.Err(err.Error(), nil)
 		return 1
 	}
-	return a.syncTargets(ctx, cfg, targets, string(fsutil.ModeLink), *force, *dryRun, only, exclude)
+	mode := fsutil.ModeLink
+	if *dir {
+		mode = fsutil.ModeLinkDir
+	}
+	cfg.RelativeLinks = cfg.RelativeLinks || *relative
+	if *dryRun {
+		return a.planSync(ctx, cfg, targets, string(mode), *force, only, exclude, "")
+	}
+	return a.syncTargets(ctx, cfg, targets, string(mode), *force, false, only, exclude)
 }
 
 func selectTargets(cfg config.Config, name string) ([]config.SyncTarget, error) {
 	if strings.TrimSpace(name) == "" {
-		return cfg.SyncTargets, nil
+		var out []config.SyncTarget
+		for _, t := range cfg.SyncTargets {
+			if t.IsEnabled() {
+				out = append(out, t)
+			}
+		}
+		return out, nil
 	}
 	for _, t := range cfg.SyncTargets {
 		if t.Name == name {
@@ -80,62 +126,244 @@ func selectTargets(cfg config.Config, name string) ([]config.SyncTarget, error)
 	return nil, fmt.Errorf("target not found: %s", name)
 }
 
-func (a App) syncTargets(ctx context.Context, cfg config.Config, targets []config.SyncTarget, mode string, force bool, dryRun bool, only []string, exclude []string) int {
-	repos, err := repo.Scan(cfg.ReposRoot)
+func targetsForRepo(cfg config.Config, targets []config.SyncTarget, r repo.Repo) ([]config.SyncTarget, error) {
+	rc, err := loadRepoConfig(cfg, r)
 	if err != nil {
-		a.Out.Err(err.Error(), nil)
-		return 1
+		return nil, err
 	}
-	repos = repo.Filter(repos, only, exclude)
-	guard := guardFromConfig(cfg)
-	policy := cfg.ConflictPolicy
+	var out []config.SyncTarget
+	for _, t := range targets {
+		if slices.Contains(rc.ExcludeTargets, t.Name) {
+			continue
+		}
+		if !slices.Contains(rc.Targets, t.Name) {
+			if len(t.Repos) > 0 && !repoSelected(cfg, t.Repos, r.Name) {
+				continue
+			}
+			if repoSelected(cfg, t.ExcludeRepos, r.Name) {
+				continue
+			}
+		}
+		out = append(out, applyRepoConfig(t, rc))
+	}
+	return out, nil
+}
+
+func repoSelected(cfg config.Config, selectors []string, name string) bool {
+	for _, sel := range selectors {
+		patterns := []string{sel}
+		if tag, ok := strings.CutPrefix(sel, config.TagPrefix); ok {
+			patterns = cfg.RepoTags[tag]
+		}
+		if match.Any(patterns, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func targetMode(cfg config.Config, t config.SyncTarget, override string) string {
+	if strings.TrimSpace(override) != "" {
+		return override
+	}
+	if t.Mode != "" {
+		return t.Mode
+	}
+	return cfg.SyncMode
+}
+
+func targetPolicy(cfg config.Config, t config.SyncTarget, force bool) string {
 	if force {
-		policy = string(fsutil.ConflictOverwrite)
+		return string(fsutil.ConflictOverwrite)
 	}
-	syncMode := cfg.SyncMode
-	if strings.TrimSpace(mode) != "" {
-		syncMode = mode
+	if t.ConflictPolicy != "" {
+		return t.ConflictPolicy
 	}
-	if syncMode != string(fsutil.ModeCopy) && syncMode != string(fsutil.ModeMirror) && syncMode != string(fsutil.ModeLink) {
-		a.Out.Err(fmt.Sprintf("invalid mode: %s", syncMode), nil)
-		return 1
+	return cfg.ConflictPolicy
+}
+
+type syncJob struct {
+	repo   repo.Repo
+	target config.SyncTarget
+	dest   string
+	opts   fsutil.SyncOptions
+}
+
+func syncJobs(ctx context.Context, cfg config.Config, targets []config.SyncTarget, mode string, force bool, dryRun bool, only []string, exclude []string) ([]syncJob, error) {
+	repos, err := repo.Scan(cfg.ReposRoot)
+	if err != nil {
+		return nil, err
 	}
-	opts := fsutil.SyncOptions{
-		Mode:           fsutil.SyncMode(syncMode),
-		ConflictPolicy: fsutil.ConflictPolicy(policy),
-		DryRun:         dryRun,
+	repos = repo.Filter(repos, only, exclude)
+	guard := guardFromConfig(cfg)
+	for _, t := range targets {
+		syncMode := targetMode(cfg, t, mode)
+		if syncMode != string(fsutil.ModeCopy) && syncMode != string(fsutil.ModeMirror) && syncMode != string(fsutil.ModeLink) && syncMode != string(fsutil.ModeLinkDir) {
+			return nil, fmt.Errorf("invalid mode: %s", syncMode)
+		}
 	}
-	var results []syncResult
+	runner := buildRunner(cfg, false)
+	templates := newTemplateCache(ctx, cfg, runner)
+	commits := map[string]string{}
+	var jobs []syncJob
 	for _, r := range repos {
-		for _, t := range targets {
+		repoTargets, err := targetsForRepo(cfg, targets, r)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", r.Name, err)
+		}
+		for _, t := range repoTargets {
 			if err := guard.CheckPath(t.Src); err != nil {
-				a.Out.Err(err.Error(), nil)
-				return 1
+				return nil, err
+			}
+			if _, ok := commits[t.Src]; !ok {
+				commits[t.Src] = sourceCommit(ctx, runner, t.Src)
+			}
+			opts := fsutil.SyncOptions{
+				Mode:           fsutil.SyncMode(targetMode(cfg, t, mode)),
+				ConflictPolicy: fsutil.ConflictPolicy(targetPolicy(cfg, t, force)),
+				DryRun:         dryRun,
+				Include:        t.Include,
+				Exclude:        t.Exclude,
+				Target:         t.Name,
+				Source:         targetRemote(cfg, t),
+				Commit:         commits[t.Src],
+				Template:       templates.forTarget(r, t),
+				Relative:       cfg.RelativeLinks || t.RelativeLinks,
 			}
-			opts.Include = t.Include
-			opts.Exclude = t.Exclude
 			for _, dest := range t.Dest {
 				destPath := fsutil.ResolvePath(r.Path, dest)
 				if err := guard.CheckPath(destPath); err != nil {
-					a.Out.Err(err.Error(), nil)
-					return 1
-				}
-				if err := fsutil.SyncDir(t.Src, destPath, opts); err != nil {
-					a.Out.Err(fmt.Sprintf("%s %s: %v", r.Name, t.Name, err), nil)
-					return 1
+					return nil, err
 				}
-				results = append(results, syncResult{Repo: r.Name, Target: t.Name, Dest: destPath})
+				jobs = append(jobs, syncJob{repo: r, target: t, dest: destPath, opts: opts})
+			}
+		}
+	}
+	return jobs, nil
+}
+
+func (a App) syncTargets(ctx context.Context, cfg config.Config, targets []config.SyncTarget, mode string, force bool, dryRun bool, only []string, exclude []string) int {
+	jobs, err := syncJobs(ctx, cfg, targets, mode, force, dryRun, only, exclude)
+	if err != nil {
+		a.Out.Err(err.Error(), nil)
+		return 1
+	}
+	return a.runSyncJobs(jobs, false, false)
+}
+
+func (a App) runSyncJobs(jobs []syncJob, atomic bool, keepGoing bool) int {
+	results, failed, err := a.executeSyncJobs(jobs, atomic, keepGoing)
+	if err != nil {
+		return 1
+	}
+	return a.printSyncResults(results, failed)
+}
+
+func (a App) executeSyncJobs(jobs []syncJob, atomic bool, keepGoing bool) ([]syncResult, int, error) {
+	if atomic {
+		for _, job := range jobs {
+			opts := job.opts
+			opts.DryRun = true
+			if _, err := fsutil.SyncDir(job.target.Src, job.dest, opts); err != nil {
+				err = fmt.Errorf("%s %s: %v (nothing was changed)", job.repo.Name, job.target.Name, err)
+				a.Out.Err(err.Error(), nil)
+				return nil, 0, err
 			}
 		}
 	}
+	var journal *fsutil.Journal
+	if len(jobs) > 0 && !jobs[0].opts.DryRun {
+		root, err := journalRoot()
+		if err == nil {
+			journal, err = fsutil.NewJournal(root)
+		}
+		if err != nil {
+			err = fmt.Errorf("journal: %v", err)
+			a.Out.Err(err.Error(), nil)
+			return nil, 0, err
+		}
+	}
+	var results []syncResult
+	failed := 0
+	for _, job := range jobs {
+		job.opts.Journal = journal
+		report, err := fsutil.SyncDir(job.target.Src, job.dest, job.opts)
+		if err != nil && keepGoing {
+			failed++
+			results = append(results, syncResult{Repo: job.repo.Name, Target: job.target.Name, Dest: job.dest, Run: journal.ID(), Error: err.Error()})
+			continue
+		}
+		if err != nil {
+			err = fmt.Errorf("%s %s: %v", job.repo.Name, job.target.Name, err)
+			a.Out.Err(err.Error(), nil)
+			a.abortJournal(journal, atomic)
+			return results, failed, err
+		}
+		results = append(results, syncResult{
+			Repo:      job.repo.Name,
+			Target:    job.target.Name,
+			Dest:      job.dest,
+			Created:   report.Count(fsutil.ActionCreated),
+			Updated:   report.Count(fsutil.ActionUpdated),
+			Unchanged: report.Count(fsutil.ActionUnchanged),
+			Removed:   report.Count(fsutil.ActionRemoved),
+			Merged:    report.Count(fsutil.ActionMerged),
+			Kept:      report.Count(fsutil.ActionKept),
+			Conflicts: report.Count(fsutil.ActionConflict),
+			Changes:   report.Changes,
+			Run:       journal.ID(),
+		})
+	}
+	if failed > 0 {
+		a.abortJournal(journal, false)
+	} else {
+		a.commitJournal(journal)
+	}
+	if journal.Trivial() {
+		for i := range results {
+			results[i].Run = ""
+		}
+	}
+	return results, failed, nil
+}
+
+func (a App) printSyncResults(results []syncResult, failed int) int {
 	if a.Out.JSON {
 		a.Out.OK("skills sync", results)
-		return 0
+		return a.failureCode(failed, len(results), "targets")
 	}
 	for _, r := range results {
-		a.Out.OK(fmt.Sprintf("%s %s %s", r.Repo, r.Target, r.Dest), nil)
+		if r.Error != "" {
+			a.Out.Err(fmt.Sprintf("%s %s: %s", r.Repo, r.Target, r.Error), nil)
+			continue
+		}
+		line := fmt.Sprintf("%s %s %s created=%d updated=%d unchanged=%d removed=%d", r.Repo, r.Target, r.Dest, r.Created, r.Updated, r.Unchanged, r.Removed)
+		if r.Merged+r.Kept+r.Conflicts > 0 {
+			line += fmt.Sprintf(" merged=%d kept=%d conflicts=%d", r.Merged, r.Kept, r.Conflicts)
+		}
+		if r.Conflicts > 0 {
+			a.Out.Warn(line, nil)
+		} else {
+			a.Out.OK(line, nil)
+		}
+		for _, c := range r.Changes {
+			if c.Action != fsutil.ActionUnchanged {
+				a.Out.Raw(fmt.Sprintf("  %s %s", c.Action, c.Path))
+			}
+		}
+	}
+	return a.failureCode(failed, len(results), "targets")
+}
+
+func sourceCommit(ctx context.Context, runner executil.Runner, src string) string {
+	if _, err := os.Stat(src); err != nil {
+		return ""
+	}
+	commit, err := gitutil.HeadCommit(ctx, runner, src)
+	if err != nil {
+		return ""
 	}
-	return 0
+	return commit
 }
 
 func guardFromConfig(cfg config.Config) safety.Guard {
@@ -0,0 +1,78 @@
+package app
+
+import (
+	"context"
+
+	"github.com/TT-AIXion/github-kanri/internal/config"
+	"github.com/TT-AIXion/github-kanri/internal/executil"
+	"github.com/TT-AIXion/github-kanri/internal/fsutil"
+	"github.com/TT-AIXion/github-kanri/internal/gitutil"
+	"github.com/TT-AIXion/github-kanri/internal/repo"
+)
+
+type templateRepo struct {
+	Name          string
+	Path          string
+	Origin        string
+	Host          string
+	Owner         string
+	DefaultBranch string
+}
+
+type templateData struct {
+	Repo   templateRepo
+	Target string
+	Vars   map[string]string
+}
+
+type templateCache struct {
+	ctx    context.Context
+	runner executil.Runner
+	cfg    config.Config
+	facts  map[string]templateRepo
+}
+
+func newTemplateCache(ctx context.Context, cfg config.Config, runner executil.Runner) *templateCache {
+	return &templateCache{
+		ctx:    ctx,
+		runner: runner,
+		cfg:    cfg,
+		facts:  map[string]templateRepo{},
+	}
+}
+
+func (c *templateCache) forTarget(r repo.Repo, t config.SyncTarget) *fsutil.Template {
+	if !t.Template && !t.TemplateAll {
+		return nil
+	}
+	facts, ok := c.facts[r.Path]
+	if !ok {
+		facts = repoFacts(c.ctx, c.runner, r)
+		c.facts[r.Path] = facts
+	}
+	vars := map[string]string{}
+	for k, v := range c.cfg.TemplateVars {
+		vars[k] = v
+	}
+	for k, v := range t.Vars {
+		vars[k] = v
+	}
+	return &fsutil.Template{
+		All:  t.TemplateAll,
+		Data: templateData{Repo: facts, Target: t.Name, Vars: vars},
+	}
+}
+
+func repoFacts(ctx context.Context, runner executil.Runner, r repo.Repo) templateRepo {
+	facts := templateRepo{Name: r.Name, Path: r.Path}
+	facts.Origin, _ = gitutil.OriginURL(ctx, runner, r.Path)
+	if u, err := gitutil.ParseRemoteURL(facts.Origin); err == nil {
+		facts.Host = u.Host
+		facts.Owner = u.Owner
+	}
+	facts.DefaultBranch, _ = gitutil.DefaultBranch(ctx, runner, r.Path)
+	if facts.DefaultBranch == "" {
+		facts.DefaultBranch, _ = gitutil.CurrentBranch(ctx, runner, r.Path)
+	}
+	return facts
+}
@@ -1,23 +1,164 @@
 package app
 
+import (
+	"time"
+
+	"github.com/TT-AIXion/github-kanri/internal/fsutil"
+)
+
 type syncResult struct {
-	Repo   string `json:"repo"`
-	Target string `json:"target"`
-	Dest   string `json:"dest"`
+	Repo      string              `json:"repo"`
+	Target    string              `json:"target"`
+	Dest      string              `json:"dest"`
+	Created   int                 `json:"created"`
+	Updated   int                 `json:"updated"`
+	Unchanged int                 `json:"unchanged"`
+	Removed   int                 `json:"removed"`
+	Merged    int                 `json:"merged"`
+	Kept      int                 `json:"kept"`
+	Conflicts int                 `json:"conflicts"`
+	Changes   []fsutil.FileChange `json:"changes,omitempty"`
+	Run       string              `json:"run,omitempty"`
+	Error     string              `json:"error,omitempty"`
 }
 
 type diffResult struct {
-	Repo    string   `json:"repo"`
-	Target  string   `json:"target"`
-	Dest    string   `json:"dest"`
-	Added   []string `json:"added"`
-	Removed []string `json:"removed"`
-	Changed []string `json:"changed"`
+	Repo            string             `json:"repo"`
+	Target          string             `json:"target"`
+	Dest            string             `json:"dest"`
+	Added           []string           `json:"added"`
+	Removed         []string           `json:"removed"`
+	Changed         []string           `json:"changed"`
+	LocalModified   []string           `json:"localModified,omitempty"`
+	UpstreamChanged []string           `json:"upstreamChanged,omitempty"`
+	Managed         bool               `json:"managed"`
+	Revision        string             `json:"revision,omitempty"`
+	SyncedAt        *time.Time         `json:"syncedAt,omitempty"`
+	Dangling        []string           `json:"dangling,omitempty"`
+	WrongTarget     []string           `json:"wrongTarget,omitempty"`
+	NotLink         []string           `json:"notLink,omitempty"`
+	Files           []fsutil.FilePatch `json:"files,omitempty"`
+	Error           string             `json:"error,omitempty"`
 }
 
-type verifyResult struct {
+func (r diffResult) clean() bool {
+	return len(r.Added)+len(r.Removed)+len(r.Changed)+len(r.Dangling)+len(r.WrongTarget)+len(r.NotLink) == 0
+}
+
+type cleanResult struct {
 	Repo   string `json:"repo"`
 	Target string `json:"target"`
 	Dest   string `json:"dest"`
-	Match  bool   `json:"match"`
+	DryRun bool   `json:"dryRun,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type verifyResult struct {
+	Repo        string   `json:"repo"`
+	Target      string   `json:"target"`
+	Dest        string   `json:"dest"`
+	Match       bool     `json:"match"`
+	Dangling    []string `json:"dangling,omitempty"`
+	WrongTarget []string `json:"wrongTarget,omitempty"`
+	NotLink     []string `json:"notLink,omitempty"`
+	Error       string   `json:"error,omitempty"`
+}
+
+type watchEvent struct {
+	Time    time.Time    `json:"time"`
+	Target  string       `json:"target"`
+	Paths   []string     `json:"paths,omitempty"`
+	Full    bool         `json:"full,omitempty"`
+	Results []syncResult `json:"results"`
+	Error   string       `json:"error,omitempty"`
+}
+
+type promoteSkip struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+type promoteResult struct {
+	Target    string        `json:"target"`
+	Dest      string        `json:"dest"`
+	Files     []string      `json:"files"`
+	Skipped   []promoteSkip `json:"skipped,omitempty"`
+	Conflicts []promoteSkip `json:"conflicts,omitempty"`
+	Error     string        `json:"error,omitempty"`
+}
+
+type promoteCommit struct {
+	Dir    string   `json:"dir"`
+	Branch string   `json:"branch,omitempty"`
+	Commit string   `json:"commit,omitempty"`
+	Files  []string `json:"files"`
+}
+
+type promoteSummary struct {
+	Repo    string          `json:"repo"`
+	DryRun  bool            `json:"dryRun,omitempty"`
+	Results []promoteResult `json:"results"`
+	Commits []promoteCommit `json:"commits,omitempty"`
+	Run     string          `json:"run,omitempty"`
+}
+
+type sourceStatus struct {
+	Name     string   `json:"name"`
+	Dir      string   `json:"dir"`
+	Remote   string   `json:"remote,omitempty"`
+	Pin      string   `json:"pin,omitempty"`
+	Git      bool     `json:"git"`
+	Commit   string   `json:"commit,omitempty"`
+	Branch   string   `json:"branch,omitempty"`
+	Detached bool     `json:"detached,omitempty"`
+	Dirty    bool     `json:"dirty"`
+	Ahead    *int     `json:"ahead,omitempty"`
+	Behind   *int     `json:"behind,omitempty"`
+	Targets  []string `json:"targets"`
+	Error    string   `json:"error,omitempty"`
+}
+
+type driftCounts struct {
+	Added           int `json:"added"`
+	Removed         int `json:"removed"`
+	Changed         int `json:"changed"`
+	LocalModified   int `json:"localModified"`
+	UpstreamChanged int `json:"upstreamChanged"`
+	Dangling        int `json:"dangling"`
+	WrongTarget     int `json:"wrongTarget"`
+	NotLink         int `json:"notLink"`
+}
+
+type destinationStatus struct {
+	Repo     string      `json:"repo"`
+	Target   string      `json:"target"`
+	Dest     string      `json:"dest"`
+	Ref      string      `json:"ref,omitempty"`
+	State    string      `json:"state"`
+	Managed  bool        `json:"managed"`
+	Revision string      `json:"revision,omitempty"`
+	SyncedAt *time.Time  `json:"syncedAt,omitempty"`
+	Counts   driftCounts `json:"counts"`
+	Error    string      `json:"error,omitempty"`
+}
+
+type skillsStatus struct {
+	Sources      []sourceStatus      `json:"sources"`
+	Destinations []destinationStatus `json:"destinations"`
+}
+
+type pruneResult struct {
+	Repo     string     `json:"repo"`
+	Dest     string     `json:"dest,omitempty"`
+	Path     string     `json:"path,omitempty"`
+	Target   string     `json:"target,omitempty"`
+	Source   string     `json:"source,omitempty"`
+	SyncedAt *time.Time `json:"syncedAt,omitempty"`
+	Removed  []string   `json:"removed,omitempty"`
+	Kept     []string   `json:"kept,omitempty"`
+	DryRun   bool       `json:"dryRun,omitempty"`
+	Skipped  string     `json:"skipped,omitempty"`
+	Run      string     `json:"run,omitempty"`
+	Error    string     `json:"error,omitempty"`
+	root     string
 }
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -21,26 +23,47 @@ func ResetUserHomeDirForTest() {
 }
 
 type Config struct {
-	ProjectsRoot   string       `json:"projectsRoot"`
-	ReposRoot      string       `json:"reposRoot"`
-	SkillsRoot     string       `json:"skillsRoot"`
-	SkillsRemote   string       `json:"skillsRemote,omitempty"`
-	SkillTargets   []string     `json:"skillTargets"`
-	SyncTargets    []SyncTarget `json:"syncTargets"`
-	AllowCommands  []string     `json:"allowCommands"`
-	DenyCommands   []string     `json:"denyCommands"`
-	AllowPaths     []string     `json:"allowPaths,omitempty"`
-	DenyPaths      []string     `json:"denyPaths,omitempty"`
-	SyncMode       string       `json:"syncMode"`
-	ConflictPolicy string       `json:"conflictPolicy"`
+	ProjectsRoot   string              `json:"projectsRoot"`
+	ReposRoot      string              `json:"reposRoot"`
+	SkillsRoot     string              `json:"skillsRoot"`
+	SkillsRemote   string              `json:"skillsRemote,omitempty"`
+	SkillTargets   []string            `json:"skillTargets"`
+	SyncTargets    []SyncTarget        `json:"syncTargets"`
+	AllowCommands  []string            `json:"allowCommands"`
+	DenyCommands   []string            `json:"denyCommands"`
+	AllowPaths     []string            `json:"allowPaths,omitempty"`
+	DenyPaths      []string            `json:"denyPaths,omitempty"`
+	SyncMode       string              `json:"syncMode"`
+	ConflictPolicy string              `json:"conflictPolicy"`
+	RepoTags       map[string][]string `json:"repoTags,omitempty"`
+	TemplateVars   map[string]string   `json:"templateVars,omitempty"`
+	RelativeLinks  bool                `json:"relativeLinks,omitempty"`
 }
 
 type SyncTarget struct {
-	Name    string   `json:"name"`
-	Src     string   `json:"src"`
-	Dest    []string `json:"dest"`
-	Include []string `json:"include"`
-	Exclude []string `json:"exclude"`
+	Name           string            `json:"name"`
+	Src            string            `json:"src"`
+	Dest           []string          `json:"dest"`
+	Include        []string          `json:"include"`
+	Exclude        []string          `json:"exclude"`
+	Mode           string            `json:"mode,omitempty"`
+	ConflictPolicy string            `json:"conflictPolicy,omitempty"`
+	Repos          []string          `json:"repos,omitempty"`
+	ExcludeRepos   []string          `json:"excludeRepos,omitempty"`
+	Enabled        *bool             `json:"enabled,omitempty"`
+	Template       bool              `json:"template,omitempty"`
+	TemplateAll    bool              `json:"templateAll,omitempty"`
+	Vars           map[string]string `json:"vars,omitempty"`
+	RelativeLinks  bool              `json:"relativeLinks,omitempty"`
+	Remote         string            `json:"remote,omitempty"`
+	Ref            string            `json:"ref,omitempty"`
+	Checkout       string            `json:"-"`
+}
+
+const TagPrefix = "tag:"
+
+func (t SyncTarget) IsEnabled() bool {
+	return t.Enabled == nil || *t.Enabled
 }
 
 func DefaultConfigPath() (string, error) {
@@ -51,6 +74,48 @@ func DefaultConfigPath() (string, error) {
 	return filepath.Join(home, ".config", "github-kanri", "config.json"), nil
 }
 
+func DefaultStateDir() (string, error) {
+	home, err := userHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "state", "github-kanri"), nil
+}
+
+func DefaultCacheDir() (string, error) {
+	home, err := userHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".cache", "github-kanri"), nil
+}
+
+func SourceCheckoutDir(remote, ref string) (string, error) {
+	cache, err := DefaultCacheDir()
+	if err != nil {
+		return "", err
+	}
+	remote = strings.TrimSpace(remote)
+	sum := sha256.Sum256([]byte(remote))
+	name := strings.TrimSuffix(filepath.Base(strings.TrimRight(remote, "/")), ".git")
+	name = sanitizeSourceName(name) + "-" + hex.EncodeToString(sum[:4])
+	if ref = strings.TrimSpace(ref); ref != "" {
+		name += "@" + sanitizeSourceName(ref)
+	}
+	return filepath.Join(cache, "sources", name), nil
+}
+
+func sanitizeSourceName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '-'
+		}
+	}, s)
+}
+
 func DefaultConfig() (Config, error) {
 	projects := "~/Projects"
 	repos := filepath.Join(projects, "repos")
@@ -79,15 +144,24 @@ func DefaultConfig() (Config, error) {
 			"git commit*",
 			"git status*",
 			"git log*",
+			"git grep*",
 			"git rev-parse*",
+			"git rev-list*",
+			"git describe*",
 			"git config*",
 			"git remote*",
 			"git clone*",
 			"git fetch*",
 			"git pull*",
 			"git checkout*",
+			"git branch -D*",
+			"git gc*",
+			"git clean*",
 			"git push*",
 			"code *",
+			"open https://*",
+			"xdg-open https://*",
+			"rundll32 url.dll,FileProtocolHandler https://*",
 		},
 		DenyCommands: []string{
 			"rm -rf*",
@@ -234,7 +308,13 @@ func ExpandConfigPaths(cfg Config) (Config, error) {
 		}
 	}
 	for i, t := range cfg.SyncTargets {
-		if t.Src, err = ExpandPath(t.Src); err != nil {
+		if strings.TrimSpace(t.Remote) != "" {
+			if t.Checkout, err = SourceCheckoutDir(t.Remote, t.Ref); err != nil {
+				return Config{}, err
+			}
+			t.Src = filepath.Join(t.Checkout, filepath.FromSlash(strings.TrimSpace(t.Src)))
+			t.Exclude = append(append([]string{}, t.Exclude...), ".git/**")
+		} else if t.Src, err = ExpandPath(t.Src); err != nil {
 			return Config{}, err
 		}
 		for j, d := range t.Dest {
@@ -258,22 +338,68 @@ func Validate(cfg Config) []error {
 	if strings.TrimSpace(cfg.SkillsRoot) == "" {
 		errs = append(errs, fmt.Errorf("skillsRoot is required"))
 	}
-	if cfg.SyncMode != "copy" && cfg.SyncMode != "mirror" && cfg.SyncMode != "link" {
-		errs = append(errs, fmt.Errorf("syncMode must be copy|mirror|link"))
+	if !validSyncMode(cfg.SyncMode) {
+		errs = append(errs, fmt.Errorf("syncMode must be copy|mirror|link|link-dir"))
 	}
-	if cfg.ConflictPolicy != "fail" && cfg.ConflictPolicy != "overwrite" {
-		errs = append(errs, fmt.Errorf("conflictPolicy must be fail|overwrite"))
+	if !validConflictPolicy(cfg.ConflictPolicy) {
+		errs = append(errs, fmt.Errorf("conflictPolicy must be fail|overwrite|merge|keep-local"))
 	}
 	for i, t := range cfg.SyncTargets {
 		if strings.TrimSpace(t.Name) == "" {
 			errs = append(errs, fmt.Errorf("syncTargets[%d].name is required", i))
 		}
-		if strings.TrimSpace(t.Src) == "" {
+		switch {
+		case strings.TrimSpace(t.Remote) != "":
+			if !insideCheckout(t) {
+				errs = append(errs, fmt.Errorf("syncTargets[%d].src must be relative to the remote checkout", i))
+			}
+		case strings.TrimSpace(t.Ref) != "":
+			errs = append(errs, fmt.Errorf("syncTargets[%d].ref requires remote", i))
+		case strings.TrimSpace(t.Src) == "":
 			errs = append(errs, fmt.Errorf("syncTargets[%d].src is required", i))
 		}
 		if len(t.Dest) == 0 {
 			errs = append(errs, fmt.Errorf("syncTargets[%d].dest is required", i))
 		}
+		if t.Mode != "" && !validSyncMode(t.Mode) {
+			errs = append(errs, fmt.Errorf("syncTargets[%d].mode must be copy|mirror|link|link-dir", i))
+		}
+		if t.ConflictPolicy != "" && !validConflictPolicy(t.ConflictPolicy) {
+			errs = append(errs, fmt.Errorf("syncTargets[%d].conflictPolicy must be fail|overwrite|merge|keep-local", i))
+		}
+		for _, sel := range append(append([]string{}, t.Repos...), t.ExcludeRepos...) {
+			tag, ok := strings.CutPrefix(sel, TagPrefix)
+			if !ok {
+				continue
+			}
+			if _, found := cfg.RepoTags[tag]; !found {
+				errs = append(errs, fmt.Errorf("syncTargets[%d] references unknown tag: %s", i, tag))
+			}
+		}
 	}
 	return errs
 }
+
+func insideCheckout(t SyncTarget) bool {
+	src := filepath.FromSlash(strings.TrimSpace(t.Src))
+	if t.Checkout != "" {
+		rel, err := filepath.Rel(t.Checkout, src)
+		if err != nil {
+			return false
+		}
+		src = rel
+	}
+	if filepath.IsAbs(src) || strings.HasPrefix(src, "~") {
+		return false
+	}
+	clean := filepath.Clean(src)
+	return clean != ".." && !strings.HasPrefix(clean, ".."+string(filepath.Separator))
+}
+
+func validSyncMode(mode string) bool {
+	return mode == "copy" || mode == "mirror" || mode == "link" || mode == "link-dir"
+}
+
+func validConflictPolicy(policy string) bool {
+	return policy == "fail" || policy == "overwrite" || policy == "merge" || policy == "keep-local"
+}
@@ -30,6 +30,20 @@ func TestDefaultConfigPathError(t *testing.T) {
 	}
 }
 
+func TestDefaultStateDir(t *testing.T) {
+	tmp := t.TempDir()
+	SetUserHomeDirForTest(func() (string, error) { return tmp, nil })
+	defer ResetUserHomeDirForTest()
+	dir, err := DefaultStateDir()
+	if err != nil || dir != filepath.Join(tmp, ".local", "state", "github-kanri") {
+		t.Fatalf("unexpected state dir: %s %v", dir, err)
+	}
+	SetUserHomeDirForTest(func() (string, error) { return "", os.ErrPermission })
+	if _, err := DefaultStateDir(); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
 func TestDefaultConfig(t *testing.T) {
 	cfg, err := DefaultConfig()
 	if err != nil {
@@ -313,3 +327,131 @@ func TestValidateMissingTargets(t *testing.T) {
 		t.Fatalf("expected errors")
 	}
 }
+
+func TestValidateConflictPolicies(t *testing.T) {
+	for _, policy := range []string{"fail", "overwrite", "merge", "keep-local"} {
+		cfg := Config{ProjectsRoot: "x", ReposRoot: "y", SkillsRoot: "z", SyncMode: "copy", ConflictPolicy: policy}
+		if errs := Validate(cfg); len(errs) != 0 {
+			t.Fatalf("%s: unexpected errors: %v", policy, errs)
+		}
+	}
+}
+
+func TestValidateSyncModes(t *testing.T) {
+	for _, mode := range []string{"copy", "mirror", "link", "link-dir"} {
+		cfg := Config{ProjectsRoot: "x", ReposRoot: "y", SkillsRoot: "z", SyncMode: mode, ConflictPolicy: "fail"}
+		cfg.SyncTargets = []SyncTarget{{Name: "s", Src: "s", Dest: []string{"d"}, Mode: mode, RelativeLinks: true}}
+		if errs := Validate(cfg); len(errs) != 0 {
+			t.Fatalf("%s: unexpected errors: %v", mode, errs)
+		}
+	}
+}
+
+func TestValidateTargetOverrides(t *testing.T) {
+	base := Config{ProjectsRoot: "x", ReposRoot: "y", SkillsRoot: "z", SyncMode: "copy", ConflictPolicy: "fail", RepoTags: map[string][]string{"service": {"api-*"}}}
+	cfg := base
+	cfg.SyncTargets = []SyncTarget{{Name: "ci", Src: "s", Dest: []string{"d"}, Mode: "mirror", ConflictPolicy: "overwrite", Repos: []string{"tag:service"}, ExcludeRepos: []string{"legacy-*"}}}
+	if errs := Validate(cfg); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	cfg = base
+	cfg.SyncTargets = []SyncTarget{{Name: "ci", Src: "s", Dest: []string{"d"}, Mode: "bad", ConflictPolicy: "bad", ExcludeRepos: []string{"tag:missing"}}}
+	if errs := Validate(cfg); len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got %v", errs)
+	}
+}
+
+func TestSyncTargetIsEnabled(t *testing.T) {
+	off := false
+	on := true
+	if !(SyncTarget{}).IsEnabled() || !(SyncTarget{Enabled: &on}).IsEnabled() || (SyncTarget{Enabled: &off}).IsEnabled() {
+		t.Fatalf("unexpected enabled state")
+	}
+}
+
+func TestRemoteTargetSources(t *testing.T) {
+	tmp := t.TempDir()
+	SetUserHomeDirForTest(func() (string, error) { return tmp, nil })
+	defer ResetUserHomeDirForTest()
+	pinned, err := SourceCheckoutDir("git@github.com:acme/skills.git", "release/v1")
+	if err != nil {
+		t.Fatalf("checkout dir: %v", err)
+	}
+	head, _ := SourceCheckoutDir("git@github.com:acme/skills.git", "")
+	other, _ := SourceCheckoutDir("git@github.com:other/skills.git", "")
+	if filepath.Dir(pinned) != filepath.Join(tmp, ".cache", "github-kanri", "sources") || !strings.HasPrefix(filepath.Base(pinned), "skills-") || !strings.HasSuffix(pinned, "@release-v1") {
+		t.Fatalf("unexpected checkout dir: %s", pinned)
+	}
+	if head == pinned || head == other {
+		t.Fatalf("expected distinct checkouts: %s %s %s", pinned, head, other)
+	}
+
+	cfg := ApplyDefaults(Config{SyncTargets: []SyncTarget{
+		{Name: "pinned", Remote: "git@github.com:acme/skills.git", Ref: "release/v1", Src: "skills", Dest: []string{".codex/skills"}},
+		{Name: "bad", Remote: "git@github.com:acme/skills.git", Src: "../escape", Dest: []string{".x"}},
+		{Name: "ref", Ref: "v1", Src: "/src", Dest: []string{".y"}},
+	}})
+	expanded, err := ExpandConfigPaths(cfg)
+	if err != nil {
+		t.Fatalf("expand: %v", err)
+	}
+	got := expanded.SyncTargets[0]
+	if got.Checkout != pinned || got.Src != filepath.Join(pinned, "skills") || len(got.Exclude) != 1 || got.Exclude[0] != ".git/**" {
+		t.Fatalf("unexpected target: %+v", got)
+	}
+	errs := Validate(expanded)
+	if len(errs) != 2 || !strings.Contains(errs[0].Error(), "syncTargets[1].src must be relative") || !strings.Contains(errs[1].Error(), "syncTargets[2].ref requires remote") {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if errs := Validate(cfg); len(errs) != 2 {
+		t.Fatalf("expected raw config validation: %v", errs)
+	}
+
+	SetUserHomeDirForTest(func() (string, error) { return "", os.ErrPermission })
+	if _, err := SourceCheckoutDir("x", ""); err == nil {
+		t.Fatalf("expected home error")
+	}
+	if _, err := ExpandConfigPaths(Config{SyncTargets: []SyncTarget{{Remote: "x"}}}); err == nil {
+		t.Fatalf("expected expand error")
+	}
+}
+
+func TestLoadRepoConfig(t *testing.T) {
+	dir := t.TempDir()
+	if _, ok, err := LoadRepoConfig(dir); ok || err != nil {
+		t.Fatalf("expected missing repo config: %v %v", ok, err)
+	}
+	path := filepath.Join(dir, RepoConfigName)
+	_ = os.WriteFile(path, []byte(`{"targets": ["ci"], "skills": ["review"], "overrides": {"skills": {"dest": ["docs/skills"], "excludeSkills": ["old"]}}}`), 0o644)
+	rc, ok, err := LoadRepoConfig(dir)
+	if err != nil || !ok || rc.Targets[0] != "ci" || rc.Overrides["skills"].Dest[0] != "docs/skills" || rc.Overrides["skills"].ExcludeSkills[0] != "old" {
+		t.Fatalf("unexpected repo config: %+v %v %v", rc, ok, err)
+	}
+	cases := map[string]string{
+		`{"target": ["ci"]}`:                              "unknown field",
+		`{"targets": ["ci"], "excludeTargets": ["ci"]}`:   "both in targets and excludeTargets",
+		`{"skills": ["a/b"]}`:                             "invalid skill name",
+		`{"overrides": {"x": {"excludeSkills": [".."]}}}`: "overrides.x: invalid skill name",
+		`{"overrides": {"x": {"dest": ["/abs"]}}}`:        "dest must be a path inside the repo",
+		`{"overrides": {"x": {"dest": ["a/../../b"]}}}`:   "dest must be a path inside the repo",
+		`{"overrides": {"x": {"dest": ["~/skills"]}}}`:    "dest must be a path inside the repo",
+		`{"overrides": {"x": {"dest": ["."]}}}`:           "dest must be a path inside the repo",
+		`{"overrides": {"x": {"dest": ["../x"]}}}`:        "dest must be a path inside the repo",
+		`{"overrides": {"x": {"dest": ["a/.."]}}}`:        "dest must be a path inside the repo",
+		`{"overrides": {"x": {"dest": [".git/hooks"]}}}`:  "dest must not be inside .git",
+		`{"overrides": {"x": {"dest": ["a/../.GIT"]}}}`:   "dest must not be inside .git",
+		`{"overrides": {"x": {"dest": ["sub/.git/x"]}}}`:  "dest must not be inside .git",
+		`{"skills": [1]}`:                                 "invalid JSON",
+	}
+	for data, want := range cases {
+		_ = os.WriteFile(path, []byte(data), 0o644)
+		if _, _, err := LoadRepoConfig(dir); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("%s: expected %q, got %v", data, want, err)
+		}
+	}
+	_ = os.Remove(path)
+	_ = os.Mkdir(path, 0o755)
+	if _, _, err := LoadRepoConfig(dir); err == nil {
+		t.Fatalf("expected read error")
+	}
+}
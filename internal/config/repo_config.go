@@ -0,0 +1,113 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const RepoConfigName = ".gkn.json"
+
+type RepoConfig struct {
+	Targets        []string                      `json:"targets,omitempty"`
+	ExcludeTargets []string                      `json:"excludeTargets,omitempty"`
+	Skills         []string                      `json:"skills,omitempty"`
+	ExcludeSkills  []string                      `json:"excludeSkills,omitempty"`
+	Include        []string                      `json:"include,omitempty"`
+	Exclude        []string                      `json:"exclude,omitempty"`
+	Overrides      map[string]RepoTargetOverride `json:"overrides,omitempty"`
+}
+
+type RepoTargetOverride struct {
+	Dest          []string `json:"dest,omitempty"`
+	Skills        []string `json:"skills,omitempty"`
+	ExcludeSkills []string `json:"excludeSkills,omitempty"`
+	Include       []string `json:"include,omitempty"`
+	Exclude       []string `json:"exclude,omitempty"`
+}
+
+func LoadRepoConfig(repoPath string) (RepoConfig, bool, error) {
+	path := filepath.Join(repoPath, RepoConfigName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return RepoConfig{}, false, nil
+		}
+		return RepoConfig{}, false, err
+	}
+	var rc RepoConfig
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&rc); err != nil {
+		return RepoConfig{}, false, formatJSONError(path, data, err)
+	}
+	if err := ValidateRepoConfig(rc); err != nil {
+		return RepoConfig{}, false, fmt.Errorf("%s: %w", path, err)
+	}
+	return rc, true, nil
+}
+
+func ValidateRepoConfig(rc RepoConfig) error {
+	for _, name := range rc.Targets {
+		for _, excluded := range rc.ExcludeTargets {
+			if name == excluded {
+				return fmt.Errorf("target %s is both in targets and excludeTargets", name)
+			}
+		}
+	}
+	if err := validateSkillNames(rc.Skills, rc.ExcludeSkills); err != nil {
+		return err
+	}
+	for name, o := range rc.Overrides {
+		if err := validateSkillNames(o.Skills, o.ExcludeSkills); err != nil {
+			return fmt.Errorf("overrides.%s: %w", name, err)
+		}
+		for _, dest := range o.Dest {
+			if !repoRelativeDest(dest) {
+				return fmt.Errorf("overrides.%s: dest must be a path inside the repo: %q", name, dest)
+			}
+			if insideGitDir(dest) {
+				return fmt.Errorf("overrides.%s: dest must not be inside .git: %q", name, dest)
+			}
+		}
+	}
+	return nil
+}
+
+func repoRelativeDest(dest string) bool {
+	trimmed := strings.TrimSpace(dest)
+	if trimmed == "" || strings.HasPrefix(trimmed, "~") {
+		return false
+	}
+	native := filepath.FromSlash(trimmed)
+	if filepath.IsAbs(native) || filepath.VolumeName(native) != "" || strings.HasPrefix(trimmed, "/") || strings.HasPrefix(trimmed, `\`) {
+		return false
+	}
+	clean := filepath.Clean(native)
+	return clean != "." && clean != ".." && !strings.HasPrefix(clean, ".."+string(filepath.Separator))
+}
+
+func insideGitDir(dest string) bool {
+	clean := filepath.ToSlash(filepath.Clean(filepath.FromSlash(strings.TrimSpace(dest))))
+	for _, part := range strings.Split(clean, "/") {
+		if strings.EqualFold(part, ".git") {
+			return true
+		}
+	}
+	return false
+}
+
+func validateSkillNames(lists ...[]string) error {
+	for _, list := range lists {
+		for _, name := range list {
+			if strings.TrimSpace(name) == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+				return fmt.Errorf("invalid skill name %q (use the top-level skill directory name)", name)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package fsutil
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func SourceDigest(srcRoot string, include []string, exclude []string, tmpl *Template) (string, error) {
+	files, err := ListFiles(srcRoot, include, exclude)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	for _, f := range sourceFiles(srcRoot, files, tmpl) {
+		hash, _, err := f.digest(tmpl)
+		if err != nil {
+			return "", err
+		}
+		_, _ = fmt.Fprintf(h, "%s\x00%s\n", f.Dest, hash)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func DestDigest(destRoot string) (string, error) {
+	h := sha256.New()
+	files, err := ListFiles(destRoot, nil, nil)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	files = append(files, ManifestName)
+	for _, rel := range files {
+		path := filepath.Join(destRoot, filepath.FromSlash(rel))
+		info, err := osLstat(path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return "", err
+		}
+		var state string
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, err := osReadlink(path)
+			if err != nil {
+				return "", err
+			}
+			state = linkHashPrefix + target
+		} else if state, err = FileHash(path); err != nil {
+			return "", err
+		}
+		_, _ = fmt.Fprintf(h, "%s\x00%s\n", rel, state)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (t *Template) Renders(srcRoot, destRel string) bool {
+	return t != nil && sourceFor(srcRoot, destRel, t).Render
+}
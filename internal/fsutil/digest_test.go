@@ -0,0 +1,99 @@
+package fsutil
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSourceDigest(t *testing.T) {
+	src, _ := setupSyncDirs(t)
+	first, err := SourceDigest(src, nil, nil, nil)
+	if err != nil || first == "" {
+		t.Fatalf("digest: %v", err)
+	}
+	if again, _ := SourceDigest(src, nil, nil, nil); again != first {
+		t.Fatalf("digest should be stable")
+	}
+	if only, _ := SourceDigest(src, []string{"a.txt"}, nil, nil); only == first {
+		t.Fatalf("include should change digest")
+	}
+	_ = os.WriteFile(filepath.Join(src, "b.txt"), []byte("B"), 0o644)
+	if changed, _ := SourceDigest(src, nil, nil, nil); changed == first {
+		t.Fatalf("content change should change digest")
+	}
+	_ = os.WriteFile(filepath.Join(src, "c.tmpl"), []byte("{{ .X }}"), 0o644)
+	one, err := SourceDigest(src, nil, nil, &Template{Data: map[string]string{"X": "1"}})
+	if err != nil {
+		t.Fatalf("digest: %v", err)
+	}
+	if two, _ := SourceDigest(src, nil, nil, &Template{Data: map[string]string{"X": "2"}}); two == one {
+		t.Fatalf("template data should change digest")
+	}
+	if _, err := SourceDigest(src, nil, nil, &Template{Data: map[string]string{}}); err == nil {
+		t.Fatalf("expected render error")
+	}
+	if _, err := SourceDigest(filepath.Join(src, "missing"), nil, nil, nil); err == nil {
+		t.Fatalf("expected missing source error")
+	}
+}
+
+func TestDestDigest(t *testing.T) {
+	src, dst := setupSyncDirs(t)
+	empty, err := DestDigest(dst)
+	if err != nil {
+		t.Fatalf("digest: %v", err)
+	}
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeLink, ConflictPolicy: ConflictFail}); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	linked, err := DestDigest(dst)
+	if err != nil || linked == empty {
+		t.Fatalf("expected digest change: %v", err)
+	}
+	_ = os.WriteFile(filepath.Join(src, "a.txt"), []byte("upstream"), 0o644)
+	if same, _ := DestDigest(dst); same != linked {
+		t.Fatalf("link digest should not follow targets")
+	}
+	_ = os.Remove(filepath.Join(dst, "a.txt"))
+	_ = os.Symlink(filepath.Join(src, "gone"), filepath.Join(dst, "a.txt"))
+	if dangling, err := DestDigest(dst); err != nil || dangling == linked {
+		t.Fatalf("expected dangling link digest: %v", err)
+	}
+
+	t.Run("lstat", func(t *testing.T) {
+		h := snapshotHooks()
+		defer h.restore()
+		osLstat = func(string) (os.FileInfo, error) { return nil, errors.New("lstat") }
+		if _, err := DestDigest(dst); err == nil {
+			t.Fatalf("expected lstat error")
+		}
+	})
+	t.Run("readlink", func(t *testing.T) {
+		h := snapshotHooks()
+		defer h.restore()
+		osReadlink = func(string) (string, error) { return "", errors.New("readlink") }
+		if _, err := DestDigest(dst); err == nil {
+			t.Fatalf("expected readlink error")
+		}
+	})
+	t.Run("walk", func(t *testing.T) {
+		h := snapshotHooks()
+		defer h.restore()
+		walkDir = func(string, fs.WalkDirFunc) error { return errors.New("walk") }
+		if _, err := DestDigest(dst); err == nil {
+			t.Fatalf("expected walk error")
+		}
+	})
+}
+
+func TestTemplateRenders(t *testing.T) {
+	src, _ := setupSyncDirs(t)
+	_ = os.WriteFile(filepath.Join(src, "c.md.tmpl"), []byte("x"), 0o644)
+	var none *Template
+	if none.Renders(src, "c.md") || !(&Template{}).Renders(src, "c.md") || (&Template{}).Renders(src, "a.txt") || !(&Template{All: true}).Renders(src, "a.txt") {
+		t.Fatalf("unexpected render detection")
+	}
+}
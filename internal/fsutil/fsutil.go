@@ -11,6 +11,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"syscall"
 
 	"github.com/TT-AIXion/github-kanri/internal/match"
 )
@@ -20,14 +21,17 @@ type SyncMode string
 type ConflictPolicy string
 
 const (
-	ModeCopy   SyncMode = "copy"
-	ModeMirror SyncMode = "mirror"
-	ModeLink   SyncMode = "link"
+	ModeCopy    SyncMode = "copy"
+	ModeMirror  SyncMode = "mirror"
+	ModeLink    SyncMode = "link"
+	ModeLinkDir SyncMode = "link-dir"
 )
 
 const (
 	ConflictFail      ConflictPolicy = "fail"
 	ConflictOverwrite ConflictPolicy = "overwrite"
+	ConflictMerge     ConflictPolicy = "merge"
+	ConflictKeepLocal ConflictPolicy = "keep-local"
 )
 
 var (
@@ -38,6 +42,9 @@ var (
 	osSymlink   = os.Symlink
 	osRemoveAll = os.RemoveAll
 	osLstat     = os.Lstat
+	osReadlink  = os.Readlink
+	osRename    = os.Rename
+	osChtimes   = os.Chtimes
 	walkDir     = filepath.WalkDir
 	relPath     = filepath.Rel
 	ioCopy      = io.Copy
@@ -50,6 +57,13 @@ type SyncOptions struct {
 	DryRun         bool
 	Include        []string
 	Exclude        []string
+	Target         string
+	Source         string
+	Commit         string
+	Template       *Template
+	Relative       bool
+	Journal        *Journal
+	Paths          []string
 }
 
 func IsGitRepo(path string) bool {
@@ -58,10 +72,19 @@ func IsGitRepo(path string) bool {
 }
 
 func ListGitRepos(root string) ([]string, error) {
-	var repos []string
+	repos, _, err := FindGitRepos(root)
+	return repos, err
+}
+
+func FindGitRepos(root string) ([]string, []string, error) {
+	var repos, denied []string
 	seen := make(map[string]struct{})
 	err := walkDir(root, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
+			if path != root && errors.Is(err, fs.ErrPermission) {
+				denied = append(denied, path)
+				return fs.SkipDir
+			}
 			return err
 		}
 		if !d.IsDir() {
@@ -79,10 +102,10 @@ func ListGitRepos(root string) ([]string, error) {
 		return nil
 	})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	sort.Strings(repos)
-	return repos, nil
+	return repos, denied, nil
 }
 
 func FilterNames(names []string, only []string, exclude []string) []string {
@@ -117,12 +140,12 @@ func ListFiles(root string, include []string, exclude []string) ([]string, error
 		}
 		rel = filepath.ToSlash(rel)
 		if d.IsDir() {
-			if match.Any(exclude, rel) || match.Any(exclude, rel+"/") {
+			if rel == BaseDirName || match.Any(exclude, rel) || match.Any(exclude, rel+"/") {
 				return fs.SkipDir
 			}
 			return nil
 		}
-		if match.Any(exclude, rel) {
+		if isSyncMetadata(rel) || match.Any(exclude, rel) {
 			return nil
 		}
 		if !match.Any(include, rel) {
@@ -163,17 +186,33 @@ func CopyFile(src, dst string, dryRun bool) error {
 	if err != nil {
 		return err
 	}
-	dstFile, err := osCreate(dst)
+	tmp := tempPath(dst)
+	dstFile, err := osCreate(tmp)
 	if err != nil {
 		return err
 	}
-	defer func() {
-		_ = dstFile.Close()
-	}()
 	if _, err := ioCopy(dstFile, srcFile); err != nil {
+		_ = dstFile.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := dstFile.Close(); err != nil {
+		_ = os.Remove(tmp)
 		return err
 	}
-	return osChmod(dst, info.Mode())
+	if err := osChmod(tmp, info.Mode()); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := osChtimes(tmp, info.ModTime(), info.ModTime()); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := osRename(tmp, dst); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
 }
 
 func LinkFile(src, dst string, dryRun bool) error {
@@ -193,6 +232,21 @@ func RemovePath(path string, dryRun bool) error {
 	return osRemoveAll(path)
 }
 
+func MoveDir(src, dst string) error {
+	err := osRename(src, dst)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	if err := copyTree(src, dst); err != nil {
+		_ = osRemoveAll(dst)
+		return fmt.Errorf("move across filesystems: %w", err)
+	}
+	if err := osRemoveAll(src); err != nil {
+		return fmt.Errorf("moved to %s but could not remove %s: %w", dst, src, err)
+	}
+	return nil
+}
+
 func FileHash(path string) (string, error) {
 	file, err := osOpen(path)
 	if err != nil {
@@ -208,119 +262,54 @@ func FileHash(path string) (string, error) {
 	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
-func SyncDir(srcRoot, destRoot string, opts SyncOptions) error {
-	files, err := ListFiles(srcRoot, opts.Include, opts.Exclude)
-	if err != nil {
-		return err
-	}
-	srcRoot = filepath.Clean(srcRoot)
-	destRoot = filepath.Clean(destRoot)
-
-	if opts.Mode == ModeLink {
-		for _, rel := range files {
-			src := filepath.Join(srcRoot, filepath.FromSlash(rel))
-			dst := filepath.Join(destRoot, filepath.FromSlash(rel))
-			if err := applyConflictPolicy(dst, opts); err != nil {
-				return err
-			}
-			if err := LinkFile(src, dst, opts.DryRun); err != nil {
-				return err
-			}
-		}
-		return nil
-	}
-
-	for _, rel := range files {
-		src := filepath.Join(srcRoot, filepath.FromSlash(rel))
-		dst := filepath.Join(destRoot, filepath.FromSlash(rel))
-		if err := applyConflictPolicy(dst, opts); err != nil {
-			return err
-		}
-		if err := CopyFile(src, dst, opts.DryRun); err != nil {
-			return err
-		}
-	}
-	if opts.Mode == ModeMirror {
-		return mirrorCleanup(srcRoot, destRoot, files, opts)
-	}
-	return nil
-}
-
-func applyConflictPolicy(dst string, opts SyncOptions) error {
-	if _, err := osLstat(dst); err != nil {
-		return nil
-	}
-	if opts.ConflictPolicy == ConflictOverwrite {
-		return RemovePath(dst, opts.DryRun)
-	}
-	return fmt.Errorf("conflict detected: %s", dst)
-}
-
-func mirrorCleanup(srcRoot, destRoot string, keep []string, opts SyncOptions) error {
-	if opts.ConflictPolicy != ConflictOverwrite {
-		return errors.New("mirror requires overwrite policy")
-	}
-	keepSet := make(map[string]struct{}, len(keep))
-	for _, rel := range keep {
-		keepSet[filepath.ToSlash(rel)] = struct{}{}
-	}
-	return walkDir(destRoot, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-		if path == destRoot {
-			return nil
-		}
-		rel, err := relPath(destRoot, path)
-		if err != nil {
-			return err
-		}
-		rel = filepath.ToSlash(rel)
-		if d.IsDir() {
-			return nil
-		}
-		if _, ok := keepSet[rel]; !ok {
-			return RemovePath(path, opts.DryRun)
-		}
-		return nil
-	})
+func DiffDir(srcRoot, destRoot string, include []string, exclude []string) (added, removed, changed []string, err error) {
+	return DiffDirTemplate(srcRoot, destRoot, include, exclude, nil)
 }
 
-func DiffDir(srcRoot, destRoot string, include []string, exclude []string) (added, removed, changed []string, err error) {
+func DiffDirTemplate(srcRoot, destRoot string, include []string, exclude []string, tmpl *Template) (added, removed, changed []string, err error) {
 	srcFiles, err := ListFiles(srcRoot, include, exclude)
 	if err != nil {
 		return nil, nil, nil, err
 	}
+	sources := sourceFiles(srcRoot, srcFiles, tmpl)
 	destFiles, err := ListFiles(destRoot, include, exclude)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
-			return srcFiles, nil, nil, nil
+			for _, f := range sources {
+				added = append(added, f.Dest)
+			}
+			sort.Strings(added)
+			return added, nil, nil, nil
 		}
 		return nil, nil, nil, err
 	}
-	srcSet := make(map[string]struct{}, len(srcFiles))
+	srcSet := make(map[string]struct{}, len(sources))
 	destSet := make(map[string]struct{}, len(destFiles))
-	for _, f := range srcFiles {
-		srcSet[f] = struct{}{}
+	for _, f := range sources {
+		srcSet[f.Dest] = struct{}{}
 	}
 	for _, f := range destFiles {
 		destSet[f] = struct{}{}
 	}
-	for _, f := range srcFiles {
-		if _, ok := destSet[f]; !ok {
-			added = append(added, f)
+	for _, f := range sources {
+		if _, ok := destSet[f.Dest]; !ok {
+			added = append(added, f.Dest)
 			continue
 		}
-		srcHash, err := FileHash(filepath.Join(srcRoot, filepath.FromSlash(f)))
+		srcHash, _, err := f.digest(tmpl)
 		if err != nil {
 			return nil, nil, nil, err
 		}
-		destHash, err := FileHash(filepath.Join(destRoot, filepath.FromSlash(f)))
+		destHash, err := FileHash(filepath.Join(destRoot, filepath.FromSlash(f.Dest)))
+		if errors.Is(err, os.ErrNotExist) {
+			changed = append(changed, f.Dest)
+			continue
+		}
 		if err != nil {
 			return nil, nil, nil, err
 		}
 		if srcHash != destHash {
-			changed = append(changed, f)
+			changed = append(changed, f.Dest)
 		}
 	}
 	for _, f := range destFiles {
@@ -335,11 +324,16 @@ func DiffDir(srcRoot, destRoot string, include []string, exclude []string) (adde
 }
 
 func CleanDir(destRoot string, keep []string, dryRun bool) error {
+	prev, hasPrev, err := ReadManifest(destRoot)
+	if err != nil {
+		return err
+	}
 	keepSet := make(map[string]struct{}, len(keep))
 	for _, rel := range keep {
 		keepSet[filepath.ToSlash(rel)] = struct{}{}
 	}
-	return walkDir(destRoot, func(path string, d fs.DirEntry, err error) error {
+	var removed []string
+	err = walkDir(destRoot, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
@@ -351,14 +345,56 @@ func CleanDir(destRoot string, keep []string, dryRun bool) error {
 			return err
 		}
 		rel = filepath.ToSlash(rel)
+		if d.IsDir() && rel == BaseDirName {
+			return fs.SkipDir
+		}
+		if d.IsDir() || isSyncMetadata(rel) {
+			return nil
+		}
+		if _, ok := keepSet[rel]; ok {
+			return nil
+		}
+		if hasPrev && !prev.Managed(rel) {
+			return nil
+		}
+		removed = append(removed, rel)
+		base, err := basePath(destRoot, rel)
+		if err != nil {
+			return err
+		}
+		if err := RemovePath(base, dryRun); err != nil {
+			return err
+		}
+		return RemovePath(path, dryRun)
+	})
+	if err != nil || !hasPrev || len(removed) == 0 {
+		return err
+	}
+	for _, rel := range removed {
+		delete(prev.Files, rel)
+	}
+	return WriteManifest(destRoot, prev, dryRun)
+}
+
+func DirSize(root string) (int64, error) {
+	var total int64
+	err := walkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
 		if d.IsDir() {
 			return nil
 		}
-		if _, ok := keepSet[rel]; !ok {
-			return RemovePath(path, dryRun)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			total += info.Size()
 		}
 		return nil
 	})
+	return total, err
 }
 
 func ResolvePath(repoRoot, dest string) string {
@@ -6,7 +6,9 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
 )
 
 type fsutilHooks struct {
@@ -21,6 +23,9 @@ type fsutilHooks struct {
 	relPath   func(string, string) (string, error)
 	copy      func(io.Writer, io.Reader) (int64, error)
 	stat      func(*os.File) (os.FileInfo, error)
+	readlink  func(string) (string, error)
+	rename    func(string, string) error
+	chtimes   func(string, time.Time, time.Time) error
 }
 
 func snapshotHooks() fsutilHooks {
@@ -36,6 +41,9 @@ func snapshotHooks() fsutilHooks {
 		relPath:   relPath,
 		copy:      ioCopy,
 		stat:      fileStat,
+		readlink:  osReadlink,
+		rename:    osRename,
+		chtimes:   osChtimes,
 	}
 }
 
@@ -51,6 +59,9 @@ func (h fsutilHooks) restore() {
 	relPath = h.relPath
 	ioCopy = h.copy
 	fileStat = h.stat
+	osReadlink = h.readlink
+	osRename = h.rename
+	osChtimes = h.chtimes
 }
 
 func TestCopyFileErrors(t *testing.T) {
@@ -113,6 +124,28 @@ func TestCopyFileErrors(t *testing.T) {
 			t.Fatalf("expected chmod error")
 		}
 	})
+
+	t.Run("chtimes", func(t *testing.T) {
+		h := snapshotHooks()
+		defer h.restore()
+		osChtimes = func(string, time.Time, time.Time) error { return errors.New("chtimes") }
+		if err := CopyFile(src, filepath.Join(root, "dst.txt"), false); err == nil {
+			t.Fatalf("expected chtimes error")
+		}
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		h := snapshotHooks()
+		defer h.restore()
+		osRename = func(string, string) error { return errors.New("rename") }
+		dst := filepath.Join(root, "renamed.txt")
+		if err := CopyFile(src, dst, false); err == nil {
+			t.Fatalf("expected rename error")
+		}
+		if _, err := os.Lstat(tempPath(dst)); !os.IsNotExist(err) {
+			t.Fatalf("expected temp file cleanup, got %v", err)
+		}
+	})
 }
 
 func TestLinkFileErrors(t *testing.T) {
@@ -174,13 +207,13 @@ func TestMirrorCleanupErrors(t *testing.T) {
 		walkDir = func(root string, fn fs.WalkDirFunc) error {
 			return fn(root, fakeDirEntry{name: "x", dir: true}, errors.New("walk"))
 		}
-		if err := mirrorCleanup(root, dest, nil, SyncOptions{ConflictPolicy: ConflictOverwrite}); err == nil {
+		if _, err := mirrorCleanup(root, dest, nil, nil, SyncOptions{ConflictPolicy: ConflictOverwrite}); err == nil {
 			t.Fatalf("expected callback error")
 		}
 	})
 
 	t.Run("conflict-policy", func(t *testing.T) {
-		if err := mirrorCleanup(root, dest, nil, SyncOptions{ConflictPolicy: ConflictFail}); err == nil {
+		if _, err := mirrorCleanup(root, dest, nil, nil, SyncOptions{ConflictPolicy: ConflictFail}); err == nil {
 			t.Fatalf("expected conflict policy error")
 		}
 	})
@@ -189,7 +222,7 @@ func TestMirrorCleanupErrors(t *testing.T) {
 		h := snapshotHooks()
 		defer h.restore()
 		walkDir = func(string, fs.WalkDirFunc) error { return errors.New("walk") }
-		if err := mirrorCleanup(root, dest, nil, SyncOptions{ConflictPolicy: ConflictOverwrite}); err == nil {
+		if _, err := mirrorCleanup(root, dest, nil, nil, SyncOptions{ConflictPolicy: ConflictOverwrite}); err == nil {
 			t.Fatalf("expected walk error")
 		}
 	})
@@ -198,7 +231,7 @@ func TestMirrorCleanupErrors(t *testing.T) {
 		h := snapshotHooks()
 		defer h.restore()
 		relPath = func(string, string) (string, error) { return "", errors.New("rel") }
-		if err := mirrorCleanup(root, dest, nil, SyncOptions{ConflictPolicy: ConflictOverwrite}); err == nil {
+		if _, err := mirrorCleanup(root, dest, nil, nil, SyncOptions{ConflictPolicy: ConflictOverwrite}); err == nil {
 			t.Fatalf("expected rel error")
 		}
 	})
@@ -207,7 +240,7 @@ func TestMirrorCleanupErrors(t *testing.T) {
 		h := snapshotHooks()
 		defer h.restore()
 		osRemoveAll = func(string) error { return errors.New("remove") }
-		if err := mirrorCleanup(root, dest, nil, SyncOptions{ConflictPolicy: ConflictOverwrite}); err == nil {
+		if _, err := mirrorCleanup(root, dest, nil, nil, SyncOptions{ConflictPolicy: ConflictOverwrite}); err == nil {
 			t.Fatalf("expected remove error")
 		}
 	})
@@ -314,7 +347,7 @@ func TestDiffDirErrors(t *testing.T) {
 
 func TestSyncDirErrors(t *testing.T) {
 	t.Run("list", func(t *testing.T) {
-		if err := SyncDir(filepath.Join(t.TempDir(), "missing"), t.TempDir(), SyncOptions{Mode: ModeCopy}); err == nil {
+		if _, err := SyncDir(filepath.Join(t.TempDir(), "missing"), t.TempDir(), SyncOptions{Mode: ModeCopy}); err == nil {
 			t.Fatalf("expected list error")
 		}
 	})
@@ -327,7 +360,7 @@ func TestSyncDirErrors(t *testing.T) {
 		_ = os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0o644)
 		_ = os.MkdirAll(dst, 0o755)
 		_ = os.WriteFile(filepath.Join(dst, "a.txt"), []byte("old"), 0o644)
-		if err := SyncDir(src, dst, SyncOptions{Mode: ModeLink, ConflictPolicy: ConflictFail}); err == nil {
+		if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeLink, ConflictPolicy: ConflictFail}); err == nil {
 			t.Fatalf("expected link conflict error")
 		}
 	})
@@ -341,7 +374,7 @@ func TestSyncDirErrors(t *testing.T) {
 		_ = os.MkdirAll(src, 0o755)
 		_ = os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0o644)
 		osSymlink = func(string, string) error { return errors.New("symlink") }
-		if err := SyncDir(src, dst, SyncOptions{Mode: ModeLink, ConflictPolicy: ConflictOverwrite}); err == nil {
+		if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeLink, ConflictPolicy: ConflictOverwrite}); err == nil {
 			t.Fatalf("expected link error")
 		}
 	})
@@ -355,10 +388,64 @@ func TestSyncDirErrors(t *testing.T) {
 		_ = os.MkdirAll(src, 0o755)
 		_ = os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0o644)
 		osCreate = func(string) (*os.File, error) { return nil, errors.New("create") }
-		if err := SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictOverwrite}); err == nil {
+		if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictOverwrite}); err == nil {
 			t.Fatalf("expected copy error")
 		}
 	})
+
+	t.Run("lstat", func(t *testing.T) {
+		h := snapshotHooks()
+		defer h.restore()
+		root := t.TempDir()
+		src := filepath.Join(root, "src")
+		_ = os.MkdirAll(src, 0o755)
+		_ = os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0o644)
+		osLstat = func(string) (os.FileInfo, error) { return nil, errors.New("lstat") }
+		if _, err := SyncDir(src, filepath.Join(root, "dst"), SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictOverwrite}); err == nil {
+			t.Fatalf("expected copy lstat error")
+		}
+		if _, err := SyncDir(src, filepath.Join(root, "dst"), SyncOptions{Mode: ModeLink, ConflictPolicy: ConflictOverwrite}); err == nil {
+			t.Fatalf("expected link lstat error")
+		}
+	})
+
+	t.Run("hash", func(t *testing.T) {
+		h := snapshotHooks()
+		defer h.restore()
+		root := t.TempDir()
+		src := filepath.Join(root, "src")
+		dst := filepath.Join(root, "dst")
+		_ = os.MkdirAll(src, 0o755)
+		_ = os.MkdirAll(dst, 0o755)
+		_ = os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0o644)
+		_ = os.WriteFile(filepath.Join(dst, "a.txt"), []byte("b"), 0o644)
+		old := time.Now().Add(-time.Hour)
+		_ = os.Chtimes(filepath.Join(dst, "a.txt"), old, old)
+		osOpen = func(string) (*os.File, error) { return nil, errors.New("open") }
+		if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictOverwrite}); err == nil {
+			t.Fatalf("expected hash error")
+		}
+	})
+
+	t.Run("replace-link", func(t *testing.T) {
+		h := snapshotHooks()
+		defer h.restore()
+		root := t.TempDir()
+		src := filepath.Join(root, "src")
+		dst := filepath.Join(root, "dst")
+		_ = os.MkdirAll(src, 0o755)
+		_ = os.MkdirAll(dst, 0o755)
+		_ = os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0o644)
+		_ = os.WriteFile(filepath.Join(dst, "a.txt"), []byte("old"), 0o644)
+		osRename = func(string, string) error { return errors.New("rename") }
+		if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeLink, ConflictPolicy: ConflictOverwrite}); err == nil {
+			t.Fatalf("expected rename error")
+		}
+		osSymlink = func(string, string) error { return errors.New("symlink") }
+		if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeLink, ConflictPolicy: ConflictOverwrite}); err == nil {
+			t.Fatalf("expected symlink error")
+		}
+	})
 }
 
 func TestFileHashCopyError(t *testing.T) {
@@ -409,6 +496,29 @@ func TestListGitReposDuplicate(t *testing.T) {
 	}
 }
 
+func TestFindGitReposSkipsDenied(t *testing.T) {
+	h := snapshotHooks()
+	defer h.restore()
+	denied := &fs.PathError{Op: "open", Path: "root/locked", Err: fs.ErrPermission}
+	walkDir = func(root string, fn fs.WalkDirFunc) error {
+		if err := fn(filepath.Join(root, "locked"), fakeDirEntry{name: "locked", dir: true}, denied); err != fs.SkipDir {
+			t.Fatalf("expected skip, got %v", err)
+		}
+		_ = fn(filepath.Join(root, "a", ".git"), fakeDirEntry{name: ".git", dir: true}, nil)
+		return nil
+	}
+	repos, skipped, err := FindGitRepos("root")
+	if err != nil || !reflect.DeepEqual(repos, []string{filepath.Join("root", "a")}) || !reflect.DeepEqual(skipped, []string{filepath.Join("root", "locked")}) {
+		t.Fatalf("unexpected result: %v %v %v", repos, skipped, err)
+	}
+	walkDir = func(root string, fn fs.WalkDirFunc) error {
+		return fn(root, nil, denied)
+	}
+	if _, _, err := FindGitRepos("root"); err == nil {
+		t.Fatalf("expected unreadable root error")
+	}
+}
+
 type fakeDirEntry struct {
 	name string
 	dir  bool
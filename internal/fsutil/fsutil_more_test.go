@@ -74,7 +74,7 @@ func TestMirrorCleanupKeeps(t *testing.T) {
 	dest := filepath.Join(root, "dest")
 	_ = os.MkdirAll(filepath.Join(dest, "dir"), 0o755)
 	_ = os.WriteFile(filepath.Join(dest, "keep.txt"), []byte("a"), 0o644)
-	if err := mirrorCleanup(root, dest, []string{"keep.txt"}, SyncOptions{ConflictPolicy: ConflictOverwrite}); err != nil {
+	if _, err := mirrorCleanup(root, dest, []string{"keep.txt"}, nil, SyncOptions{ConflictPolicy: ConflictOverwrite}); err != nil {
 		t.Fatalf("mirror: %v", err)
 	}
 }
@@ -84,7 +84,7 @@ func TestMirrorCleanupRemoves(t *testing.T) {
 	dest := filepath.Join(root, "dest")
 	_ = os.MkdirAll(dest, 0o755)
 	_ = os.WriteFile(filepath.Join(dest, "remove.txt"), []byte("a"), 0o644)
-	if err := mirrorCleanup(root, dest, nil, SyncOptions{ConflictPolicy: ConflictOverwrite}); err != nil {
+	if _, err := mirrorCleanup(root, dest, nil, nil, SyncOptions{ConflictPolicy: ConflictOverwrite}); err != nil {
 		t.Fatalf("mirror remove: %v", err)
 	}
 }
@@ -108,3 +108,22 @@ func TestCleanDirRemoves(t *testing.T) {
 		t.Fatalf("clean remove: %v", err)
 	}
 }
+
+func TestDirSize(t *testing.T) {
+	root := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(root, "a"), 0o755)
+	_ = os.WriteFile(filepath.Join(root, "a", "one.txt"), []byte("12345"), 0o644)
+	_ = os.WriteFile(filepath.Join(root, "two.txt"), []byte("123"), 0o644)
+	_ = os.Symlink(filepath.Join(root, "two.txt"), filepath.Join(root, "link"))
+	size, err := DirSize(root)
+	if err != nil || size != 8 {
+		t.Fatalf("unexpected size: %d %v", size, err)
+	}
+	size, err = DirSize(filepath.Join(root, "two.txt"))
+	if err != nil || size != 3 {
+		t.Fatalf("unexpected file size: %d %v", size, err)
+	}
+	if _, err := DirSize(filepath.Join(root, "missing")); err == nil {
+		t.Fatalf("expected error")
+	}
+}
@@ -1,12 +1,35 @@
 package fsutil
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"testing"
+	"time"
 )
 
+func TestMain(m *testing.M) {
+	state, err := os.MkdirTemp("", "fsutil-state")
+	if err != nil {
+		panic(err)
+	}
+	stateDir = func() (string, error) { return state, nil }
+	code := m.Run()
+	_ = os.RemoveAll(state)
+	os.Exit(code)
+}
+
+func testBasePath(t *testing.T, destRoot, rel string) string {
+	t.Helper()
+	path, err := basePath(destRoot, rel)
+	if err != nil {
+		t.Fatalf("base path: %v", err)
+	}
+	return path
+}
+
 func TestIsGitRepoAndListGitRepos(t *testing.T) {
 	root := t.TempDir()
 	repo1 := filepath.Join(root, "repo1")
@@ -90,6 +113,48 @@ func TestCopyLinkRemove(t *testing.T) {
 	}
 }
 
+func TestMoveDir(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	_ = os.MkdirAll(filepath.Join(src, "sub"), 0o755)
+	_ = os.WriteFile(filepath.Join(src, "sub", "a.txt"), []byte("a"), 0o644)
+	_ = os.Symlink("sub/a.txt", filepath.Join(src, "link"))
+	if err := MoveDir(src, filepath.Join(root, "renamed")); err != nil {
+		t.Fatalf("move: %v", err)
+	}
+	orig := osRename
+	defer func() { osRename = orig }()
+	osRename = func(oldpath, newpath string) error {
+		if strings.HasSuffix(newpath, ".gkn-tmp") || strings.Contains(oldpath, ".gkn-tmp") {
+			return orig(oldpath, newpath)
+		}
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EXDEV}
+	}
+	dst := filepath.Join(root, "copied")
+	if err := MoveDir(filepath.Join(root, "renamed"), dst); err != nil {
+		t.Fatalf("cross-device move: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "sub", "a.txt")); string(data) != "a" {
+		t.Fatalf("file not copied: %q", data)
+	}
+	if link, _ := os.Readlink(filepath.Join(dst, "link")); link != "sub/a.txt" {
+		t.Fatalf("link not copied: %q", link)
+	}
+	if _, err := os.Stat(filepath.Join(root, "renamed")); !os.IsNotExist(err) {
+		t.Fatalf("source not removed: %v", err)
+	}
+	if err := MoveDir(filepath.Join(root, "missing"), filepath.Join(root, "x")); err == nil || !strings.Contains(err.Error(), "move across filesystems") {
+		t.Fatalf("expected copy error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "x")); !os.IsNotExist(err) {
+		t.Fatalf("partial copy kept: %v", err)
+	}
+	osRename = func(string, string) error { return errors.New("denied") }
+	if err := MoveDir(dst, filepath.Join(root, "y")); err == nil || err.Error() != "denied" {
+		t.Fatalf("expected rename error: %v", err)
+	}
+}
+
 func TestSyncDirCopyConflict(t *testing.T) {
 	root := t.TempDir()
 	src := filepath.Join(root, "src")
@@ -98,13 +163,13 @@ func TestSyncDirCopyConflict(t *testing.T) {
 	_ = os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0o644)
 	_ = os.MkdirAll(dst, 0o755)
 	_ = os.WriteFile(filepath.Join(dst, "a.txt"), []byte("old"), 0o644)
-	if err := SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictFail}); err == nil {
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictFail}); err == nil {
 		t.Fatalf("expected conflict")
 	}
-	if err := SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictOverwrite}); err != nil {
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictOverwrite}); err != nil {
 		t.Fatalf("overwrite: %v", err)
 	}
-	if err := SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictOverwrite, DryRun: true}); err != nil {
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictOverwrite, DryRun: true}); err != nil {
 		t.Fatalf("dry overwrite: %v", err)
 	}
 }
@@ -115,14 +180,14 @@ func TestSyncDirLinkMirror(t *testing.T) {
 	dst := filepath.Join(root, "dst")
 	_ = os.MkdirAll(src, 0o755)
 	_ = os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0o644)
-	if err := SyncDir(src, dst, SyncOptions{Mode: ModeLink, ConflictPolicy: ConflictOverwrite}); err != nil {
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeLink, ConflictPolicy: ConflictOverwrite}); err != nil {
 		t.Fatalf("link: %v", err)
 	}
-	if err := SyncDir(src, dst, SyncOptions{Mode: ModeMirror, ConflictPolicy: ConflictFail}); err == nil {
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeMirror, ConflictPolicy: ConflictFail}); err == nil {
 		t.Fatalf("expected mirror error")
 	}
 	_ = os.WriteFile(filepath.Join(dst, "extra.txt"), []byte("x"), 0o644)
-	if err := SyncDir(src, dst, SyncOptions{Mode: ModeMirror, ConflictPolicy: ConflictOverwrite}); err != nil {
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeMirror, ConflictPolicy: ConflictOverwrite}); err != nil {
 		t.Fatalf("mirror: %v", err)
 	}
 }
@@ -170,3 +235,112 @@ func TestResolvePath(t *testing.T) {
 		t.Fatalf("expected rel")
 	}
 }
+
+func TestSyncDirIncremental(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	dst := filepath.Join(root, "dst")
+	_ = os.MkdirAll(filepath.Join(src, "sub"), 0o755)
+	_ = os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0o644)
+	_ = os.WriteFile(filepath.Join(src, "sub", "b.txt"), []byte("b"), 0o644)
+	report, err := SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictFail})
+	if err != nil {
+		t.Fatalf("first sync: %v", err)
+	}
+	if report.Count(ActionCreated) != 2 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	info, _ := os.Stat(filepath.Join(dst, "a.txt"))
+	srcInfo, _ := os.Stat(filepath.Join(src, "a.txt"))
+	if !info.ModTime().Equal(srcInfo.ModTime()) {
+		t.Fatalf("expected mtime preserved")
+	}
+	report, err = SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictFail})
+	if err != nil {
+		t.Fatalf("second sync: %v", err)
+	}
+	if report.Count(ActionUnchanged) != 2 || len(report.Modified()) != 0 {
+		t.Fatalf("expected unchanged: %+v", report)
+	}
+	_ = os.WriteFile(filepath.Join(src, "a.txt"), []byte("A"), 0o644)
+	report, err = SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictOverwrite})
+	if err != nil {
+		t.Fatalf("third sync: %v", err)
+	}
+	modified := report.Modified()
+	if len(modified) != 1 || modified[0].Path != "a.txt" || modified[0].Action != ActionUpdated {
+		t.Fatalf("unexpected modified: %+v", modified)
+	}
+}
+
+func TestSyncDirSameContentDifferentMtime(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	dst := filepath.Join(root, "dst")
+	_ = os.MkdirAll(src, 0o755)
+	_ = os.MkdirAll(dst, 0o755)
+	_ = os.WriteFile(filepath.Join(src, "a.txt"), []byte("same"), 0o644)
+	_ = os.WriteFile(filepath.Join(dst, "a.txt"), []byte("same"), 0o644)
+	old := time.Now().Add(-time.Hour)
+	_ = os.Chtimes(filepath.Join(dst, "a.txt"), old, old)
+	report, err := SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictFail})
+	if err != nil {
+		t.Fatalf("expected no conflict: %v", err)
+	}
+	if report.Count(ActionUnchanged) != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+}
+
+func TestSyncDirLinkIncremental(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	dst := filepath.Join(root, "dst")
+	_ = os.MkdirAll(src, 0o755)
+	_ = os.MkdirAll(dst, 0o755)
+	_ = os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0o644)
+	_ = os.WriteFile(filepath.Join(src, "b.txt"), []byte("b"), 0o644)
+	_ = os.WriteFile(filepath.Join(dst, "b.txt"), []byte("b"), 0o644)
+	report, err := SyncDir(src, dst, SyncOptions{Mode: ModeLink, ConflictPolicy: ConflictFail})
+	if err != nil {
+		t.Fatalf("link sync: %v", err)
+	}
+	if report.Count(ActionCreated) != 1 || report.Count(ActionUpdated) != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if target, err := os.Readlink(filepath.Join(dst, "b.txt")); err != nil || target != filepath.Join(src, "b.txt") {
+		t.Fatalf("expected identical file replaced by link: %q %v", target, err)
+	}
+	report, err = SyncDir(src, dst, SyncOptions{Mode: ModeLink, ConflictPolicy: ConflictFail})
+	if err != nil {
+		t.Fatalf("second link sync: %v", err)
+	}
+	if report.Count(ActionUnchanged) != 2 {
+		t.Fatalf("expected unchanged: %+v", report)
+	}
+	_ = os.Remove(filepath.Join(dst, "a.txt"))
+	_ = os.MkdirAll(filepath.Join(dst, "a.txt"), 0o755)
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeLink, ConflictPolicy: ConflictOverwrite}); err != nil {
+		t.Fatalf("replace dir: %v", err)
+	}
+	if info, err := os.Lstat(filepath.Join(dst, "a.txt")); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("expected dir replaced by link")
+	}
+}
+
+func TestSyncDirMirrorReportsRemoved(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	dst := filepath.Join(root, "dst")
+	_ = os.MkdirAll(src, 0o755)
+	_ = os.MkdirAll(dst, 0o755)
+	_ = os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0o644)
+	_ = os.WriteFile(filepath.Join(dst, "stale.txt"), []byte("x"), 0o644)
+	report, err := SyncDir(src, dst, SyncOptions{Mode: ModeMirror, ConflictPolicy: ConflictOverwrite})
+	if err != nil {
+		t.Fatalf("mirror: %v", err)
+	}
+	if report.Count(ActionRemoved) != 1 || report.Count(ActionCreated) != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+}
@@ -0,0 +1,456 @@
+package fsutil
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	journalMetaName    = "journal.json"
+	journalEntriesName = "entries.jsonl"
+	journalFilesDir    = "files"
+	journalAbsent      = "absent"
+	journalTreePrefix  = "tree:"
+)
+
+type JournalStatus string
+
+const (
+	JournalRunning    JournalStatus = "running"
+	JournalCommitted  JournalStatus = "committed"
+	JournalFailed     JournalStatus = "failed"
+	JournalRolledBack JournalStatus = "rolled-back"
+)
+
+type JournalMeta struct {
+	ID        string        `json:"id"`
+	CreatedAt time.Time     `json:"createdAt"`
+	Status    JournalStatus `json:"status"`
+	Entries   int           `json:"entries"`
+}
+
+type JournalEntry struct {
+	Path    string   `json:"path"`
+	Existed bool     `json:"existed"`
+	Link    string   `json:"link,omitempty"`
+	Backup  string   `json:"backup,omitempty"`
+	Dirs    []string `json:"dirs,omitempty"`
+	After   string   `json:"after,omitempty"`
+}
+
+type Journal struct {
+	Dir      string
+	Meta     JournalMeta
+	entries  []JournalEntry
+	recorded map[string]bool
+	created  map[string]bool
+	made     map[string]bool
+	log      *os.File
+}
+
+func NewJournal(root string) (*Journal, error) {
+	now := timeNow().UTC()
+	id := now.Format("20060102-150405.000000")
+	dir := filepath.Join(root, id)
+	for n := 1; ; n++ {
+		if _, err := osLstat(dir); err != nil {
+			break
+		}
+		id = fmt.Sprintf("%s-%d", now.Format("20060102-150405.000000"), n)
+		dir = filepath.Join(root, id)
+	}
+	if err := osMkdirAll(filepath.Join(dir, journalFilesDir), 0o755); err != nil {
+		return nil, err
+	}
+	log, err := os.OpenFile(filepath.Join(dir, journalEntriesName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	j := &Journal{Dir: dir, Meta: JournalMeta{ID: id, CreatedAt: now, Status: JournalRunning}, recorded: map[string]bool{}, created: map[string]bool{}, made: map[string]bool{}, log: log}
+	if err := j.writeMeta(); err != nil {
+		_ = log.Close()
+		return nil, err
+	}
+	return j, nil
+}
+
+func OpenJournal(root, id string) (*Journal, error) {
+	dir := filepath.Join(root, id)
+	data, err := os.ReadFile(filepath.Join(dir, journalMetaName))
+	if err != nil {
+		return nil, err
+	}
+	j := &Journal{Dir: dir, recorded: map[string]bool{}, created: map[string]bool{}, made: map[string]bool{}}
+	if err := json.Unmarshal(data, &j.Meta); err != nil {
+		return nil, fmt.Errorf("invalid journal %s: %w", id, err)
+	}
+	file, err := os.Open(filepath.Join(dir, journalEntriesName))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		var e JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("invalid journal %s: %w", id, err)
+		}
+		j.entries = append(j.entries, e)
+		j.recorded[e.Path] = true
+		j.created[e.Path] = !e.Existed
+		for _, dir := range e.Dirs {
+			j.made[dir] = true
+		}
+	}
+	return j, scanner.Err()
+}
+
+func ListJournals(root string) ([]JournalMeta, error) {
+	dirents, err := os.ReadDir(root)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var out []JournalMeta
+	for _, d := range dirents {
+		if !d.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(root, d.Name(), journalMetaName))
+		if err != nil {
+			continue
+		}
+		var meta JournalMeta
+		if json.Unmarshal(data, &meta) == nil {
+			out = append(out, meta)
+		}
+	}
+	sort.Slice(out, func(i, k int) bool { return out[i].ID > out[k].ID })
+	return out, nil
+}
+
+func PruneJournals(root string, keep int) error {
+	journals, err := ListJournals(root)
+	if err != nil {
+		return err
+	}
+	for i := keep; i < len(journals); i++ {
+		if err := osRemoveAll(filepath.Join(root, journals[i].ID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (j *Journal) ID() string {
+	if j == nil {
+		return ""
+	}
+	return j.Meta.ID
+}
+
+func (j *Journal) Entries() []JournalEntry {
+	return j.entries
+}
+
+func (j *Journal) Record(path string) error {
+	if j == nil {
+		return nil
+	}
+	path = filepath.Clean(path)
+	if j.recorded[path] || j.insideCreated(path) {
+		return nil
+	}
+	entry := JournalEntry{Path: path}
+	info, err := osLstat(path)
+	switch {
+	case err == nil && j.made[path]:
+	case errors.Is(err, os.ErrNotExist):
+		for dir := filepath.Dir(path); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+			if _, err := osLstat(dir); err == nil {
+				break
+			}
+			entry.Dirs = append([]string{dir}, entry.Dirs...)
+		}
+	case err != nil:
+		return err
+	case info.Mode()&os.ModeSymlink != 0:
+		entry.Existed = true
+		if entry.Link, err = osReadlink(path); err != nil {
+			return err
+		}
+	default:
+		entry.Existed = true
+		entry.Backup = filepath.Join(journalFilesDir, strconv.Itoa(len(j.entries)))
+		if err := copyTree(path, filepath.Join(j.Dir, entry.Backup)); err != nil {
+			return err
+		}
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := j.log.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	j.recorded[path] = true
+	j.created[path] = !entry.Existed
+	for _, dir := range entry.Dirs {
+		j.made[dir] = true
+	}
+	j.entries = append(j.entries, entry)
+	return nil
+}
+
+func (j *Journal) insideCreated(path string) bool {
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if j.created[dir] {
+			return true
+		}
+		if filepath.Dir(dir) == dir {
+			return false
+		}
+	}
+}
+
+func (j *Journal) Trivial() bool {
+	if j == nil {
+		return true
+	}
+	for _, e := range j.entries {
+		if filepath.Base(e.Path) != ManifestName || !e.Existed {
+			return false
+		}
+		if _, err := osLstat(e.Path); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (j *Journal) Discard() error {
+	if j.log != nil {
+		_ = j.log.Close()
+		j.log = nil
+	}
+	return osRemoveAll(j.Dir)
+}
+
+func (j *Journal) Finish(status JournalStatus) error {
+	var err error
+	if j.log != nil {
+		err = j.log.Close()
+		j.log = nil
+	}
+	if err == nil && (status == JournalCommitted || status == JournalFailed) {
+		err = j.writeAfter()
+	}
+	j.Meta.Status = status
+	if merr := j.writeMeta(); err == nil {
+		err = merr
+	}
+	return err
+}
+
+func (j *Journal) writeAfter() error {
+	var buf bytes.Buffer
+	for i := range j.entries {
+		state, err := pathState(j.entries[i].Path)
+		if err != nil {
+			return err
+		}
+		j.entries[i].After = state
+		data, err := json.Marshal(j.entries[i])
+		if err != nil {
+			return err
+		}
+		buf.Write(append(data, '\n'))
+	}
+	return writeFileAtomic(filepath.Join(j.Dir, journalEntriesName), buf.Bytes(), 0o644)
+}
+
+func (j *Journal) Drifted() ([]string, error) {
+	var out []string
+	for _, e := range j.entries {
+		state, err := pathState(e.Path)
+		if err != nil {
+			return out, err
+		}
+		if e.After == "" || state != e.After {
+			out = append(out, e.Path)
+		}
+	}
+	return out, nil
+}
+
+func (j *Journal) Rollback() error {
+	var errs []error
+	for i := len(j.entries) - 1; i >= 0; i-- {
+		if err := j.restore(j.entries[i]); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", j.entries[i].Path, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	return j.Finish(JournalRolledBack)
+}
+
+func (j *Journal) restore(e JournalEntry) error {
+	if err := osRemoveAll(e.Path); err != nil {
+		return err
+	}
+	switch {
+	case !e.Existed:
+		for i := len(e.Dirs) - 1; i >= 0; i-- {
+			if os.Remove(e.Dirs[i]) != nil {
+				break
+			}
+		}
+		return nil
+	case e.Backup == "":
+		return LinkFile(e.Link, e.Path, false)
+	default:
+		return copyTree(filepath.Join(j.Dir, e.Backup), e.Path)
+	}
+}
+
+func (j *Journal) writeMeta() error {
+	j.Meta.Entries = len(j.entries)
+	data, err := json.MarshalIndent(j.Meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(j.Dir, journalMetaName), append(data, '\n'), 0o644)
+}
+
+func pathState(path string) (string, error) {
+	info, err := osLstat(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return journalAbsent, nil
+	case err != nil:
+		return "", err
+	case info.Mode()&os.ModeSymlink != 0:
+		link, err := osReadlink(path)
+		return linkHashPrefix + link, err
+	case !info.IsDir():
+		return FileHash(path)
+	}
+	h := sha256.New()
+	err = walkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || p == path || d.IsDir() {
+			return err
+		}
+		rel, err := relPath(path, p)
+		if err != nil {
+			return err
+		}
+		state, err := pathState(p)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(h, "%s\x00%s\n", filepath.ToSlash(rel), state)
+		return nil
+	})
+	return journalTreePrefix + hex.EncodeToString(h.Sum(nil)), err
+}
+
+func copyTree(src, dst string) error {
+	return walkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := relPath(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case d.IsDir():
+			return osMkdirAll(target, 0o755)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := osReadlink(path)
+			if err != nil {
+				return err
+			}
+			return LinkFile(link, target, false)
+		default:
+			return CopyFile(path, target, false)
+		}
+	})
+}
+
+func (o SyncOptions) record(paths ...string) error {
+	if o.DryRun {
+		return nil
+	}
+	for _, path := range paths {
+		if err := o.Journal.Record(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o SyncOptions) copyFile(src, dst string) error {
+	if err := o.record(dst); err != nil {
+		return err
+	}
+	return CopyFile(src, dst, o.DryRun)
+}
+
+func (o SyncOptions) linkFile(target, dst string) error {
+	if err := o.record(dst); err != nil {
+		return err
+	}
+	return LinkFile(target, dst, o.DryRun)
+}
+
+func (o SyncOptions) replaceWithLink(target, dst string, dstInfo os.FileInfo) error {
+	if err := o.record(dst); err != nil {
+		return err
+	}
+	return replaceWithLink(target, dst, dstInfo, o.DryRun)
+}
+
+func (o SyncOptions) removePath(path string) error {
+	if err := o.record(path); err != nil {
+		return err
+	}
+	return RemovePath(path, o.DryRun)
+}
+
+func (o SyncOptions) removeBase(destRoot, rel string) error {
+	base, err := basePath(destRoot, rel)
+	if err != nil {
+		return err
+	}
+	return o.removePath(base)
+}
+
+func (o SyncOptions) writeManifest(destRoot string, m Manifest) error {
+	if prev, ok, err := ReadManifest(destRoot); err == nil && ok && prev.Same(m) {
+		return nil
+	}
+	if err := o.record(filepath.Join(destRoot, ManifestName)); err != nil {
+		return err
+	}
+	return WriteManifest(destRoot, m, o.DryRun)
+}
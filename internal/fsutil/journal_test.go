@@ -0,0 +1,296 @@
+package fsutil
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func treeState(t *testing.T, root string) map[string]string {
+	t.Helper()
+	out := map[string]string{}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		switch {
+		case d.IsDir():
+			out[rel] = "dir"
+		case d.Type()&fs.ModeSymlink != 0:
+			target, _ := os.Readlink(path)
+			out[rel] = "link:" + target
+		default:
+			data, _ := os.ReadFile(path)
+			out[rel] = "file:" + string(data)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+	return out
+}
+
+func TestJournalRollbackRestoresDest(t *testing.T) {
+	src, dst := setupSyncDirs(t)
+	_ = os.MkdirAll(filepath.Join(src, "nested"), 0o755)
+	_ = os.WriteFile(filepath.Join(src, "nested", "c.txt"), []byte("c"), 0o644)
+	opts := SyncOptions{Mode: ModeMirror, ConflictPolicy: ConflictOverwrite}
+	if _, err := SyncDir(src, dst, opts); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	_ = os.WriteFile(filepath.Join(dst, "a.txt"), []byte("local"), 0o644)
+	_ = os.Symlink("b.txt", filepath.Join(dst, "alias"))
+	_ = os.Remove(filepath.Join(src, "b.txt"))
+	_ = os.RemoveAll(filepath.Join(src, "nested"))
+	_ = os.MkdirAll(filepath.Join(src, "new", "deep"), 0o755)
+	_ = os.WriteFile(filepath.Join(src, "new", "deep", "d.txt"), []byte("d"), 0o644)
+	_ = os.WriteFile(filepath.Join(src, "alias"), []byte("file"), 0o644)
+	old := time.Now().Add(-time.Hour)
+	_ = os.Chtimes(filepath.Join(dst, "a.txt"), old, old)
+	before := treeState(t, dst)
+
+	root := t.TempDir()
+	j, err := NewJournal(root)
+	if err != nil {
+		t.Fatalf("journal: %v", err)
+	}
+	opts.Journal = j
+	report, err := SyncDir(src, dst, opts)
+	if err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if report.Count(ActionRemoved) != 2 || reflect.DeepEqual(before, treeState(t, dst)) {
+		t.Fatalf("expected sync to change dest: %+v", report)
+	}
+	if err := j.Finish(JournalCommitted); err != nil {
+		t.Fatalf("finish: %v", err)
+	}
+
+	reopened, err := OpenJournal(root, j.Meta.ID)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if reopened.Meta.Status != JournalCommitted || len(reopened.Entries()) != reopened.Meta.Entries || len(reopened.Entries()) == 0 {
+		t.Fatalf("unexpected journal: %+v %d", reopened.Meta, len(reopened.Entries()))
+	}
+	if err := reopened.Rollback(); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	if after := treeState(t, dst); !reflect.DeepEqual(before, after) {
+		t.Fatalf("rollback mismatch:\nbefore %v\nafter  %v", before, after)
+	}
+	journals, err := ListJournals(root)
+	if err != nil || len(journals) != 1 || journals[0].Status != JournalRolledBack {
+		t.Fatalf("unexpected journals: %+v %v", journals, err)
+	}
+}
+
+func TestJournalRollbackRemovesNewDest(t *testing.T) {
+	src, dst := setupSyncDirs(t)
+	dst = filepath.Join(dst, "deep", "skills")
+	j, err := NewJournal(t.TempDir())
+	if err != nil {
+		t.Fatalf("journal: %v", err)
+	}
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeLinkDir, ConflictPolicy: ConflictFail, Journal: j}); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if len(j.Entries()) == 0 {
+		t.Fatalf("expected entries")
+	}
+	for _, e := range j.Entries() {
+		if e.Existed || filepath.Dir(e.Path) != dst {
+			t.Fatalf("expected only created files recorded: %+v", j.Entries())
+		}
+	}
+	if err := j.Rollback(); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Dir(filepath.Dir(dst))); !os.IsNotExist(err) {
+		t.Fatalf("expected created dirs removed: %v", err)
+	}
+}
+
+func TestJournalRollbackKeepsLaterFiles(t *testing.T) {
+	src, dst := setupSyncDirs(t)
+	dst = filepath.Join(dst, "deep", "skills")
+	j, err := NewJournal(t.TempDir())
+	if err != nil {
+		t.Fatalf("journal: %v", err)
+	}
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictFail, Journal: j}); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	user := filepath.Join(filepath.Dir(dst), "notes.txt")
+	_ = os.WriteFile(user, []byte("mine"), 0o644)
+	if err := j.Rollback(); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	if data, err := os.ReadFile(user); err != nil || string(data) != "mine" {
+		t.Fatalf("expected later file kept: %q %v", data, err)
+	}
+	if _, err := os.Lstat(dst); !os.IsNotExist(err) {
+		t.Fatalf("expected created dest removed: %v", err)
+	}
+}
+
+func TestJournalDrifted(t *testing.T) {
+	src, dst := setupSyncDirs(t)
+	root := t.TempDir()
+	j, err := NewJournal(root)
+	if err != nil {
+		t.Fatalf("journal: %v", err)
+	}
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictOverwrite, Journal: j}); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if drifted, err := j.Drifted(); err != nil || len(drifted) != len(j.Entries()) {
+		t.Fatalf("expected unfinished run to report every path: %v %v", drifted, err)
+	}
+	if err := j.Finish(JournalCommitted); err != nil {
+		t.Fatalf("finish: %v", err)
+	}
+	reopened, err := OpenJournal(root, j.ID())
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if drifted, err := reopened.Drifted(); err != nil || len(drifted) != 0 {
+		t.Fatalf("unexpected drift: %v %v", drifted, err)
+	}
+	_ = os.WriteFile(filepath.Join(dst, "a.txt"), []byte("later"), 0o644)
+	drifted, err := reopened.Drifted()
+	if err != nil || !reflect.DeepEqual(drifted, []string{filepath.Join(dst, "a.txt")}) {
+		t.Fatalf("expected a.txt drifted: %v %v", drifted, err)
+	}
+}
+
+func TestJournalDryRunRecordsNothing(t *testing.T) {
+	src, dst := setupSyncDirs(t)
+	j, err := NewJournal(t.TempDir())
+	if err != nil {
+		t.Fatalf("journal: %v", err)
+	}
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictFail, DryRun: true, Journal: j}); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if len(j.Entries()) != 0 {
+		t.Fatalf("unexpected entries: %+v", j.Entries())
+	}
+}
+
+func TestJournalTrivialAndDiscard(t *testing.T) {
+	src, dst := setupSyncDirs(t)
+	opts := SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictFail, Commit: "abc"}
+	if _, err := SyncDir(src, dst, opts); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	j, err := NewJournal(t.TempDir())
+	if err != nil {
+		t.Fatalf("journal: %v", err)
+	}
+	opts.Journal = j
+	opts.Commit = "def"
+	if _, err := SyncDir(src, dst, opts); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if len(j.Entries()) != 1 || !j.Trivial() {
+		t.Fatalf("expected a manifest-only journal: %+v", j.Entries())
+	}
+	_ = os.WriteFile(filepath.Join(src, "a.txt"), []byte("a2"), 0o644)
+	if _, err := SyncDir(src, dst, opts); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if j.Trivial() {
+		t.Fatalf("file changes are not trivial")
+	}
+	if err := j.Discard(); err != nil {
+		t.Fatalf("discard: %v", err)
+	}
+	if _, err := os.Stat(j.Dir); !os.IsNotExist(err) {
+		t.Fatalf("expected journal removed: %v", err)
+	}
+	var none *Journal
+	if !none.Trivial() {
+		t.Fatalf("nil journal should be trivial")
+	}
+}
+
+func TestListAndPruneJournals(t *testing.T) {
+	root := t.TempDir()
+	if journals, err := ListJournals(filepath.Join(root, "missing")); err != nil || journals != nil {
+		t.Fatalf("expected empty list: %v %v", journals, err)
+	}
+	orig := timeNow
+	defer func() { timeNow = orig }()
+	var ids []string
+	for i := 0; i < 3; i++ {
+		at := time.Date(2024, 1, 1, 0, 0, i, 0, time.UTC)
+		timeNow = func() time.Time { return at }
+		j, err := NewJournal(root)
+		if err != nil {
+			t.Fatalf("journal: %v", err)
+		}
+		_ = j.Finish(JournalCommitted)
+		ids = append(ids, j.Meta.ID)
+	}
+	_ = os.MkdirAll(filepath.Join(root, "junk"), 0o755)
+	_ = os.WriteFile(filepath.Join(root, "file"), []byte("x"), 0o644)
+	if err := PruneJournals(root, 2); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	journals, _ := ListJournals(root)
+	if len(journals) != 2 || journals[0].ID != ids[2] || journals[1].ID != ids[1] {
+		t.Fatalf("unexpected journals: %+v", journals)
+	}
+}
+
+func TestJournalErrors(t *testing.T) {
+	root := t.TempDir()
+	if _, err := OpenJournal(root, "missing"); err == nil {
+		t.Fatalf("expected missing journal error")
+	}
+	_ = os.MkdirAll(filepath.Join(root, "bad"), 0o755)
+	_ = os.WriteFile(filepath.Join(root, "bad", journalMetaName), []byte("{"), 0o644)
+	if _, err := OpenJournal(root, "bad"); err == nil {
+		t.Fatalf("expected meta parse error")
+	}
+	_ = os.WriteFile(filepath.Join(root, "bad", journalMetaName), []byte("{}"), 0o644)
+	if _, err := OpenJournal(root, "bad"); err == nil {
+		t.Fatalf("expected missing entries error")
+	}
+	_ = os.WriteFile(filepath.Join(root, "bad", journalEntriesName), []byte("{\n"), 0o644)
+	if _, err := OpenJournal(root, "bad"); err == nil {
+		t.Fatalf("expected entry parse error")
+	}
+
+	h := snapshotHooks()
+	defer h.restore()
+	osMkdirAll = func(string, os.FileMode) error { return errors.New("mkdir") }
+	if _, err := NewJournal(root); err == nil {
+		t.Fatalf("expected mkdir error")
+	}
+	h.restore()
+
+	j, err := NewJournal(root)
+	if err != nil {
+		t.Fatalf("journal: %v", err)
+	}
+	osLstat = func(string) (os.FileInfo, error) { return nil, errors.New("lstat") }
+	if err := j.Record(filepath.Join(root, "x")); err == nil {
+		t.Fatalf("expected lstat error")
+	}
+	h.restore()
+	var nilJournal *Journal
+	if err := nilJournal.Record("x"); err != nil {
+		t.Fatalf("nil journal should be a no-op: %v", err)
+	}
+}
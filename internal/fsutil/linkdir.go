@@ -0,0 +1,357 @@
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/TT-AIXion/github-kanri/internal/match"
+)
+
+const linkHashPrefix = "link:"
+
+func LinkEntries(files []string) []string {
+	seen := map[string]struct{}{}
+	var out []string
+	for _, rel := range files {
+		name, _, _ := strings.Cut(filepath.ToSlash(rel), "/")
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func linkTarget(src, dst string, relative bool) (string, error) {
+	if !relative {
+		return src, nil
+	}
+	absSrc, err := filepath.Abs(src)
+	if err != nil {
+		return "", err
+	}
+	absDst, err := filepath.Abs(dst)
+	if err != nil {
+		return "", err
+	}
+	return relPath(filepath.Dir(absDst), absSrc)
+}
+
+func linksTo(dst, src string) (bool, error) {
+	info, err := osLstat(dst)
+	if err != nil {
+		return false, err
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		return false, nil
+	}
+	target, err := osReadlink(dst)
+	if err != nil {
+		return false, err
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(dst), target)
+	}
+	if filepath.Clean(target) == filepath.Clean(src) {
+		return true, nil
+	}
+	resolved, err := filepath.EvalSymlinks(target)
+	if err != nil {
+		return false, nil
+	}
+	want, err := filepath.EvalSymlinks(src)
+	if err != nil {
+		return false, nil
+	}
+	return resolved == want, nil
+}
+
+func syncLinkDirs(srcRoot, destRoot string, files []string, prev, next Manifest, opts SyncOptions) (SyncReport, error) {
+	var report SyncReport
+	for _, f := range sourceFiles(srcRoot, files, opts.Template) {
+		if f.Render {
+			return report, fmt.Errorf("%s: templates are not supported in %s mode", f.Rel, ModeLinkDir)
+		}
+	}
+	entries := LinkEntries(files)
+	linked := make(map[string]struct{}, len(entries))
+	for _, name := range entries {
+		linked[name] = struct{}{}
+		src := filepath.Join(srcRoot, filepath.FromSlash(name))
+		dst := filepath.Join(destRoot, filepath.FromSlash(name))
+		target, err := linkTarget(src, dst, opts.Relative)
+		if err != nil {
+			return report, opts.abortManifest(destRoot, prev, next, err)
+		}
+		recorded := prev.Files[name]
+		action, err := syncLinkEntry(src, dst, target, recorded, destRoot, name, prev, opts)
+		if err != nil {
+			return report, opts.abortManifest(destRoot, prev, next, err)
+		}
+		report.Changes = append(report.Changes, FileChange{Path: name, Action: action})
+		if opts.DryRun {
+			continue
+		}
+		if action == ActionKept {
+			if recorded != "" {
+				next.Files[name] = recorded
+			}
+			continue
+		}
+		next.Files[name] = linkHashPrefix + target
+	}
+	for _, rel := range prev.Paths() {
+		if _, ok := next.Files[rel]; ok {
+			continue
+		}
+		top, _, _ := strings.Cut(rel, "/")
+		if _, ok := linked[top]; ok {
+			continue
+		}
+		dst := filepath.Join(destRoot, filepath.FromSlash(rel))
+		recorded := prev.Files[rel]
+		if target, ok := strings.CutPrefix(recorded, linkHashPrefix); ok {
+			if current, err := osReadlink(dst); err == nil && current == target {
+				report.Changes = append(report.Changes, FileChange{Path: rel, Action: ActionRemoved})
+				if err := opts.removePath(dst); err != nil {
+					return report, err
+				}
+			}
+			continue
+		}
+		if _, err := osLstat(dst); err == nil {
+			next.Files[rel] = recorded
+		}
+	}
+	if err := opts.writeManifest(destRoot, next); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+func syncLinkEntry(src, dst, target, recorded, destRoot, rel string, prev Manifest, opts SyncOptions) (FileAction, error) {
+	dstInfo, err := osLstat(dst)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+		return ActionCreated, opts.linkFile(target, dst)
+	}
+	replace := opts.ConflictPolicy == ConflictOverwrite
+	if dstInfo.Mode()&os.ModeSymlink != 0 {
+		current, err := osReadlink(dst)
+		if err != nil {
+			return "", err
+		}
+		if current == target {
+			return ActionUnchanged, nil
+		}
+		if !replace {
+			replace = recorded == linkHashPrefix+current
+		}
+		if !replace {
+			if replace, err = linksTo(dst, src); err != nil {
+				return "", err
+			}
+		}
+	} else if !replace {
+		if replace, err = pristineTree(destRoot, rel, prev); err != nil {
+			return "", err
+		}
+	}
+	if !replace {
+		if opts.ConflictPolicy == ConflictKeepLocal {
+			return ActionKept, nil
+		}
+		return "", fmt.Errorf("conflict detected: %s", dst)
+	}
+	if err := opts.removeBase(destRoot, rel); err != nil {
+		return "", err
+	}
+	return ActionUpdated, opts.replaceWithLink(target, dst, dstInfo)
+}
+
+func unlinkDirs(destRoot string, prev Manifest, opts SyncOptions) error {
+	for rel, recorded := range prev.Files {
+		target, ok := strings.CutPrefix(recorded, linkHashPrefix)
+		if !ok {
+			continue
+		}
+		dst := filepath.Join(destRoot, filepath.FromSlash(rel))
+		if current, err := osReadlink(dst); err == nil && current == target {
+			if err := opts.removePath(dst); err != nil {
+				return err
+			}
+		}
+		delete(prev.Files, rel)
+	}
+	return nil
+}
+
+func pristineTree(destRoot, rel string, prev Manifest) (bool, error) {
+	pristine := true
+	err := walkDir(filepath.Join(destRoot, filepath.FromSlash(rel)), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		sub, err := relPath(destRoot, path)
+		if err != nil {
+			return err
+		}
+		ok, err := unmodified(path, prev.Files[filepath.ToSlash(sub)])
+		if err != nil {
+			return err
+		}
+		if !ok {
+			pristine = false
+			return fs.SkipAll
+		}
+		return nil
+	})
+	return pristine, err
+}
+
+type LinkIssue string
+
+const (
+	LinkDangling    LinkIssue = "dangling"
+	LinkWrongTarget LinkIssue = "wrong-target"
+	LinkNotLink     LinkIssue = "not-link"
+)
+
+type DirDiff struct {
+	Added       []string
+	Removed     []string
+	Changed     []string
+	Dangling    []string
+	WrongTarget []string
+	NotLink     []string
+}
+
+func (d DirDiff) Clean() bool {
+	return len(d.Added)+len(d.Removed)+len(d.Changed)+len(d.Dangling)+len(d.WrongTarget)+len(d.NotLink) == 0
+}
+
+func checkLink(dst, src string) (LinkIssue, error) {
+	info, err := osLstat(dst)
+	if err != nil {
+		return "", err
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		return LinkNotLink, nil
+	}
+	if _, err := os.Stat(dst); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return LinkDangling, nil
+		}
+		return "", err
+	}
+	ok, err := linksTo(dst, src)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return LinkWrongTarget, nil
+	}
+	return "", nil
+}
+
+func renderedDrift(f sourceFile, dst string, tmpl *Template) (bool, error) {
+	info, err := osLstat(dst)
+	if err != nil {
+		return false, err
+	}
+	if !info.Mode().IsRegular() {
+		return true, nil
+	}
+	srcHash, _, err := f.digest(tmpl)
+	if err != nil {
+		return false, err
+	}
+	destHash, err := FileHash(dst)
+	if err != nil {
+		return false, err
+	}
+	return srcHash != destHash, nil
+}
+
+func DiffLinks(srcRoot, destRoot string, include []string, exclude []string, mode SyncMode, tmpl *Template) (DirDiff, error) {
+	var d DirDiff
+	files, err := ListFiles(srcRoot, include, exclude)
+	if err != nil {
+		return d, err
+	}
+	sources := sourceFiles(srcRoot, files, tmpl)
+	if mode == ModeLinkDir {
+		sources = sourceFiles(srcRoot, LinkEntries(files), nil)
+	}
+	expected := make(map[string]struct{}, len(sources))
+	for _, f := range sources {
+		expected[f.Dest] = struct{}{}
+		dst := filepath.Join(destRoot, filepath.FromSlash(f.Dest))
+		if f.Render {
+			changed, err := renderedDrift(f, dst, tmpl)
+			switch {
+			case errors.Is(err, os.ErrNotExist):
+				d.Added = append(d.Added, f.Dest)
+			case err != nil:
+				return d, err
+			case changed:
+				d.Changed = append(d.Changed, f.Dest)
+			}
+			continue
+		}
+		issue, err := checkLink(dst, f.Path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				d.Added = append(d.Added, f.Dest)
+				continue
+			}
+			return d, err
+		}
+		switch issue {
+		case LinkDangling:
+			d.Dangling = append(d.Dangling, f.Dest)
+		case LinkWrongTarget:
+			d.WrongTarget = append(d.WrongTarget, f.Dest)
+		case LinkNotLink:
+			d.NotLink = append(d.NotLink, f.Dest)
+		}
+	}
+	var present []string
+	if mode == ModeLinkDir {
+		dirents, err := os.ReadDir(destRoot)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return d, err
+		}
+		for _, e := range dirents {
+			name := e.Name()
+			if name == BaseDirName || isSyncMetadata(name) || match.Any(exclude, name) || match.Any(exclude, name+"/") {
+				continue
+			}
+			present = append(present, name)
+		}
+	} else {
+		present, err = ListFiles(destRoot, include, exclude)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return d, err
+		}
+	}
+	for _, rel := range present {
+		if _, ok := expected[rel]; !ok {
+			d.Removed = append(d.Removed, rel)
+		}
+	}
+	sort.Strings(d.Removed)
+	return d, nil
+}
@@ -0,0 +1,336 @@
+package fsutil
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func setupLinkDirs(t *testing.T) (string, string) {
+	t.Helper()
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	dst := filepath.Join(root, "repo", "dst")
+	_ = os.MkdirAll(filepath.Join(src, "alpha"), 0o755)
+	_ = os.MkdirAll(filepath.Join(src, "beta", "refs"), 0o755)
+	_ = os.WriteFile(filepath.Join(src, "alpha", "SKILL.md"), []byte("alpha"), 0o644)
+	_ = os.WriteFile(filepath.Join(src, "beta", "refs", "doc.md"), []byte("beta"), 0o644)
+	_ = os.WriteFile(filepath.Join(src, "README.md"), []byte("readme"), 0o644)
+	return src, dst
+}
+
+func TestLinkEntries(t *testing.T) {
+	got := LinkEntries([]string{"b/x", "a/y", "b/z", "top.md"})
+	if len(got) != 3 || got[0] != "a" || got[1] != "b" || got[2] != "top.md" {
+		t.Fatalf("unexpected entries: %v", got)
+	}
+}
+
+func TestSyncDirLinkDir(t *testing.T) {
+	src, dst := setupLinkDirs(t)
+	opts := SyncOptions{Mode: ModeLinkDir, ConflictPolicy: ConflictFail}
+	report, err := SyncDir(src, dst, opts)
+	if err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if report.Count(ActionCreated) != 3 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	target, err := os.Readlink(filepath.Join(dst, "alpha"))
+	if err != nil || target != filepath.Join(src, "alpha") {
+		t.Fatalf("expected absolute dir link: %q %v", target, err)
+	}
+	m, _, _ := ReadManifest(dst)
+	if m.Mode != ModeLinkDir || len(m.Paths()) != 3 || !m.Managed("beta") {
+		t.Fatalf("unexpected manifest: %+v", m)
+	}
+
+	_ = os.WriteFile(filepath.Join(src, "alpha", "new.md"), []byte("new"), 0o644)
+	d, err := DiffLinks(src, dst, nil, nil, ModeLinkDir, nil)
+	if err != nil || !d.Clean() {
+		t.Fatalf("new upstream files should need no sync: %+v %v", d, err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "alpha", "new.md")); err != nil {
+		t.Fatalf("expected file visible through link: %v", err)
+	}
+
+	report, err = SyncDir(src, dst, SyncOptions{Mode: ModeLinkDir, ConflictPolicy: ConflictFail, Relative: true})
+	if err != nil || report.Count(ActionUpdated) != 3 {
+		t.Fatalf("expected relink: %+v %v", report, err)
+	}
+	target, _ = os.Readlink(filepath.Join(dst, "alpha"))
+	if filepath.IsAbs(target) {
+		t.Fatalf("expected relative link: %s", target)
+	}
+	if data, err := os.ReadFile(filepath.Join(dst, "alpha", "SKILL.md")); err != nil || string(data) != "alpha" {
+		t.Fatalf("relative link broken: %v", err)
+	}
+	report, err = SyncDir(src, dst, SyncOptions{Mode: ModeLinkDir, ConflictPolicy: ConflictFail, Relative: true})
+	if err != nil || report.Count(ActionUnchanged) != 3 {
+		t.Fatalf("expected unchanged: %+v %v", report, err)
+	}
+
+	_ = os.RemoveAll(filepath.Join(src, "beta"))
+	report, err = SyncDir(src, dst, SyncOptions{Mode: ModeLinkDir, ConflictPolicy: ConflictFail, Relative: true})
+	if err != nil || report.Count(ActionRemoved) != 1 {
+		t.Fatalf("expected stale link removed: %+v %v", report, err)
+	}
+	if _, err := os.Lstat(filepath.Join(dst, "beta")); !os.IsNotExist(err) {
+		t.Fatalf("expected beta link removed")
+	}
+}
+
+func TestSyncDirLinkDirConflicts(t *testing.T) {
+	src, dst := setupLinkDirs(t)
+	_ = os.MkdirAll(filepath.Join(dst, "alpha"), 0o755)
+	_ = os.WriteFile(filepath.Join(dst, "alpha", "SKILL.md"), []byte("local"), 0o644)
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeLinkDir, ConflictPolicy: ConflictFail}); err == nil {
+		t.Fatalf("expected conflict on local dir")
+	}
+	report, err := SyncDir(src, dst, SyncOptions{Mode: ModeLinkDir, ConflictPolicy: ConflictKeepLocal})
+	if err != nil || report.Count(ActionKept) != 1 {
+		t.Fatalf("expected kept: %+v %v", report, err)
+	}
+	if info, _ := os.Lstat(filepath.Join(dst, "alpha")); !info.IsDir() {
+		t.Fatalf("local dir should be kept")
+	}
+	_ = os.Remove(filepath.Join(dst, "README.md"))
+	_ = os.Symlink(filepath.Join(t.TempDir()), filepath.Join(dst, "README.md"))
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeLinkDir, ConflictPolicy: ConflictFail}); err == nil {
+		t.Fatalf("expected conflict on foreign link")
+	}
+	report, err = SyncDir(src, dst, SyncOptions{Mode: ModeLinkDir, ConflictPolicy: ConflictOverwrite})
+	if err != nil || report.Count(ActionUpdated) != 2 {
+		t.Fatalf("expected overwrite: %+v %v", report, err)
+	}
+	if ok, err := linksTo(filepath.Join(dst, "alpha"), filepath.Join(src, "alpha")); err != nil || !ok {
+		t.Fatalf("expected alpha linked: %v", err)
+	}
+}
+
+func TestSyncDirLinkDirReplacesPristineCopy(t *testing.T) {
+	src, dst := setupLinkDirs(t)
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictFail}); err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	report, err := SyncDir(src, dst, SyncOptions{Mode: ModeLinkDir, ConflictPolicy: ConflictFail})
+	if err != nil || report.Count(ActionUpdated) != 3 {
+		t.Fatalf("expected pristine copies replaced: %+v %v", report, err)
+	}
+	if _, err := os.Stat(testBasePath(t, dst, "alpha")); !os.IsNotExist(err) {
+		t.Fatalf("expected base copies removed")
+	}
+	m, _, _ := ReadManifest(dst)
+	if m.Managed("alpha/SKILL.md") || !m.Managed("alpha") {
+		t.Fatalf("unexpected manifest: %v", m.Paths())
+	}
+
+	report, err = SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictFail})
+	if err != nil || report.Count(ActionCreated) != 3 {
+		t.Fatalf("expected links replaced by copies: %+v %v", report, err)
+	}
+	if info, err := os.Lstat(filepath.Join(dst, "alpha")); err != nil || !info.IsDir() {
+		t.Fatalf("expected real dir after switching back: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(src, "alpha", "SKILL.md")); err != nil {
+		t.Fatalf("source must be untouched: %v", err)
+	}
+}
+
+func TestSyncDirLinkDirRejectsTemplates(t *testing.T) {
+	src, dst := setupLinkDirs(t)
+	_ = os.WriteFile(filepath.Join(src, "alpha", "x.tmpl"), []byte("x"), 0o644)
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeLinkDir, ConflictPolicy: ConflictFail, Template: &Template{}}); err == nil {
+		t.Fatalf("expected template error")
+	}
+}
+
+func TestDiffLinksLinkDir(t *testing.T) {
+	src, dst := setupLinkDirs(t)
+	d, err := DiffLinks(src, dst, nil, nil, ModeLinkDir, nil)
+	if err != nil || len(d.Added) != 3 {
+		t.Fatalf("expected all added: %+v %v", d, err)
+	}
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeLinkDir, ConflictPolicy: ConflictFail}); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	_ = os.Remove(filepath.Join(dst, "alpha"))
+	_ = os.MkdirAll(filepath.Join(dst, "alpha"), 0o755)
+	_ = os.Remove(filepath.Join(dst, "README.md"))
+	_ = os.Symlink(filepath.Join(src, "beta"), filepath.Join(dst, "README.md"))
+	_ = os.WriteFile(filepath.Join(dst, "local.md"), []byte("x"), 0o644)
+	_ = os.MkdirAll(filepath.Join(dst, ".git"), 0o755)
+	d, err = DiffLinks(src, dst, nil, []string{".git/**"}, ModeLinkDir, nil)
+	if err != nil || len(d.Added) != 0 || len(d.Removed) != 1 || d.Removed[0] != "local.md" {
+		t.Fatalf("unexpected diff: %+v %v", d, err)
+	}
+	if len(d.NotLink) != 1 || d.NotLink[0] != "alpha" || len(d.WrongTarget) != 1 || d.WrongTarget[0] != "README.md" {
+		t.Fatalf("unexpected link issues: %+v", d)
+	}
+	m, _, _ := ReadManifest(dst)
+	local, upstream, err := ClassifyChanges(src, dst, []string{"alpha"}, m, nil)
+	if err != nil || len(local) != 1 || len(upstream) != 0 {
+		t.Fatalf("expected local link change: %v %v %v", local, upstream, err)
+	}
+	moved := filepath.Join(t.TempDir(), "moved")
+	_ = os.Rename(src, moved)
+	d, err = DiffLinks(moved, dst, nil, nil, ModeLinkDir, nil)
+	if err != nil || len(d.Dangling) != 2 || d.Dangling[1] != "beta" {
+		t.Fatalf("expected dangling link after source moved: %+v %v", d, err)
+	}
+	_, upstream, _ = ClassifyChanges(moved, dst, []string{"beta"}, m, nil)
+	if len(upstream) != 1 {
+		t.Fatalf("expected upstream link change: %v", upstream)
+	}
+	if _, err := DiffLinks(filepath.Join(t.TempDir(), "missing"), dst, nil, nil, ModeLinkDir, nil); err == nil {
+		t.Fatalf("expected missing source error")
+	}
+}
+
+func TestDiffLinksFiles(t *testing.T) {
+	src, dst := setupLinkDirs(t)
+	_ = os.WriteFile(filepath.Join(src, "NOTE.md.tmpl"), []byte("{{ .Name }}"), 0o644)
+	tmpl := &Template{Data: map[string]string{"Name": "x"}}
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeLink, ConflictPolicy: ConflictFail, Template: tmpl}); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	d, err := DiffLinks(src, dst, nil, nil, ModeLink, tmpl)
+	if err != nil || !d.Clean() {
+		t.Fatalf("expected clean: %+v %v", d, err)
+	}
+	_ = os.Remove(filepath.Join(dst, "README.md"))
+	_ = os.Symlink(filepath.Join(src, "missing.md"), filepath.Join(dst, "README.md"))
+	_ = os.Remove(filepath.Join(dst, "alpha", "SKILL.md"))
+	_ = os.WriteFile(filepath.Join(dst, "alpha", "SKILL.md"), []byte("alpha"), 0o644)
+	_ = os.Remove(filepath.Join(dst, "beta", "refs", "doc.md"))
+	_ = os.Symlink(filepath.Join(src, "alpha", "SKILL.md"), filepath.Join(dst, "beta", "refs", "doc.md"))
+	_ = os.WriteFile(filepath.Join(dst, "NOTE.md"), []byte("y"), 0o644)
+	_ = os.WriteFile(filepath.Join(dst, "extra.md"), []byte("extra"), 0o644)
+	d, err = DiffLinks(src, dst, nil, nil, ModeLink, tmpl)
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	if len(d.Dangling) != 1 || d.Dangling[0] != "README.md" {
+		t.Fatalf("unexpected dangling: %+v", d)
+	}
+	if len(d.NotLink) != 1 || d.NotLink[0] != "alpha/SKILL.md" {
+		t.Fatalf("unexpected not-link: %+v", d)
+	}
+	if len(d.WrongTarget) != 1 || d.WrongTarget[0] != "beta/refs/doc.md" {
+		t.Fatalf("unexpected wrong-target: %+v", d)
+	}
+	if len(d.Changed) != 1 || d.Changed[0] != "NOTE.md" || len(d.Removed) != 1 || d.Removed[0] != "extra.md" {
+		t.Fatalf("unexpected changes: %+v", d)
+	}
+	_ = os.Remove(filepath.Join(dst, "NOTE.md"))
+	_ = os.MkdirAll(filepath.Join(dst, "NOTE.md"), 0o755)
+	if d, err = DiffLinks(src, dst, nil, nil, ModeLink, tmpl); err != nil || len(d.Changed) != 1 {
+		t.Fatalf("expected non-regular rendered file changed: %+v %v", d, err)
+	}
+	_ = os.RemoveAll(filepath.Join(dst, "NOTE.md"))
+	if d, err = DiffLinks(src, dst, nil, nil, ModeLink, tmpl); err != nil || len(d.Added) != 1 {
+		t.Fatalf("expected missing rendered file added: %+v %v", d, err)
+	}
+	if d, err = DiffLinks(src, filepath.Join(t.TempDir(), "none"), nil, nil, ModeLink, nil); err != nil || len(d.Added) != 4 {
+		t.Fatalf("expected all added for missing dest: %+v %v", d, err)
+	}
+	_ = os.WriteFile(filepath.Join(dst, "NOTE.md"), []byte("x"), 0o644)
+	if _, err := DiffLinks(src, dst, nil, nil, ModeLink, &Template{Data: map[string]string{}}); err == nil {
+		t.Fatalf("expected render error")
+	}
+}
+
+func TestDiffDirDanglingLink(t *testing.T) {
+	src, dst := setupLinkDirs(t)
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeLink, ConflictPolicy: ConflictFail}); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	_ = os.Remove(filepath.Join(dst, "README.md"))
+	_ = os.Symlink(filepath.Join(src, "missing.md"), filepath.Join(dst, "README.md"))
+	_, _, changed, err := DiffDir(src, dst, nil, nil)
+	if err != nil || len(changed) != 1 || changed[0] != "README.md" {
+		t.Fatalf("expected dangling link reported as changed: %v %v", changed, err)
+	}
+}
+
+func TestCleanDirLinkDir(t *testing.T) {
+	src, dst := setupLinkDirs(t)
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeLinkDir, ConflictPolicy: ConflictFail}); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if err := CleanDir(dst, []string{"alpha"}, false); err != nil {
+		t.Fatalf("clean: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(dst, "beta")); !os.IsNotExist(err) {
+		t.Fatalf("expected beta link removed")
+	}
+	if _, err := os.Stat(filepath.Join(src, "beta", "refs", "doc.md")); err != nil {
+		t.Fatalf("clean must not touch the source: %v", err)
+	}
+}
+
+func TestLinkDirErrors(t *testing.T) {
+	src, dst := setupLinkDirs(t)
+	t.Run("lstat", func(t *testing.T) {
+		h := snapshotHooks()
+		defer h.restore()
+		osLstat = func(string) (os.FileInfo, error) { return nil, errors.New("lstat") }
+		if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeLinkDir, ConflictPolicy: ConflictFail}); err == nil {
+			t.Fatalf("expected lstat error")
+		}
+		if _, err := DiffLinks(src, dst, nil, nil, ModeLinkDir, nil); err == nil {
+			t.Fatalf("expected diff lstat error")
+		}
+	})
+	t.Run("symlink", func(t *testing.T) {
+		h := snapshotHooks()
+		defer h.restore()
+		osSymlink = func(string, string) error { return errors.New("symlink") }
+		if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeLinkDir, ConflictPolicy: ConflictFail}); err == nil {
+			t.Fatalf("expected symlink error")
+		}
+	})
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeLinkDir, ConflictPolicy: ConflictFail}); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	t.Run("readlink", func(t *testing.T) {
+		h := snapshotHooks()
+		defer h.restore()
+		osReadlink = func(string) (string, error) { return "", errors.New("readlink") }
+		if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeLinkDir, ConflictPolicy: ConflictFail}); err == nil {
+			t.Fatalf("expected readlink error")
+		}
+		if _, err := DiffLinks(src, dst, nil, nil, ModeLinkDir, nil); err == nil {
+			t.Fatalf("expected diff readlink error")
+		}
+	})
+	t.Run("rel", func(t *testing.T) {
+		h := snapshotHooks()
+		defer h.restore()
+		relPath = func(string, string) (string, error) { return "", errors.New("rel") }
+		if _, err := linkTarget(src, dst, true); err == nil {
+			t.Fatalf("expected rel error")
+		}
+	})
+}
+
+func TestSyncDirRelativeFileLinks(t *testing.T) {
+	src, dst := setupLinkDirs(t)
+	report, err := SyncDir(src, dst, SyncOptions{Mode: ModeLink, ConflictPolicy: ConflictFail, Relative: true})
+	if err != nil || report.Count(ActionCreated) != 3 {
+		t.Fatalf("sync: %+v %v", report, err)
+	}
+	target, err := os.Readlink(filepath.Join(dst, "beta", "refs", "doc.md"))
+	if err != nil || target != filepath.Join("..", "..", "..", "..", "src", "beta", "refs", "doc.md") {
+		t.Fatalf("unexpected relative link: %q %v", target, err)
+	}
+	report, err = SyncDir(src, dst, SyncOptions{Mode: ModeLink, ConflictPolicy: ConflictFail, Relative: true})
+	if err != nil || report.Count(ActionUnchanged) != 3 {
+		t.Fatalf("expected unchanged: %+v %v", report, err)
+	}
+	report, err = SyncDir(src, dst, SyncOptions{Mode: ModeLink, ConflictPolicy: ConflictFail})
+	if err != nil || report.Count(ActionUpdated) != 3 {
+		t.Fatalf("expected switch to absolute: %+v %v", report, err)
+	}
+}
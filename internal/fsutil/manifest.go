@@ -0,0 +1,120 @@
+package fsutil
+
+import (
+	"encoding/json"
+	"errors"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const ManifestName = ".gkn-sync.json"
+
+type Manifest struct {
+	Target   string            `json:"target,omitempty"`
+	Source   string            `json:"source,omitempty"`
+	Commit   string            `json:"commit,omitempty"`
+	Mode     SyncMode          `json:"mode"`
+	SyncedAt time.Time         `json:"syncedAt"`
+	Files    map[string]string `json:"files"`
+}
+
+var timeNow = time.Now
+
+func (m Manifest) Managed(rel string) bool {
+	_, ok := m.Files[filepath.ToSlash(rel)]
+	return ok
+}
+
+func (m Manifest) Same(o Manifest) bool {
+	return m.Target == o.Target && m.Source == o.Source && m.Commit == o.Commit && m.Mode == o.Mode && maps.Equal(m.Files, o.Files)
+}
+
+func (m Manifest) Paths() []string {
+	out := make([]string, 0, len(m.Files))
+	for rel := range m.Files {
+		out = append(out, rel)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func ReadManifest(destRoot string) (Manifest, bool, error) {
+	data, err := os.ReadFile(filepath.Join(destRoot, ManifestName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return Manifest{}, false, nil
+		}
+		return Manifest{}, false, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return Manifest{}, false, err
+	}
+	if m.Files == nil {
+		m.Files = map[string]string{}
+	}
+	return m, true, nil
+}
+
+func WriteManifest(destRoot string, m Manifest, dryRun bool) error {
+	if dryRun {
+		return nil
+	}
+	if m.Files == nil {
+		m.Files = map[string]string{}
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := osMkdirAll(destRoot, 0o755); err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(destRoot, ManifestName), append(data, '\n'), 0o644)
+}
+
+func ClassifyChanges(srcRoot, destRoot string, changed []string, m Manifest, tmpl *Template) (local, upstream []string, err error) {
+	for _, rel := range changed {
+		recorded, ok := m.Files[rel]
+		if !ok {
+			continue
+		}
+		if target, ok := strings.CutPrefix(recorded, linkHashPrefix); ok {
+			if current, err := osReadlink(filepath.Join(destRoot, filepath.FromSlash(rel))); err != nil || current != target {
+				local = append(local, rel)
+			} else {
+				upstream = append(upstream, rel)
+			}
+			continue
+		}
+		destHash, err := FileHash(filepath.Join(destRoot, filepath.FromSlash(rel)))
+		if err != nil {
+			return nil, nil, err
+		}
+		srcHash, _, err := sourceFor(srcRoot, rel, tmpl).digest(tmpl)
+		if err != nil {
+			return nil, nil, err
+		}
+		if destHash != recorded {
+			local = append(local, rel)
+		}
+		if srcHash != recorded {
+			upstream = append(upstream, rel)
+		}
+	}
+	return local, upstream, nil
+}
+
+func isSyncMetadata(rel string) bool {
+	rel = filepath.ToSlash(rel)
+	if strings.HasPrefix(rel, BaseDirName+"/") {
+		return true
+	}
+	base := path.Base(rel)
+	return base == ManifestName || strings.HasSuffix(base, ConflictSuffix) || (strings.HasPrefix(base, ".") && strings.HasSuffix(base, ".gkn-tmp"))
+}
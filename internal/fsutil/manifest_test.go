@@ -0,0 +1,356 @@
+package fsutil
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func setupSyncDirs(t *testing.T) (string, string) {
+	t.Helper()
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	dst := filepath.Join(root, "dst")
+	_ = os.MkdirAll(src, 0o755)
+	_ = os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0o644)
+	_ = os.WriteFile(filepath.Join(src, "b.txt"), []byte("b"), 0o644)
+	return src, dst
+}
+
+func TestSyncDirWritesManifest(t *testing.T) {
+	src, dst := setupSyncDirs(t)
+	opts := SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictFail, Target: "skills", Source: "git@github.com:acme/skills.git", Commit: "abc123"}
+	if _, err := SyncDir(src, dst, opts); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	m, ok, err := ReadManifest(dst)
+	if err != nil || !ok {
+		t.Fatalf("read manifest: %v %v", ok, err)
+	}
+	if m.Target != "skills" || m.Source != opts.Source || m.Commit != "abc123" || m.Mode != ModeCopy || m.SyncedAt.IsZero() {
+		t.Fatalf("unexpected manifest: %+v", m)
+	}
+	if !m.Managed("a.txt") || !m.Managed("b.txt") || len(m.Paths()) != 2 {
+		t.Fatalf("unexpected files: %v", m.Paths())
+	}
+	files, err := ListFiles(dst, nil, nil)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("manifest should be hidden from listing: %v", files)
+	}
+}
+
+func TestSyncDirKeepsUnchangedManifest(t *testing.T) {
+	src, dst := setupSyncDirs(t)
+	opts := SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictFail, Target: "skills", Commit: "abc123"}
+	if _, err := SyncDir(src, dst, opts); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	before, _ := os.ReadFile(filepath.Join(dst, ManifestName))
+	orig := timeNow
+	timeNow = func() time.Time { return orig().Add(time.Hour) }
+	defer func() { timeNow = orig }()
+	if _, err := SyncDir(src, dst, opts); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if after, _ := os.ReadFile(filepath.Join(dst, ManifestName)); string(after) != string(before) {
+		t.Fatalf("unchanged sync rewrote the manifest:\n%s\n%s", before, after)
+	}
+	opts.Commit = "def456"
+	if _, err := SyncDir(src, dst, opts); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if m, _, _ := ReadManifest(dst); m.Commit != "def456" {
+		t.Fatalf("expected manifest updated for new commit: %+v", m)
+	}
+}
+
+func TestSyncDirRecordsWrittenFilesOnAbort(t *testing.T) {
+	src, dst := setupSyncDirs(t)
+	_ = os.MkdirAll(dst, 0o755)
+	_ = os.WriteFile(filepath.Join(dst, "b.txt"), []byte("mine"), 0o644)
+	opts := SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictFail, Commit: "c1"}
+	if _, err := SyncDir(src, dst, opts); err == nil {
+		t.Fatalf("expected conflict")
+	}
+	m, ok, err := ReadManifest(dst)
+	if err != nil || !ok || m.Commit != "c1" || !m.Managed("a.txt") || m.Managed("b.txt") {
+		t.Fatalf("expected the written file recorded: %+v %v %v", m, ok, err)
+	}
+
+	_ = os.Remove(filepath.Join(dst, "b.txt"))
+	if _, err := SyncDir(src, dst, opts); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	_ = os.WriteFile(filepath.Join(src, "a.txt"), []byte("a2"), 0o644)
+	_ = os.WriteFile(filepath.Join(src, "b.txt"), []byte("b2"), 0o644)
+	_ = os.WriteFile(filepath.Join(dst, "b.txt"), []byte("mine"), 0o644)
+	backdate(t, filepath.Join(dst, "b.txt"))
+	opts.Commit = "c2"
+	if _, err := SyncDir(src, dst, opts); err == nil {
+		t.Fatalf("expected conflict")
+	}
+	m, _, _ = ReadManifest(dst)
+	hash, _ := FileHash(filepath.Join(src, "a.txt"))
+	if m.Commit != "c1" || m.Files["a.txt"] != hash || m.Files["b.txt"] == "" {
+		t.Fatalf("expected updated hash under the old commit: %+v", m)
+	}
+	if local, _, err := ClassifyChanges(src, dst, []string{"a.txt", "b.txt"}, m, nil); err != nil || len(local) != 1 || local[0] != "b.txt" {
+		t.Fatalf("expected only b.txt locally modified: %v %v", local, err)
+	}
+}
+
+func TestSyncDirDryRunSkipsManifest(t *testing.T) {
+	src, dst := setupSyncDirs(t)
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictFail, DryRun: true}); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if _, ok, _ := ReadManifest(dst); ok {
+		t.Fatalf("expected no manifest in dry run")
+	}
+}
+
+func TestSyncDirUpdatesManagedFileWithoutConflict(t *testing.T) {
+	src, dst := setupSyncDirs(t)
+	opts := SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictFail}
+	if _, err := SyncDir(src, dst, opts); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	_ = os.WriteFile(filepath.Join(src, "a.txt"), []byte("upstream"), 0o644)
+	if _, err := SyncDir(src, dst, opts); err != nil {
+		t.Fatalf("expected upstream change to apply: %v", err)
+	}
+	_ = os.WriteFile(filepath.Join(src, "a.txt"), []byte("upstream2"), 0o644)
+	_ = os.WriteFile(filepath.Join(dst, "a.txt"), []byte("local"), 0o644)
+	if _, err := SyncDir(src, dst, opts); err == nil {
+		t.Fatalf("expected conflict on locally modified file")
+	}
+}
+
+func TestSyncDirLinkUpdatesManagedFile(t *testing.T) {
+	src, dst := setupSyncDirs(t)
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictFail}); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	_ = os.WriteFile(filepath.Join(src, "a.txt"), []byte("upstream"), 0o644)
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeLink, ConflictPolicy: ConflictFail}); err != nil {
+		t.Fatalf("expected managed file replaced by link: %v", err)
+	}
+}
+
+func TestMirrorKeepsUnmanagedFiles(t *testing.T) {
+	src, dst := setupSyncDirs(t)
+	opts := SyncOptions{Mode: ModeMirror, ConflictPolicy: ConflictOverwrite}
+	if _, err := SyncDir(src, dst, opts); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	_ = os.WriteFile(filepath.Join(dst, "local.txt"), []byte("mine"), 0o644)
+	_ = os.Remove(filepath.Join(src, "b.txt"))
+	report, err := SyncDir(src, dst, opts)
+	if err != nil {
+		t.Fatalf("mirror: %v", err)
+	}
+	if report.Count(ActionRemoved) != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "local.txt")); err != nil {
+		t.Fatalf("expected local file kept: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "b.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected managed file removed")
+	}
+	m, _, _ := ReadManifest(dst)
+	if m.Managed("b.txt") || m.Managed("local.txt") {
+		t.Fatalf("unexpected manifest: %v", m.Paths())
+	}
+}
+
+func TestCopyKeepsStaleManagedEntries(t *testing.T) {
+	src, dst := setupSyncDirs(t)
+	opts := SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictFail}
+	if _, err := SyncDir(src, dst, opts); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	_ = os.Remove(filepath.Join(src, "b.txt"))
+	if _, err := SyncDir(src, dst, opts); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	m, _, _ := ReadManifest(dst)
+	if !m.Managed("b.txt") {
+		t.Fatalf("expected stale file to stay managed")
+	}
+}
+
+func TestCleanDirWithManifest(t *testing.T) {
+	src, dst := setupSyncDirs(t)
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictFail}); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	_ = os.WriteFile(filepath.Join(dst, "local.txt"), []byte("mine"), 0o644)
+	if err := CleanDir(dst, []string{"a.txt"}, false); err != nil {
+		t.Fatalf("clean: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "local.txt")); err != nil {
+		t.Fatalf("expected local file kept: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "b.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected managed file removed")
+	}
+	if _, err := os.Stat(filepath.Join(dst, ManifestName)); err != nil {
+		t.Fatalf("expected manifest kept: %v", err)
+	}
+	m, _, _ := ReadManifest(dst)
+	if m.Managed("b.txt") || !m.Managed("a.txt") {
+		t.Fatalf("unexpected manifest: %v", m.Paths())
+	}
+}
+
+func TestClassifyChanges(t *testing.T) {
+	src, dst := setupSyncDirs(t)
+	_ = os.WriteFile(filepath.Join(src, "c.txt"), []byte("c"), 0o644)
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictFail}); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	_ = os.WriteFile(filepath.Join(dst, "a.txt"), []byte("local"), 0o644)
+	_ = os.WriteFile(filepath.Join(src, "b.txt"), []byte("upstream"), 0o644)
+	_ = os.WriteFile(filepath.Join(dst, "c.txt"), []byte("local"), 0o644)
+	_ = os.WriteFile(filepath.Join(src, "c.txt"), []byte("upstream"), 0o644)
+	_, _, changed, err := DiffDir(src, dst, nil, nil)
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	m, _, _ := ReadManifest(dst)
+	local, upstream, err := ClassifyChanges(src, dst, changed, m, nil)
+	if err != nil {
+		t.Fatalf("classify: %v", err)
+	}
+	if len(local) != 2 || local[0] != "a.txt" || local[1] != "c.txt" {
+		t.Fatalf("unexpected local: %v", local)
+	}
+	if len(upstream) != 2 || upstream[0] != "b.txt" || upstream[1] != "c.txt" {
+		t.Fatalf("unexpected upstream: %v", upstream)
+	}
+	if local, upstream, err := ClassifyChanges(src, dst, []string{"x.txt"}, m, nil); err != nil || local != nil || upstream != nil {
+		t.Fatalf("expected unmanaged file skipped")
+	}
+}
+
+func TestClassifyChangesErrors(t *testing.T) {
+	src, dst := setupSyncDirs(t)
+	m := Manifest{Files: map[string]string{"a.txt": "x", "missing.txt": "x"}}
+	if _, _, err := ClassifyChanges(src, dst, []string{"a.txt"}, m, nil); err == nil {
+		t.Fatalf("expected dest hash error")
+	}
+	_ = os.MkdirAll(dst, 0o755)
+	_ = os.WriteFile(filepath.Join(dst, "missing.txt"), []byte("x"), 0o644)
+	if _, _, err := ClassifyChanges(src, dst, []string{"missing.txt"}, m, nil); err == nil {
+		t.Fatalf("expected src hash error")
+	}
+}
+
+func TestReadManifestErrors(t *testing.T) {
+	dst := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dst, ManifestName), []byte("{"), 0o644)
+	if _, _, err := ReadManifest(dst); err == nil {
+		t.Fatalf("expected parse error")
+	}
+	src, _ := setupSyncDirs(t)
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictFail}); err == nil {
+		t.Fatalf("expected sync manifest error")
+	}
+	if err := CleanDir(dst, nil, false); err == nil {
+		t.Fatalf("expected clean manifest error")
+	}
+	_ = os.Remove(filepath.Join(dst, ManifestName))
+	_ = os.MkdirAll(filepath.Join(dst, ManifestName), 0o755)
+	if _, _, err := ReadManifest(dst); err == nil {
+		t.Fatalf("expected read error")
+	}
+	empty := t.TempDir()
+	_ = os.WriteFile(filepath.Join(empty, ManifestName), []byte("{}"), 0o644)
+	m, ok, err := ReadManifest(empty)
+	if err != nil || !ok || m.Files == nil {
+		t.Fatalf("expected empty manifest: %+v %v %v", m, ok, err)
+	}
+}
+
+func TestWriteManifestErrors(t *testing.T) {
+	dst := t.TempDir()
+	if err := WriteManifest(dst, Manifest{}, true); err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if _, ok, _ := ReadManifest(dst); ok {
+		t.Fatalf("expected no manifest in dry run")
+	}
+
+	t.Run("mkdir", func(t *testing.T) {
+		h := snapshotHooks()
+		defer h.restore()
+		osMkdirAll = func(string, os.FileMode) error { return errors.New("mkdir") }
+		if err := WriteManifest(dst, Manifest{}, false); err == nil {
+			t.Fatalf("expected mkdir error")
+		}
+	})
+
+	t.Run("write", func(t *testing.T) {
+		blocked := filepath.Join(t.TempDir(), "dst")
+		_ = os.MkdirAll(filepath.Join(blocked, "."+ManifestName+".gkn-tmp"), 0o755)
+		_ = os.WriteFile(filepath.Join(blocked, "."+ManifestName+".gkn-tmp", "x"), []byte("x"), 0o644)
+		if err := WriteManifest(blocked, Manifest{}, false); err == nil {
+			t.Fatalf("expected write error")
+		}
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		h := snapshotHooks()
+		defer h.restore()
+		osRename = func(string, string) error { return errors.New("rename") }
+		if err := WriteManifest(dst, Manifest{}, false); err == nil {
+			t.Fatalf("expected rename error")
+		}
+		if _, err := os.Stat(tempPath(filepath.Join(dst, ManifestName))); !os.IsNotExist(err) {
+			t.Fatalf("expected temp cleanup")
+		}
+	})
+}
+
+func TestSyncDirPaths(t *testing.T) {
+	src, dst := setupSyncDirs(t)
+	_ = os.MkdirAll(filepath.Join(src, "dir"), 0o755)
+	_ = os.WriteFile(filepath.Join(src, "dir", "c.txt"), []byte("c"), 0o644)
+	opts := SyncOptions{Mode: ModeMirror, ConflictPolicy: ConflictOverwrite}
+	if _, err := SyncDir(src, dst, opts); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	_ = os.WriteFile(filepath.Join(src, "a.txt"), []byte("a2"), 0o644)
+	_ = os.WriteFile(filepath.Join(src, "b.txt"), []byte("b2"), 0o644)
+	_ = os.RemoveAll(filepath.Join(src, "dir"))
+	opts.Paths = []string{"a.txt", "dir/"}
+	report, err := SyncDir(src, dst, opts)
+	if err != nil {
+		t.Fatalf("partial sync: %v", err)
+	}
+	if len(report.Changes) != 2 || report.Changes[0] != (FileChange{Path: "a.txt", Action: ActionUpdated}) || report.Changes[1] != (FileChange{Path: "dir/c.txt", Action: ActionRemoved}) {
+		t.Fatalf("unexpected changes: %+v", report.Changes)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "b.txt")); string(data) != "b" {
+		t.Fatalf("b.txt should not be synced: %s", data)
+	}
+	m, _, _ := ReadManifest(dst)
+	if !m.Managed("b.txt") || m.Managed("dir/c.txt") {
+		t.Fatalf("unexpected manifest: %v", m.Paths())
+	}
+	opts.Paths = []string{"."}
+	if report, err := SyncDir(src, dst, opts); err != nil || report.Count(ActionUpdated) != 1 {
+		t.Fatalf("expected full sync: %+v %v", report, err)
+	}
+	opts.ConflictPolicy = ConflictFail
+	opts.Paths = []string{"a.txt"}
+	if _, err := SyncDir(src, dst, opts); err == nil {
+		t.Fatalf("expected mirror policy error")
+	}
+}
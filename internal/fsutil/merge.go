@@ -0,0 +1,238 @@
+package fsutil
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+
+	"github.com/TT-AIXion/github-kanri/internal/config"
+)
+
+const (
+	BaseDirName    = ".gkn-sync-base"
+	ConflictSuffix = ".gkn-conflict"
+)
+
+const (
+	markerLocal    = "<<<<<<< local\n"
+	markerSep      = "=======\n"
+	markerUpstream = ">>>>>>> upstream\n"
+)
+
+func IsBinary(data []byte) bool {
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+func Merge3(base, local, upstream []byte) ([]byte, bool) {
+	o := splitLines(base)
+	a := splitLines(local)
+	b := splitLines(upstream)
+	aIdx := matchLines(o, a)
+	bIdx := matchLines(o, b)
+	var out bytes.Buffer
+	conflict := false
+	i, ja, jb := 0, 0, 0
+	for {
+		k := i
+		for k < len(o) && (aIdx[k] < 0 || bIdx[k] < 0) {
+			k++
+		}
+		if k == i && k < len(o) && aIdx[k] == ja && bIdx[k] == jb {
+			out.WriteString(o[k])
+			i, ja, jb = i+1, ja+1, jb+1
+			continue
+		}
+		aEnd, bEnd := len(a), len(b)
+		if k < len(o) {
+			aEnd, bEnd = aIdx[k], bIdx[k]
+		}
+		oc, ac, bc := o[i:k], a[ja:aEnd], b[jb:bEnd]
+		switch {
+		case equalLines(ac, oc):
+			writeLines(&out, bc)
+		case equalLines(bc, oc), equalLines(ac, bc):
+			writeLines(&out, ac)
+		default:
+			conflict = true
+			out.WriteString(markerLocal)
+			writeLines(&out, ac)
+			terminate(&out)
+			out.WriteString(markerSep)
+			writeLines(&out, bc)
+			terminate(&out)
+			out.WriteString(markerUpstream)
+		}
+		if k == len(o) {
+			break
+		}
+		i, ja, jb = k, aEnd, bEnd
+	}
+	return out.Bytes(), conflict
+}
+
+func splitLines(data []byte) []string {
+	var lines []string
+	for len(data) > 0 {
+		idx := bytes.IndexByte(data, '\n')
+		if idx < 0 {
+			lines = append(lines, string(data))
+			break
+		}
+		lines = append(lines, string(data[:idx+1]))
+		data = data[idx+1:]
+	}
+	return lines
+}
+
+func matchLines(o, a []string) []int {
+	n, m := len(o), len(a)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if o[i] == a[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	idx := make([]int, n)
+	for i := range idx {
+		idx[i] = -1
+	}
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case o[i] == a[j]:
+			idx[i] = j
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return idx
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func writeLines(out *bytes.Buffer, lines []string) {
+	for _, l := range lines {
+		out.WriteString(l)
+	}
+}
+
+func terminate(out *bytes.Buffer) {
+	if out.Len() > 0 && out.Bytes()[out.Len()-1] != '\n' {
+		out.WriteByte('\n')
+	}
+}
+
+var stateDir = config.DefaultStateDir
+
+func baseDir(destRoot string) (string, error) {
+	state, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(destRoot)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(state, "sync-base", hex.EncodeToString(sum[:8])), nil
+}
+
+func basePath(destRoot, rel string) (string, error) {
+	dir, err := baseDir(destRoot)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, filepath.FromSlash(rel)), nil
+}
+
+func mergeFile(src, dst, base string, dryRun bool) (FileAction, error) {
+	upstream, err := os.ReadFile(src)
+	if err != nil {
+		return "", err
+	}
+	return mergeContent(upstream, dst, base, dryRun)
+}
+
+func mergeContent(upstream []byte, dst, base string, dryRun bool) (FileAction, error) {
+	local, err := os.ReadFile(dst)
+	if err != nil {
+		return "", err
+	}
+	sidecar := dst + ConflictSuffix
+	if bytes.Contains(local, []byte(markerLocal)) && bytes.Contains(local, []byte(markerUpstream)) {
+		return ActionConflict, nil
+	}
+	baseData, err := os.ReadFile(base)
+	if err == nil && bytes.Equal(baseData, upstream) {
+		return ActionKept, nil
+	}
+	if err != nil || IsBinary(upstream) || IsBinary(local) || IsBinary(baseData) {
+		if !dryRun {
+			if err := writeFileAtomic(sidecar, upstream, 0o644); err != nil {
+				return "", err
+			}
+		}
+		return ActionConflict, nil
+	}
+	merged, conflict := Merge3(baseData, local, upstream)
+	if !dryRun {
+		info, err := os.Stat(dst)
+		if err != nil {
+			return "", err
+		}
+		if err := writeFileAtomic(dst, merged, info.Mode().Perm()); err != nil {
+			return "", err
+		}
+		if !conflict {
+			_ = os.Remove(sidecar)
+		}
+	}
+	if conflict {
+		return ActionConflict, nil
+	}
+	return ActionMerged, nil
+}
+
+func writeFileAtomicDir(path string, data []byte, perm os.FileMode) error {
+	if err := osMkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, perm)
+}
+
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp := tempPath(path)
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		return err
+	}
+	if err := osRename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
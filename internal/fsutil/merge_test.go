@@ -0,0 +1,296 @@
+package fsutil
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMerge3(t *testing.T) {
+	base := "a\nb\nc\nd\n"
+	cases := []struct {
+		name     string
+		local    string
+		upstream string
+		want     string
+		conflict bool
+	}{
+		{"upstream only", base, "a\nB\nc\nd\n", "a\nB\nc\nd\n", false},
+		{"local only", "a\nb\nC\nd\n", base, "a\nb\nC\nd\n", false},
+		{"disjoint", "A\nb\nc\nd\n", "a\nb\nc\nD\n", "A\nb\nc\nD\n", false},
+		{"same change", "a\nX\nc\nd\n", "a\nX\nc\nd\n", "a\nX\nc\nd\n", false},
+		{"insert and append", "a\nb\nnew\nc\nd\n", "a\nb\nc\nd\ne\n", "a\nb\nnew\nc\nd\ne\n", false},
+		{"conflict", "a\nL\nc\nd\n", "a\nU\nc\nd\n", "a\n<<<<<<< local\nL\n=======\nU\n>>>>>>> upstream\nc\nd\n", true},
+		{"conflict no newline", "a\nb\nc\nL", "a\nb\nc\nU", "a\nb\nc\n<<<<<<< local\nL\n=======\nU\n>>>>>>> upstream\n", true},
+	}
+	for _, tc := range cases {
+		got, conflict := Merge3([]byte(base), []byte(tc.local), []byte(tc.upstream))
+		if string(got) != tc.want || conflict != tc.conflict {
+			t.Fatalf("%s: got %q conflict=%v", tc.name, got, conflict)
+		}
+	}
+	got, conflict := Merge3(nil, []byte("x\n"), []byte("x\n"))
+	if string(got) != "x\n" || conflict {
+		t.Fatalf("empty base: got %q", got)
+	}
+}
+
+func backdate(t *testing.T, path string) {
+	t.Helper()
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+}
+
+func TestIsBinary(t *testing.T) {
+	if IsBinary([]byte("text\n")) || !IsBinary([]byte{'a', 0, 'b'}) {
+		t.Fatalf("unexpected binary detection")
+	}
+}
+
+func TestSyncDirMergePolicy(t *testing.T) {
+	src, dst := setupSyncDirs(t)
+	_ = os.WriteFile(filepath.Join(src, "a.txt"), []byte("one\ntwo\nthree\n"), 0o644)
+	opts := SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictMerge}
+	if _, err := SyncDir(src, dst, opts); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if data, err := os.ReadFile(testBasePath(t, dst, "a.txt")); err != nil || string(data) != "one\ntwo\nthree\n" {
+		t.Fatalf("expected base copy: %q %v", data, err)
+	}
+	files, _ := ListFiles(dst, nil, nil)
+	if len(files) != 2 {
+		t.Fatalf("base dir should be hidden: %v", files)
+	}
+
+	_ = os.WriteFile(filepath.Join(dst, "a.txt"), []byte("ONE\ntwo\nthree\n"), 0o644)
+	_ = os.WriteFile(filepath.Join(src, "a.txt"), []byte("one\ntwo\nTHREE\n"), 0o644)
+	backdate(t, filepath.Join(dst, "a.txt"))
+	report, err := SyncDir(src, dst, opts)
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	if report.Count(ActionMerged) != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "a.txt")); string(data) != "ONE\ntwo\nTHREE\n" {
+		t.Fatalf("unexpected merge: %q", data)
+	}
+
+	report, err = SyncDir(src, dst, opts)
+	if err != nil || report.Count(ActionKept) != 1 {
+		t.Fatalf("expected local-only change kept: %+v %v", report, err)
+	}
+
+	_ = os.WriteFile(filepath.Join(src, "a.txt"), []byte("uno\ntwo\nTHREE\n"), 0o644)
+	backdate(t, filepath.Join(dst, "a.txt"))
+	report, err = SyncDir(src, dst, opts)
+	if err != nil || report.Count(ActionConflict) != 1 {
+		t.Fatalf("expected conflict: %+v %v", report, err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dst, "a.txt"))
+	if !strings.Contains(string(data), "<<<<<<< local\nONE\n=======\nuno\n>>>>>>> upstream\n") {
+		t.Fatalf("expected conflict markers: %q", data)
+	}
+	if base, _ := os.ReadFile(testBasePath(t, dst, "a.txt")); string(base) != "one\ntwo\nTHREE\n" {
+		t.Fatalf("base should stay until the conflict is resolved: %q", base)
+	}
+	backdate(t, filepath.Join(dst, "a.txt"))
+	report, err = SyncDir(src, dst, opts)
+	if err != nil || report.Count(ActionConflict) != 1 {
+		t.Fatalf("expected unresolved conflict reported again: %+v %v", report, err)
+	}
+	if again, _ := os.ReadFile(filepath.Join(dst, "a.txt")); string(again) != string(data) {
+		t.Fatalf("unresolved conflict should not be merged again: %q", again)
+	}
+
+	_ = os.WriteFile(filepath.Join(dst, "a.txt"), []byte("uno\ntwo\nTHREE\n"), 0o644)
+	backdate(t, filepath.Join(dst, "a.txt"))
+	report, err = SyncDir(src, dst, opts)
+	if err != nil || report.Count(ActionUnchanged) != 2 {
+		t.Fatalf("expected resolved file unchanged: %+v %v", report, err)
+	}
+	if base, _ := os.ReadFile(testBasePath(t, dst, "a.txt")); string(base) != "uno\ntwo\nTHREE\n" {
+		t.Fatalf("expected base advanced after resolution: %q", base)
+	}
+}
+
+func TestSyncDirBaseOnlyForMerge(t *testing.T) {
+	src, dst := setupSyncDirs(t)
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictFail}); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if _, err := os.Stat(testBasePath(t, dst, ".")); !os.IsNotExist(err) {
+		t.Fatalf("expected no base without merge: %v", err)
+	}
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictMerge}); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if data, err := os.ReadFile(testBasePath(t, dst, "a.txt")); err != nil || string(data) != "a" {
+		t.Fatalf("expected base for merge: %q %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, BaseDirName)); !os.IsNotExist(err) {
+		t.Fatalf("expected bases outside the destination: %v", err)
+	}
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictOverwrite}); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if _, err := os.Stat(testBasePath(t, dst, "a.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected stale base removed: %v", err)
+	}
+}
+
+func TestSyncDirMergeBinaryAndMissingBase(t *testing.T) {
+	src, dst := setupSyncDirs(t)
+	opts := SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictMerge}
+	if _, err := SyncDir(src, dst, opts); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	_ = os.WriteFile(filepath.Join(dst, "a.txt"), []byte{'l', 0}, 0o644)
+	_ = os.WriteFile(filepath.Join(src, "a.txt"), []byte{'u', 0}, 0o644)
+	backdate(t, filepath.Join(dst, "a.txt"))
+	report, err := SyncDir(src, dst, opts)
+	if err != nil || report.Count(ActionConflict) != 1 {
+		t.Fatalf("expected binary conflict: %+v %v", report, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "a.txt"+ConflictSuffix)); string(data) != "u\x00" {
+		t.Fatalf("expected sidecar with upstream: %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "a.txt")); string(data) != "l\x00" {
+		t.Fatalf("expected local kept: %q", data)
+	}
+	if _, removed, _, _ := DiffDir(src, dst, nil, nil); len(removed) != 0 {
+		t.Fatalf("sidecar should be hidden: %v", removed)
+	}
+
+	_ = os.RemoveAll(testBasePath(t, dst, "."))
+	_ = os.WriteFile(filepath.Join(dst, "b.txt"), []byte("local"), 0o644)
+	_ = os.WriteFile(filepath.Join(src, "b.txt"), []byte("upstream"), 0o644)
+	report, err = SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictMerge, DryRun: true})
+	if err != nil || report.Count(ActionConflict) != 2 {
+		t.Fatalf("expected dry-run conflicts without base: %+v %v", report, err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "b.txt"+ConflictSuffix)); !os.IsNotExist(err) {
+		t.Fatalf("dry run should not write sidecar")
+	}
+}
+
+func TestSyncDirKeepLocalPolicy(t *testing.T) {
+	src, dst := setupSyncDirs(t)
+	opts := SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictKeepLocal}
+	if _, err := SyncDir(src, dst, opts); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	m, _, _ := ReadManifest(dst)
+	recorded := m.Files["a.txt"]
+	_ = os.WriteFile(filepath.Join(dst, "a.txt"), []byte("local"), 0o644)
+	_ = os.WriteFile(filepath.Join(src, "a.txt"), []byte("upstream"), 0o644)
+	_ = os.WriteFile(filepath.Join(src, "b.txt"), []byte("upstream b"), 0o644)
+	report, err := SyncDir(src, dst, opts)
+	if err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if report.Count(ActionKept) != 1 || report.Count(ActionUpdated) != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "a.txt")); string(data) != "local" {
+		t.Fatalf("expected local kept: %q", data)
+	}
+	m, _, _ = ReadManifest(dst)
+	if m.Files["a.txt"] != recorded {
+		t.Fatalf("expected recorded hash kept for local file")
+	}
+
+	_ = os.WriteFile(filepath.Join(dst, "c.txt"), []byte("unmanaged"), 0o644)
+	_ = os.WriteFile(filepath.Join(src, "c.txt"), []byte("upstream c"), 0o644)
+	if _, err := SyncDir(src, dst, opts); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	m, _, _ = ReadManifest(dst)
+	if m.Managed("c.txt") {
+		t.Fatalf("kept unmanaged file should stay unmanaged")
+	}
+
+	linkDst := filepath.Join(filepath.Dir(dst), "link")
+	_ = os.MkdirAll(linkDst, 0o755)
+	_ = os.WriteFile(filepath.Join(linkDst, "a.txt"), []byte("local"), 0o644)
+	report, err = SyncDir(src, linkDst, SyncOptions{Mode: ModeLink, ConflictPolicy: ConflictKeepLocal})
+	if err != nil || report.Count(ActionKept) != 1 {
+		t.Fatalf("expected link keep-local: %+v %v", report, err)
+	}
+	if _, err := SyncDir(src, linkDst, SyncOptions{Mode: ModeLink, ConflictPolicy: ConflictMerge}); err == nil {
+		t.Fatalf("expected merge conflict in link mode")
+	}
+}
+
+func TestMirrorAndCleanRemoveBase(t *testing.T) {
+	src, dst := setupSyncDirs(t)
+	opts := SyncOptions{Mode: ModeMirror, ConflictPolicy: ConflictOverwrite}
+	if _, err := SyncDir(src, dst, opts); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	_ = os.Remove(filepath.Join(src, "b.txt"))
+	if _, err := SyncDir(src, dst, opts); err != nil {
+		t.Fatalf("mirror: %v", err)
+	}
+	if _, err := os.Stat(testBasePath(t, dst, "b.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected base removed in mirror")
+	}
+	if err := CleanDir(dst, nil, false); err != nil {
+		t.Fatalf("clean: %v", err)
+	}
+	if _, err := os.Stat(testBasePath(t, dst, "a.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected base removed in clean")
+	}
+}
+
+func TestMergeFileErrors(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src.txt")
+	dst := filepath.Join(root, "dst.txt")
+	base := filepath.Join(root, "base.txt")
+	if _, err := mergeFile(src, dst, base, false); err == nil {
+		t.Fatalf("expected src read error")
+	}
+	_ = os.WriteFile(src, []byte("u\n"), 0o644)
+	if _, err := mergeFile(src, dst, base, false); err == nil {
+		t.Fatalf("expected dst read error")
+	}
+	_ = os.WriteFile(dst, []byte("l\n"), 0o644)
+	_ = os.WriteFile(base, []byte("b\n"), 0o644)
+
+	h := snapshotHooks()
+	defer h.restore()
+	osRename = func(string, string) error { return errors.New("rename") }
+	if _, err := mergeFile(src, dst, base, false); err == nil {
+		t.Fatalf("expected merge write error")
+	}
+	_ = os.Remove(base)
+	if _, err := mergeFile(src, dst, base, false); err == nil {
+		t.Fatalf("expected sidecar write error")
+	}
+}
+
+func TestSyncDirBaseStateError(t *testing.T) {
+	src, dst := setupSyncDirs(t)
+	orig := stateDir
+	stateDir = func() (string, error) { return "", os.ErrPermission }
+	defer func() { stateDir = orig }()
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictMerge}); err == nil {
+		t.Fatalf("expected state dir error")
+	}
+}
+
+func TestSyncDirBaseCopyError(t *testing.T) {
+	src, dst := setupSyncDirs(t)
+	base := testBasePath(t, dst, ".")
+	_ = os.MkdirAll(filepath.Dir(base), 0o755)
+	_ = os.WriteFile(base, []byte("blocker"), 0o644)
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictMerge}); err == nil {
+		t.Fatalf("expected base copy error")
+	}
+}
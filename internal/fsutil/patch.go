@@ -0,0 +1,249 @@
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	DefaultPatchContext       = 3
+	DefaultPatchLimit   int64 = 64 << 10
+)
+
+type PatchStatus string
+
+const (
+	PatchAdded   PatchStatus = "added"
+	PatchRemoved PatchStatus = "removed"
+	PatchChanged PatchStatus = "changed"
+)
+
+const noNewline = `\ No newline at end of file`
+
+type Hunk struct {
+	OldStart int      `json:"oldStart"`
+	OldLines int      `json:"oldLines"`
+	NewStart int      `json:"newStart"`
+	NewLines int      `json:"newLines"`
+	Lines    []string `json:"lines"`
+}
+
+func (h Hunk) Header() string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+}
+
+type FilePatch struct {
+	Path     string      `json:"path"`
+	Status   PatchStatus `json:"status"`
+	OldHash  string      `json:"oldHash,omitempty"`
+	NewHash  string      `json:"newHash,omitempty"`
+	Binary   bool        `json:"binary,omitempty"`
+	TooLarge bool        `json:"tooLarge,omitempty"`
+	Added    int         `json:"added"`
+	Deleted  int         `json:"deleted"`
+	Hunks    []Hunk      `json:"hunks,omitempty"`
+}
+
+type lineOp struct {
+	kind   byte
+	text   string
+	oldPos int
+	newPos int
+}
+
+func UnifiedDiff(oldData, newData []byte, context int) []Hunk {
+	ops := diffOps(splitLines(oldData), splitLines(newData))
+	var changes []int
+	for i, op := range ops {
+		if op.kind != ' ' {
+			changes = append(changes, i)
+		}
+	}
+	var hunks []Hunk
+	for i := 0; i < len(changes); {
+		start := max(changes[i]-context, 0)
+		last := changes[i]
+		j := i + 1
+		for j < len(changes) && changes[j]-last <= 2*context+1 {
+			last = changes[j]
+			j++
+		}
+		end := min(last+context+1, len(ops))
+		hunks = append(hunks, buildHunk(ops[start:end]))
+		i = j
+	}
+	return hunks
+}
+
+func diffOps(a, b []string) []lineOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	var ops []lineOp
+	i, j := 0, 0
+	add := func(kind byte, text string) {
+		ops = append(ops, lineOp{kind: kind, text: text, oldPos: i, newPos: j})
+		switch kind {
+		case ' ':
+			i++
+			j++
+		case '-':
+			i++
+		case '+':
+			j++
+		}
+	}
+	for k := 0; k < prefix; k++ {
+		add(' ', a[k])
+	}
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	idx := matchLines(midA, midB)
+	x, y := 0, 0
+	for x < len(midA) {
+		switch {
+		case idx[x] < 0:
+			add('-', midA[x])
+			x++
+		case idx[x] > y:
+			add('+', midB[y])
+			y++
+		default:
+			add(' ', midA[x])
+			x++
+			y++
+		}
+	}
+	for ; y < len(midB); y++ {
+		add('+', midB[y])
+	}
+	for k := len(a) - suffix; k < len(a); k++ {
+		add(' ', a[k])
+	}
+	return ops
+}
+
+func buildHunk(ops []lineOp) Hunk {
+	h := Hunk{OldStart: ops[0].oldPos, NewStart: ops[0].newPos}
+	for _, op := range ops {
+		switch op.kind {
+		case ' ':
+			h.OldLines++
+			h.NewLines++
+		case '-':
+			h.OldLines++
+		case '+':
+			h.NewLines++
+		}
+		h.Lines = append(h.Lines, string(op.kind)+strings.TrimSuffix(op.text, "\n"))
+		if !strings.HasSuffix(op.text, "\n") {
+			h.Lines = append(h.Lines, noNewline)
+		}
+	}
+	if h.OldLines > 0 {
+		h.OldStart++
+	}
+	if h.NewLines > 0 {
+		h.NewStart++
+	}
+	return h
+}
+
+type patchSide struct {
+	data    []byte
+	hash    string
+	large   bool
+	present bool
+}
+
+func readPatchSide(path string, limit int64) (patchSide, bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return patchSide{}, true, nil
+		}
+		return patchSide{}, false, err
+	}
+	if info.IsDir() {
+		return patchSide{}, false, nil
+	}
+	side := patchSide{present: true}
+	if limit > 0 && info.Size() > limit {
+		side.large = true
+		side.hash, err = FileHash(path)
+		return side, true, err
+	}
+	if side.data, err = os.ReadFile(path); err != nil {
+		return side, false, err
+	}
+	side.hash = bytesHash(side.data)
+	return side, true, nil
+}
+
+func PatchFiles(srcRoot, destRoot string, added, removed, changed []string, tmpl *Template, context int, limit int64) ([]FilePatch, error) {
+	var out []FilePatch
+	for _, group := range []struct {
+		status PatchStatus
+		files  []string
+	}{{PatchAdded, added}, {PatchRemoved, removed}, {PatchChanged, changed}} {
+		for _, rel := range group.files {
+			var oldSide, newSide patchSide
+			ok := true
+			var err error
+			if group.status != PatchAdded {
+				oldSide, ok, err = readPatchSide(filepath.Join(destRoot, filepath.FromSlash(rel)), limit)
+				if err != nil {
+					return nil, err
+				}
+			}
+			if ok && group.status != PatchRemoved {
+				f := sourceFor(srcRoot, rel, tmpl)
+				if f.Render {
+					newSide.hash, newSide.data, err = f.digest(tmpl)
+					newSide.present = true
+					newSide.large = limit > 0 && int64(len(newSide.data)) > limit
+				} else {
+					newSide, ok, err = readPatchSide(f.Path, limit)
+				}
+				if err != nil {
+					return nil, err
+				}
+			}
+			if !ok {
+				continue
+			}
+			out = append(out, buildPatch(rel, group.status, oldSide, newSide, context))
+		}
+	}
+	return out, nil
+}
+
+func buildPatch(rel string, status PatchStatus, oldSide, newSide patchSide, context int) FilePatch {
+	p := FilePatch{Path: rel, Status: status, OldHash: oldSide.hash, NewHash: newSide.hash}
+	switch {
+	case oldSide.large || newSide.large:
+		p.TooLarge = true
+	case IsBinary(oldSide.data) || IsBinary(newSide.data):
+		p.Binary = true
+	default:
+		p.Hunks = UnifiedDiff(oldSide.data, newSide.data, context)
+		for _, h := range p.Hunks {
+			for _, l := range h.Lines {
+				switch l[0] {
+				case '+':
+					p.Added++
+				case '-':
+					p.Deleted++
+				}
+			}
+		}
+	}
+	return p
+}
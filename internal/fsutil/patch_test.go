@@ -0,0 +1,136 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	old := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	cases := []struct {
+		name    string
+		old     string
+		new     string
+		context int
+		want    []Hunk
+	}{
+		{"identical", old, old, 3, nil},
+		{"change", "a\nb\nc\n", "a\nB\nc\n", 1, []Hunk{{OldStart: 1, OldLines: 3, NewStart: 1, NewLines: 3, Lines: []string{" a", "-b", "+B", " c"}}}},
+		{"added file", "", "x\ny\n", 3, []Hunk{{OldStart: 0, OldLines: 0, NewStart: 1, NewLines: 2, Lines: []string{"+x", "+y"}}}},
+		{"removed file", "x\n", "", 3, []Hunk{{OldStart: 1, OldLines: 1, NewStart: 0, NewLines: 0, Lines: []string{"-x"}}}},
+		{"no newline", "a\nb", "a\nc", 1, []Hunk{{OldStart: 1, OldLines: 2, NewStart: 1, NewLines: 2, Lines: []string{" a", "-b", noNewline, "+c", noNewline}}}},
+		{"split hunks", old, strings.Replace(strings.Replace(old, "b\n", "B\n", 1), "i\n", "I\n", 1), 1, []Hunk{
+			{OldStart: 1, OldLines: 3, NewStart: 1, NewLines: 3, Lines: []string{" a", "-b", "+B", " c"}},
+			{OldStart: 8, OldLines: 3, NewStart: 8, NewLines: 3, Lines: []string{" h", "-i", "+I", " j"}},
+		}},
+		{"merged hunks", old, strings.Replace(strings.Replace(old, "b\n", "B\n", 1), "e\n", "E\n", 1), 1, []Hunk{
+			{OldStart: 1, OldLines: 6, NewStart: 1, NewLines: 6, Lines: []string{" a", "-b", "+B", " c", " d", "-e", "+E", " f"}},
+		}},
+		{"insert middle", "a\nb\n", "a\nx\nb\n", 0, []Hunk{{OldStart: 1, OldLines: 0, NewStart: 2, NewLines: 1, Lines: []string{"+x"}}}},
+		{"reorder", "a\nb\nc\n", "c\nb\na\n", 0, nil},
+	}
+	for _, tc := range cases {
+		got := UnifiedDiff([]byte(tc.old), []byte(tc.new), tc.context)
+		if tc.name == "reorder" {
+			added, deleted := 0, 0
+			for _, h := range got {
+				for _, l := range h.Lines {
+					if l[0] == '+' {
+						added++
+					}
+					if l[0] == '-' {
+						deleted++
+					}
+				}
+			}
+			if added != 2 || deleted != 2 {
+				t.Fatalf("%s: unexpected hunks %+v", tc.name, got)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("%s: got %+v want %+v", tc.name, got, tc.want)
+		}
+	}
+	if h := (Hunk{OldStart: 1, OldLines: 2, NewStart: 3, NewLines: 4}); h.Header() != "@@ -1,2 +3,4 @@" {
+		t.Fatalf("unexpected header: %s", h.Header())
+	}
+}
+
+func TestPatchFiles(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	dst := filepath.Join(root, "dst")
+	_ = os.MkdirAll(filepath.Join(src, "dir"), 0o755)
+	_ = os.MkdirAll(filepath.Join(dst, "dir"), 0o755)
+	_ = os.WriteFile(filepath.Join(src, "new.md"), []byte("new\n"), 0o644)
+	_ = os.WriteFile(filepath.Join(dst, "old.md"), []byte("old\n"), 0o644)
+	_ = os.WriteFile(filepath.Join(src, "same.md"), []byte("one\ntwo\n"), 0o644)
+	_ = os.WriteFile(filepath.Join(dst, "same.md"), []byte("one\n"), 0o644)
+	_ = os.WriteFile(filepath.Join(src, "bin"), []byte{0, 1}, 0o644)
+	_ = os.WriteFile(filepath.Join(dst, "bin"), []byte{0, 2}, 0o644)
+	_ = os.WriteFile(filepath.Join(src, "big.md"), []byte(strings.Repeat("x", 64)), 0o644)
+	_ = os.WriteFile(filepath.Join(dst, "big.md"), []byte("small"), 0o644)
+	_ = os.WriteFile(filepath.Join(src, "tpl.md.tmpl"), []byte("{{ .Name }}\n"), 0o644)
+	_ = os.WriteFile(filepath.Join(dst, "tpl.md"), []byte("old\n"), 0o644)
+	_ = os.Symlink(filepath.Join(src, "gone"), filepath.Join(dst, "dangling"))
+	tmpl := &Template{Data: map[string]string{"Name": "rendered"}}
+	patches, err := PatchFiles(src, dst, []string{"new.md", "dir"}, []string{"old.md", "dangling"}, []string{"same.md", "bin", "big.md", "tpl.md"}, tmpl, 3, 32)
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	byPath := map[string]FilePatch{}
+	for _, p := range patches {
+		byPath[p.Path] = p
+	}
+	if _, ok := byPath["dir"]; ok {
+		t.Fatalf("directories should be skipped")
+	}
+	if p := byPath["new.md"]; p.Status != PatchAdded || p.Added != 1 || p.OldHash != "" || p.NewHash == "" {
+		t.Fatalf("unexpected added patch: %+v", p)
+	}
+	if p := byPath["old.md"]; p.Status != PatchRemoved || p.Deleted != 1 || p.NewHash != "" {
+		t.Fatalf("unexpected removed patch: %+v", p)
+	}
+	if p := byPath["dangling"]; p.Status != PatchRemoved || p.OldHash != "" || len(p.Hunks) != 0 {
+		t.Fatalf("unexpected dangling patch: %+v", p)
+	}
+	if p := byPath["same.md"]; p.Added != 1 || p.Deleted != 0 || p.Hunks[0].Lines[1] != "+two" {
+		t.Fatalf("unexpected changed patch: %+v", p)
+	}
+	if p := byPath["bin"]; !p.Binary || len(p.Hunks) != 0 {
+		t.Fatalf("expected binary: %+v", p)
+	}
+	if p := byPath["big.md"]; !p.TooLarge || p.NewHash == "" || p.OldHash == "" {
+		t.Fatalf("expected too large: %+v", p)
+	}
+	if p := byPath["tpl.md"]; p.Hunks[0].Lines[1] != "+rendered" {
+		t.Fatalf("expected rendered content: %+v", p)
+	}
+	if _, err := PatchFiles(src, dst, nil, nil, []string{"tpl.md"}, &Template{Data: map[string]string{}}, 3, 0); err == nil {
+		t.Fatalf("expected render error")
+	}
+	_ = os.WriteFile(filepath.Join(src, "tpl.md.tmpl"), []byte(strings.Repeat("y", 64)), 0o644)
+	patches, err = PatchFiles(src, dst, nil, nil, []string{"tpl.md"}, tmpl, 3, 32)
+	if err != nil || !patches[0].TooLarge {
+		t.Fatalf("expected rendered too large: %+v %v", patches, err)
+	}
+}
+
+func TestReadPatchSideErrors(t *testing.T) {
+	dir := t.TempDir()
+	loop := filepath.Join(dir, "loop")
+	_ = os.Symlink(loop, loop)
+	if _, _, err := readPatchSide(loop, 0); err == nil {
+		t.Fatalf("expected stat error")
+	}
+	if _, err := PatchFiles(dir, dir, nil, []string{"loop"}, nil, nil, 3, 0); err == nil {
+		t.Fatalf("expected patch error")
+	}
+	if _, err := PatchFiles(dir, t.TempDir(), []string{"loop"}, nil, nil, nil, 3, 0); err == nil {
+		t.Fatalf("expected source error")
+	}
+}
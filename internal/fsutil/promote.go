@@ -0,0 +1,40 @@
+package fsutil
+
+import (
+	"path/filepath"
+)
+
+func PromoteFiles(srcRoot, destRoot string, rels []string, opts SyncOptions) error {
+	m, hasManifest, err := ReadManifest(destRoot)
+	if err != nil {
+		return err
+	}
+	for _, rel := range rels {
+		from := filepath.Join(destRoot, filepath.FromSlash(rel))
+		if err := opts.copyFile(from, filepath.Join(srcRoot, filepath.FromSlash(rel))); err != nil {
+			return err
+		}
+		if !hasManifest {
+			continue
+		}
+		hash, err := FileHash(from)
+		if err != nil {
+			return err
+		}
+		m.Files[filepath.ToSlash(rel)] = hash
+		base, err := basePath(destRoot, rel)
+		if err != nil {
+			return err
+		}
+		if _, err := osLstat(base); err != nil {
+			continue
+		}
+		if err := opts.copyFile(from, base); err != nil {
+			return err
+		}
+	}
+	if !hasManifest {
+		return nil
+	}
+	return opts.writeManifest(destRoot, m)
+}
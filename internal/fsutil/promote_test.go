@@ -0,0 +1,82 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPromoteFiles(t *testing.T) {
+	src, dst := setupSyncDirs(t)
+	opts := SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictMerge}
+	if _, err := SyncDir(src, dst, opts); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	_ = os.WriteFile(filepath.Join(dst, "a.txt"), []byte("edited"), 0o644)
+	_ = os.MkdirAll(filepath.Join(dst, "sub"), 0o755)
+	_ = os.WriteFile(filepath.Join(dst, "sub", "c.txt"), []byte("c"), 0o644)
+
+	if err := PromoteFiles(src, dst, []string{"a.txt", "sub/c.txt"}, SyncOptions{DryRun: true}); err != nil {
+		t.Fatalf("dry-run promote: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(src, "a.txt")); string(data) != "a" {
+		t.Fatalf("dry-run changed source: %q", data)
+	}
+
+	root := t.TempDir()
+	journal, err := NewJournal(root)
+	if err != nil {
+		t.Fatalf("journal: %v", err)
+	}
+	if err := PromoteFiles(src, dst, []string{"a.txt", "sub/c.txt"}, SyncOptions{Journal: journal}); err != nil {
+		t.Fatalf("promote: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(src, "a.txt")); string(data) != "edited" {
+		t.Fatalf("source not updated: %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(src, "sub", "c.txt")); string(data) != "c" {
+		t.Fatalf("new file not promoted: %q", data)
+	}
+	if data, _ := os.ReadFile(testBasePath(t, dst, "a.txt")); string(data) != "edited" {
+		t.Fatalf("base not updated: %q", data)
+	}
+	m, _, err := ReadManifest(dst)
+	if err != nil {
+		t.Fatalf("manifest: %v", err)
+	}
+	hash, _ := FileHash(filepath.Join(src, "a.txt"))
+	if m.Files["a.txt"] != hash || !m.Managed("sub/c.txt") {
+		t.Fatalf("manifest not updated: %v", m.Files)
+	}
+	added, removed, changed, err := DiffDir(src, dst, nil, nil)
+	if err != nil || len(added)+len(removed)+len(changed) != 0 {
+		t.Fatalf("expected in sync: %v %v %v %v", added, removed, changed, err)
+	}
+	if err := journal.Rollback(); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(src, "a.txt")); string(data) != "a" {
+		t.Fatalf("rollback did not restore source: %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(src, "sub", "c.txt")); !os.IsNotExist(err) {
+		t.Fatalf("rollback kept promoted file: %v", err)
+	}
+}
+
+func TestPromoteFilesWithoutManifest(t *testing.T) {
+	src, dst := setupSyncDirs(t)
+	_ = os.MkdirAll(dst, 0o755)
+	_ = os.WriteFile(filepath.Join(dst, "b.txt"), []byte("local"), 0o644)
+	if err := PromoteFiles(src, dst, []string{"b.txt"}, SyncOptions{}); err != nil {
+		t.Fatalf("promote: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(src, "b.txt")); string(data) != "local" {
+		t.Fatalf("source not updated: %q", data)
+	}
+	if _, ok, _ := ReadManifest(dst); ok {
+		t.Fatalf("unexpected manifest")
+	}
+	if err := PromoteFiles(src, dst, []string{"missing.txt"}, SyncOptions{}); err == nil {
+		t.Fatalf("expected missing file error")
+	}
+}
@@ -0,0 +1,137 @@
+package fsutil
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type PruneReport struct {
+	Removed []string `json:"removed"`
+	Kept    []string `json:"kept,omitempty"`
+}
+
+func FindManifests(root string) ([]string, error) {
+	var dirs []string
+	err := walkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path == root {
+				return nil
+			}
+			if d.Name() == ".git" || d.Name() == "node_modules" || d.Name() == BaseDirName {
+				return fs.SkipDir
+			}
+			if _, err := osLstat(filepath.Join(path, ".git")); err == nil {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.Name() == ManifestName && d.Type().IsRegular() {
+			dirs = append(dirs, filepath.Dir(path))
+		}
+		return nil
+	})
+	sort.Strings(dirs)
+	return dirs, err
+}
+
+func PruneDest(destRoot string, opts SyncOptions) (PruneReport, error) {
+	var report PruneReport
+	m, ok, err := ReadManifest(destRoot)
+	if err != nil {
+		return report, err
+	}
+	if !ok {
+		return report, fmt.Errorf("no %s in %s", ManifestName, destRoot)
+	}
+	var dirs []string
+	err = walkDir(destRoot, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == destRoot {
+			return nil
+		}
+		rel, err := relPath(destRoot, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if rel == BaseDirName {
+				return fs.SkipDir
+			}
+			dirs = append(dirs, path)
+			return nil
+		}
+		if rel == ManifestName {
+			return nil
+		}
+		remove := false
+		if m.Managed(rel) {
+			if remove, err = pristine(path, d, m.Files[rel]); err != nil {
+				return err
+			}
+		}
+		if remove {
+			report.Removed = append(report.Removed, rel)
+		} else {
+			report.Kept = append(report.Kept, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+	for _, rel := range report.Removed {
+		if err := opts.removePath(filepath.Join(destRoot, filepath.FromSlash(rel))); err != nil {
+			return report, err
+		}
+	}
+	bases, err := baseDir(destRoot)
+	if err != nil {
+		return report, err
+	}
+	for _, base := range []string{bases, filepath.Join(destRoot, BaseDirName)} {
+		if _, err := osLstat(base); err == nil {
+			if err := opts.removePath(base); err != nil {
+				return report, err
+			}
+		}
+	}
+	if err := opts.removePath(filepath.Join(destRoot, ManifestName)); err != nil {
+		return report, err
+	}
+	if opts.DryRun {
+		return report, nil
+	}
+	sort.Slice(dirs, func(i, j int) bool { return len(dirs[i]) > len(dirs[j]) })
+	for _, dir := range append(dirs, destRoot) {
+		_ = os.Remove(dir)
+	}
+	return report, nil
+}
+
+func pristine(path string, d fs.DirEntry, recorded string) (bool, error) {
+	target, linked := strings.CutPrefix(recorded, linkHashPrefix)
+	if d.Type()&fs.ModeSymlink == 0 {
+		if linked {
+			return false, nil
+		}
+		return unmodified(path, recorded)
+	}
+	if linked {
+		current, err := osReadlink(path)
+		return err == nil && current == target, nil
+	}
+	if ok, err := unmodified(path, recorded); err == nil {
+		return ok, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,136 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestFindManifests(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{".codex/skills", "a/b", ".git/x", "node_modules/pkg"} {
+		_ = os.MkdirAll(filepath.Join(root, dir), 0o755)
+		_ = os.WriteFile(filepath.Join(root, dir, ManifestName), []byte("{}"), 0o644)
+	}
+	_ = os.MkdirAll(filepath.Join(root, "c", ManifestName), 0o755)
+	for _, nested := range []string{"nested/.git", "sub/.git"} {
+		_ = os.MkdirAll(filepath.Join(root, filepath.Dir(nested), ".claude", "skills"), 0o755)
+		_ = os.WriteFile(filepath.Join(root, filepath.Dir(nested), ".claude", "skills", ManifestName), []byte("{}"), 0o644)
+	}
+	_ = os.MkdirAll(filepath.Join(root, "nested", ".git"), 0o755)
+	_ = os.WriteFile(filepath.Join(root, "sub", ".git"), []byte("gitdir: ../.git/modules/sub"), 0o644)
+	dirs, err := FindManifests(root)
+	if err != nil {
+		t.Fatalf("find: %v", err)
+	}
+	want := []string{filepath.Join(root, ".codex/skills"), filepath.Join(root, "a/b")}
+	if !slices.Equal(dirs, want) {
+		t.Fatalf("unexpected manifests: %v", dirs)
+	}
+	if _, err := FindManifests(filepath.Join(root, "missing")); err == nil {
+		t.Fatalf("expected missing root error")
+	}
+}
+
+func TestPruneDest(t *testing.T) {
+	src, dst := setupSyncDirs(t)
+	_ = os.MkdirAll(filepath.Join(src, "sub"), 0o755)
+	_ = os.WriteFile(filepath.Join(src, "sub", "c.txt"), []byte("c"), 0o644)
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictMerge}); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	_ = os.WriteFile(filepath.Join(dst, "notes.md"), []byte("mine"), 0o644)
+	_ = os.WriteFile(filepath.Join(dst, "b.txt"), []byte("edited"), 0o644)
+
+	report, err := PruneDest(dst, SyncOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("dry-run prune: %v", err)
+	}
+	if !slices.Equal(report.Removed, []string{"a.txt", "sub/c.txt"}) || !slices.Equal(report.Kept, []string{"b.txt", "notes.md"}) {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if _, ok, _ := ReadManifest(dst); !ok {
+		t.Fatalf("dry-run removed the manifest")
+	}
+
+	journal, err := NewJournal(t.TempDir())
+	if err != nil {
+		t.Fatalf("journal: %v", err)
+	}
+	if _, err := PruneDest(dst, SyncOptions{Journal: journal}); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	entries, _ := os.ReadDir(dst)
+	if len(entries) != 2 || entries[0].Name() != "b.txt" || entries[1].Name() != "notes.md" {
+		t.Fatalf("expected only edited and unmanaged files left: %v", entries)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "b.txt")); string(data) != "edited" {
+		t.Fatalf("locally edited file changed: %q", data)
+	}
+	if _, err := os.Stat(testBasePath(t, dst, ".")); !os.IsNotExist(err) {
+		t.Fatalf("expected merge bases removed: %v", err)
+	}
+	if err := journal.Rollback(); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	if _, ok, _ := ReadManifest(dst); !ok {
+		t.Fatalf("rollback did not restore the manifest")
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "sub", "c.txt")); string(data) != "c" {
+		t.Fatalf("rollback did not restore files: %q", data)
+	}
+
+	_ = os.Remove(filepath.Join(dst, "notes.md"))
+	_ = os.WriteFile(filepath.Join(dst, "b.txt"), []byte("b"), 0o644)
+	if _, err := PruneDest(dst, SyncOptions{}); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Fatalf("expected empty destination removed: %v", err)
+	}
+	if _, err := PruneDest(dst, SyncOptions{}); err == nil {
+		t.Fatalf("expected missing manifest error")
+	}
+}
+
+func TestPruneDestLinks(t *testing.T) {
+	for _, mode := range []SyncMode{ModeLink, ModeLinkDir} {
+		src, dst := setupSyncDirs(t)
+		_ = os.MkdirAll(filepath.Join(src, "skill"), 0o755)
+		_ = os.WriteFile(filepath.Join(src, "skill", "SKILL.md"), []byte("s"), 0o644)
+		if _, err := SyncDir(src, dst, SyncOptions{Mode: mode, ConflictPolicy: ConflictOverwrite}); err != nil {
+			t.Fatalf("%s sync: %v", mode, err)
+		}
+		report, err := PruneDest(dst, SyncOptions{})
+		if err != nil || len(report.Removed) == 0 || len(report.Kept) != 0 {
+			t.Fatalf("%s prune: %+v %v", mode, report, err)
+		}
+		if _, err := os.Lstat(dst); !os.IsNotExist(err) {
+			t.Fatalf("%s: expected destination removed: %v", mode, err)
+		}
+	}
+}
+
+func TestPruneDestKeepsRetargetedLinks(t *testing.T) {
+	for _, mode := range []SyncMode{ModeLink, ModeLinkDir} {
+		src, dst := setupSyncDirs(t)
+		_ = os.MkdirAll(filepath.Join(src, "skill"), 0o755)
+		_ = os.WriteFile(filepath.Join(src, "skill", "SKILL.md"), []byte("s"), 0o644)
+		_ = os.WriteFile(filepath.Join(src, "top.md"), []byte("t"), 0o644)
+		if _, err := SyncDir(src, dst, SyncOptions{Mode: mode, ConflictPolicy: ConflictOverwrite}); err != nil {
+			t.Fatalf("%s sync: %v", mode, err)
+		}
+		local := filepath.Join(t.TempDir(), "local.md")
+		_ = os.WriteFile(local, []byte("mine"), 0o644)
+		_ = os.Remove(filepath.Join(dst, "top.md"))
+		_ = os.Symlink(local, filepath.Join(dst, "top.md"))
+		report, err := PruneDest(dst, SyncOptions{})
+		if err != nil || !slices.Contains(report.Kept, "top.md") || slices.Contains(report.Removed, "top.md") || len(report.Removed) == 0 {
+			t.Fatalf("%s prune: %+v %v", mode, report, err)
+		}
+		if link, _ := os.Readlink(filepath.Join(dst, "top.md")); link != local {
+			t.Fatalf("%s: retargeted link removed: %q", mode, link)
+		}
+	}
+}
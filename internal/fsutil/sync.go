@@ -0,0 +1,501 @@
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type FileAction string
+
+const (
+	ActionCreated   FileAction = "created"
+	ActionUpdated   FileAction = "updated"
+	ActionUnchanged FileAction = "unchanged"
+	ActionRemoved   FileAction = "removed"
+	ActionMerged    FileAction = "merged"
+	ActionConflict  FileAction = "conflict"
+	ActionKept      FileAction = "kept"
+)
+
+type FileChange struct {
+	Path   string     `json:"path"`
+	Action FileAction `json:"action"`
+}
+
+type SyncReport struct {
+	Changes []FileChange
+}
+
+func (r SyncReport) Count(action FileAction) int {
+	n := 0
+	for _, c := range r.Changes {
+		if c.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+func (r SyncReport) Modified() []FileChange {
+	var out []FileChange
+	for _, c := range r.Changes {
+		if c.Action != ActionUnchanged {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func SyncDir(srcRoot, destRoot string, opts SyncOptions) (SyncReport, error) {
+	var report SyncReport
+	files, err := ListFiles(srcRoot, opts.Include, opts.Exclude)
+	if err != nil {
+		return report, err
+	}
+	srcRoot = filepath.Clean(srcRoot)
+	destRoot = filepath.Clean(destRoot)
+	prev, hasPrev, err := ReadManifest(destRoot)
+	if err != nil {
+		return report, err
+	}
+	next := Manifest{
+		Target:   opts.Target,
+		Source:   opts.Source,
+		Commit:   opts.Commit,
+		Mode:     opts.Mode,
+		SyncedAt: timeNow().UTC(),
+		Files:    map[string]string{},
+	}
+	if opts.Mode == ModeLinkDir {
+		return syncLinkDirs(srcRoot, destRoot, files, prev, next, opts)
+	}
+	partial := len(opts.Paths) > 0
+	if partial {
+		files = underPaths(files, opts.Paths)
+	}
+	if err := unlinkDirs(destRoot, prev, opts); err != nil {
+		return report, err
+	}
+	sources := sourceFiles(srcRoot, files, opts.Template)
+	keep := make([]string, 0, len(sources))
+	for _, f := range sources {
+		keep = append(keep, f.Dest)
+		dst := filepath.Join(destRoot, filepath.FromSlash(f.Dest))
+		recorded := prev.Files[f.Dest]
+		base, err := basePath(destRoot, f.Dest)
+		if err != nil {
+			return report, opts.abortManifest(destRoot, prev, next, err)
+		}
+		var action FileAction
+		var hash string
+		var rendered []byte
+		switch {
+		case f.Render:
+			hash, rendered, err = f.digest(opts.Template)
+			if err != nil {
+				return report, opts.abortManifest(destRoot, prev, next, fmt.Errorf("%s: %w", f.Rel, err))
+			}
+			action, err = syncRendered(rendered, f.Path, dst, recorded, base, opts)
+		case opts.Mode == ModeLink:
+			action, err = syncLink(f.Path, dst, recorded, opts)
+		default:
+			action, err = syncCopy(f.Path, dst, recorded, base, opts)
+		}
+		if err != nil {
+			return report, opts.abortManifest(destRoot, prev, next, err)
+		}
+		report.Changes = append(report.Changes, FileChange{Path: f.Dest, Action: action})
+		if opts.DryRun {
+			continue
+		}
+		if action == ActionKept || action == ActionConflict {
+			if recorded != "" {
+				next.Files[f.Dest] = recorded
+			}
+			continue
+		}
+		if !f.Render {
+			if hash, err = FileHash(f.Path); err != nil {
+				return report, opts.abortManifest(destRoot, prev, next, err)
+			}
+		}
+		next.Files[f.Dest] = hash
+		if opts.Mode == ModeLink && !f.Render {
+			continue
+		}
+		if opts.ConflictPolicy != ConflictMerge {
+			if _, err := osLstat(base); err == nil {
+				if err := opts.removePath(base); err != nil {
+					return report, opts.abortManifest(destRoot, prev, next, err)
+				}
+			}
+			continue
+		}
+		if current, _ := unmodified(base, hash); current {
+			continue
+		}
+		if err := opts.record(base); err != nil {
+			return report, opts.abortManifest(destRoot, prev, next, err)
+		}
+		if f.Render {
+			err = writeFileAtomicDir(base, rendered, 0o644)
+		} else {
+			err = CopyFile(f.Path, base, false)
+		}
+		if err != nil {
+			return report, opts.abortManifest(destRoot, prev, next, err)
+		}
+	}
+	removedSet := map[string]struct{}{}
+	if opts.Mode == ModeMirror {
+		var managed *Manifest
+		if hasPrev {
+			managed = &prev
+		}
+		var removed []string
+		if partial {
+			removed, err = mirrorCleanupPaths(destRoot, keep, prev, opts)
+		} else {
+			removed, err = mirrorCleanup(srcRoot, destRoot, keep, managed, opts)
+		}
+		if err != nil {
+			return report, opts.abortManifest(destRoot, prev, next, err)
+		}
+		for _, rel := range removed {
+			removedSet[rel] = struct{}{}
+			report.Changes = append(report.Changes, FileChange{Path: rel, Action: ActionRemoved})
+		}
+	}
+	carryManifest(destRoot, prev, next, removedSet)
+	if err := opts.writeManifest(destRoot, next); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+func carryManifest(destRoot string, prev, next Manifest, removed map[string]struct{}) {
+	for rel, hash := range prev.Files {
+		if _, ok := next.Files[rel]; ok {
+			continue
+		}
+		if _, ok := removed[rel]; ok {
+			continue
+		}
+		if top, _, nested := strings.Cut(rel, "/"); nested && strings.HasPrefix(next.Files[top], linkHashPrefix) {
+			continue
+		}
+		if _, err := osLstat(filepath.Join(destRoot, filepath.FromSlash(rel))); err == nil {
+			next.Files[rel] = hash
+		}
+	}
+}
+
+func (o SyncOptions) abortManifest(destRoot string, prev, next Manifest, cause error) error {
+	if o.DryRun || len(next.Files) == 0 {
+		return cause
+	}
+	if prev.Files != nil {
+		next.Target, next.Source, next.Commit, next.Mode = prev.Target, prev.Source, prev.Commit, prev.Mode
+	}
+	carryManifest(destRoot, prev, next, nil)
+	if err := o.writeManifest(destRoot, next); err != nil {
+		return errors.Join(cause, err)
+	}
+	return cause
+}
+
+func syncCopy(src, dst, recorded, base string, opts SyncOptions) (FileAction, error) {
+	dstInfo, err := osLstat(dst)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+		return ActionCreated, opts.copyFile(src, dst)
+	}
+	same, err := sameContent(src, dst, dstInfo)
+	if err != nil {
+		return "", err
+	}
+	if same {
+		return ActionUnchanged, nil
+	}
+	if !dstInfo.Mode().IsRegular() {
+		if err := applyConflictPolicy(dst, opts); err != nil {
+			return "", err
+		}
+		return ActionUpdated, opts.copyFile(src, dst)
+	}
+	if opts.ConflictPolicy != ConflictOverwrite {
+		pristine, err := unmodified(dst, recorded)
+		if err != nil {
+			return "", err
+		}
+		if !pristine {
+			switch opts.ConflictPolicy {
+			case ConflictKeepLocal:
+				return ActionKept, nil
+			case ConflictMerge:
+				if err := opts.record(dst, dst+ConflictSuffix); err != nil {
+					return "", err
+				}
+				return mergeFile(src, dst, base, opts.DryRun)
+			default:
+				return "", fmt.Errorf("conflict detected: %s", dst)
+			}
+		}
+	}
+	return ActionUpdated, opts.copyFile(src, dst)
+}
+
+func syncRendered(data []byte, src, dst, recorded, base string, opts SyncOptions) (FileAction, error) {
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return "", err
+	}
+	write := func() error {
+		if opts.DryRun {
+			return nil
+		}
+		if err := opts.record(dst); err != nil {
+			return err
+		}
+		return writeFileAtomicDir(dst, data, srcInfo.Mode().Perm())
+	}
+	dstInfo, err := osLstat(dst)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+		return ActionCreated, write()
+	}
+	if !dstInfo.Mode().IsRegular() {
+		if err := applyConflictPolicy(dst, opts); err != nil {
+			return "", err
+		}
+		return ActionUpdated, write()
+	}
+	hash, err := FileHash(dst)
+	if err != nil {
+		return "", err
+	}
+	if hash == bytesHash(data) {
+		return ActionUnchanged, nil
+	}
+	if opts.ConflictPolicy != ConflictOverwrite && hash != recorded {
+		switch opts.ConflictPolicy {
+		case ConflictKeepLocal:
+			return ActionKept, nil
+		case ConflictMerge:
+			if err := opts.record(dst, dst+ConflictSuffix); err != nil {
+				return "", err
+			}
+			return mergeContent(data, dst, base, opts.DryRun)
+		default:
+			return "", fmt.Errorf("conflict detected: %s", dst)
+		}
+	}
+	return ActionUpdated, write()
+}
+
+func syncLink(src, dst, recorded string, opts SyncOptions) (FileAction, error) {
+	target, err := linkTarget(src, dst, opts.Relative)
+	if err != nil {
+		return "", err
+	}
+	dstInfo, err := osLstat(dst)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+		return ActionCreated, opts.linkFile(target, dst)
+	}
+	if dstInfo.Mode()&os.ModeSymlink != 0 {
+		if current, err := osReadlink(dst); err == nil && current == target {
+			return ActionUnchanged, nil
+		}
+	}
+	same, err := sameContent(src, dst, dstInfo)
+	if err != nil {
+		return "", err
+	}
+	if !same && opts.ConflictPolicy != ConflictOverwrite {
+		pristine, err := unmodified(dst, recorded)
+		if err != nil {
+			return "", err
+		}
+		if !pristine {
+			if opts.ConflictPolicy == ConflictKeepLocal {
+				return ActionKept, nil
+			}
+			return "", fmt.Errorf("conflict detected: %s", dst)
+		}
+	}
+	return ActionUpdated, opts.replaceWithLink(target, dst, dstInfo)
+}
+
+func unmodified(dst, recorded string) (bool, error) {
+	if recorded == "" {
+		return false, nil
+	}
+	hash, err := FileHash(dst)
+	if err != nil {
+		return false, err
+	}
+	return hash == recorded, nil
+}
+
+func replaceWithLink(src, dst string, dstInfo os.FileInfo, dryRun bool) error {
+	if dryRun {
+		return nil
+	}
+	if dstInfo.IsDir() {
+		if err := RemovePath(dst, false); err != nil {
+			return err
+		}
+		return LinkFile(src, dst, false)
+	}
+	tmp := tempPath(dst)
+	_ = os.Remove(tmp)
+	if err := osSymlink(src, tmp); err != nil {
+		return err
+	}
+	if err := osRename(tmp, dst); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func sameContent(src, dst string, dstInfo os.FileInfo) (bool, error) {
+	if !dstInfo.Mode().IsRegular() {
+		return false, nil
+	}
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return false, err
+	}
+	if srcInfo.Size() != dstInfo.Size() {
+		return false, nil
+	}
+	if srcInfo.ModTime().Equal(dstInfo.ModTime()) {
+		return true, nil
+	}
+	srcHash, err := FileHash(src)
+	if err != nil {
+		return false, err
+	}
+	dstHash, err := FileHash(dst)
+	if err != nil {
+		return false, err
+	}
+	return srcHash == dstHash, nil
+}
+
+func applyConflictPolicy(dst string, opts SyncOptions) error {
+	if _, err := osLstat(dst); err != nil {
+		return nil
+	}
+	if opts.ConflictPolicy == ConflictOverwrite {
+		return opts.removePath(dst)
+	}
+	return fmt.Errorf("conflict detected: %s", dst)
+}
+
+func mirrorCleanup(srcRoot, destRoot string, keep []string, managed *Manifest, opts SyncOptions) ([]string, error) {
+	if opts.ConflictPolicy != ConflictOverwrite {
+		return nil, errors.New("mirror requires overwrite policy")
+	}
+	keepSet := make(map[string]struct{}, len(keep))
+	for _, rel := range keep {
+		keepSet[filepath.ToSlash(rel)] = struct{}{}
+	}
+	var removed []string
+	err := walkDir(destRoot, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == destRoot && errors.Is(err, os.ErrNotExist) {
+				return fs.SkipDir
+			}
+			return err
+		}
+		if path == destRoot {
+			return nil
+		}
+		rel, err := relPath(destRoot, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() && rel == BaseDirName {
+			return fs.SkipDir
+		}
+		if d.IsDir() || isSyncMetadata(rel) {
+			return nil
+		}
+		if _, ok := keepSet[rel]; ok {
+			return nil
+		}
+		if managed != nil && !managed.Managed(rel) {
+			return nil
+		}
+		removed = append(removed, rel)
+		if err := opts.removeBase(destRoot, rel); err != nil {
+			return err
+		}
+		return opts.removePath(path)
+	})
+	return removed, err
+}
+
+func mirrorCleanupPaths(destRoot string, keep []string, prev Manifest, opts SyncOptions) ([]string, error) {
+	if opts.ConflictPolicy != ConflictOverwrite {
+		return nil, errors.New("mirror requires overwrite policy")
+	}
+	keepSet := make(map[string]struct{}, len(keep))
+	for _, rel := range keep {
+		keepSet[rel] = struct{}{}
+	}
+	var prefixes []string
+	for _, p := range opts.Paths {
+		prefixes = append(prefixes, p, strings.TrimSuffix(p, TemplateSuffix))
+	}
+	var removed []string
+	for _, rel := range underPaths(prev.Paths(), prefixes) {
+		if _, ok := keepSet[rel]; ok {
+			continue
+		}
+		path := filepath.Join(destRoot, filepath.FromSlash(rel))
+		if _, err := osLstat(path); err != nil {
+			continue
+		}
+		removed = append(removed, rel)
+		if err := opts.removeBase(destRoot, rel); err != nil {
+			return removed, err
+		}
+		if err := opts.removePath(path); err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+func underPaths(files []string, paths []string) []string {
+	var out []string
+	for _, f := range files {
+		for _, p := range paths {
+			p = strings.TrimSuffix(filepath.ToSlash(p), "/")
+			if p == "" || p == "." || f == p || strings.HasPrefix(f, p+"/") {
+				out = append(out, f)
+				break
+			}
+		}
+	}
+	return out
+}
+
+func tempPath(dst string) string {
+	return filepath.Join(filepath.Dir(dst), "."+filepath.Base(dst)+".gkn-tmp")
+}
@@ -0,0 +1,93 @@
+package fsutil
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+const TemplateSuffix = ".tmpl"
+
+type Template struct {
+	All  bool
+	Data any
+}
+
+type sourceFile struct {
+	Rel    string
+	Dest   string
+	Path   string
+	Render bool
+}
+
+func sourceFiles(srcRoot string, files []string, tmpl *Template) []sourceFile {
+	out := make([]sourceFile, 0, len(files))
+	for _, rel := range files {
+		f := sourceFile{Rel: rel, Dest: rel, Path: filepath.Join(srcRoot, filepath.FromSlash(rel))}
+		if tmpl != nil {
+			if strings.HasSuffix(rel, TemplateSuffix) {
+				f.Dest = strings.TrimSuffix(rel, TemplateSuffix)
+				f.Render = true
+			} else if tmpl.All {
+				f.Render = true
+			}
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+func sourceFor(srcRoot, destRel string, tmpl *Template) sourceFile {
+	if tmpl != nil {
+		path := filepath.Join(srcRoot, filepath.FromSlash(destRel+TemplateSuffix))
+		if _, err := os.Stat(path); err == nil {
+			return sourceFile{Rel: destRel + TemplateSuffix, Dest: destRel, Path: path, Render: true}
+		}
+	}
+	return sourceFiles(srcRoot, []string{destRel}, tmpl)[0]
+}
+
+func (t *Template) Render(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tpl, err := template.New(filepath.Base(path)).Option("missingkey=error").Parse(string(data))
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := tpl.Execute(&out, t.Data); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func (f sourceFile) digest(tmpl *Template) (string, []byte, error) {
+	if !f.Render {
+		hash, err := FileHash(f.Path)
+		return hash, nil, err
+	}
+	data, err := tmpl.Render(f.Path)
+	if err != nil {
+		return "", nil, err
+	}
+	return bytesHash(data), data, nil
+}
+
+func bytesHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func DestNames(files []string, tmpl *Template) []string {
+	out := make([]string, 0, len(files))
+	for _, f := range sourceFiles("", files, tmpl) {
+		out = append(out, f.Dest)
+	}
+	return out
+}
@@ -0,0 +1,164 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSyncDirRendersTemplates(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	dst := filepath.Join(root, "dst")
+	_ = os.MkdirAll(src, 0o755)
+	_ = os.WriteFile(filepath.Join(src, "README.md.tmpl"), []byte("# {{ .Name }}\n"), 0o644)
+	_ = os.WriteFile(filepath.Join(src, "plain.txt"), []byte("{{ .Name }}"), 0o644)
+	tmpl := &Template{Data: map[string]string{"Name": "alpha"}}
+	opts := SyncOptions{Mode: ModeLink, ConflictPolicy: ConflictFail, Template: tmpl}
+	report, err := SyncDir(src, dst, opts)
+	if err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if report.Count(ActionCreated) != 2 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "README.md")); string(data) != "# alpha\n" {
+		t.Fatalf("unexpected render: %q", data)
+	}
+	if info, err := os.Lstat(filepath.Join(dst, "README.md")); err != nil || !info.Mode().IsRegular() {
+		t.Fatalf("rendered file should be a regular file in link mode")
+	}
+	if info, err := os.Lstat(filepath.Join(dst, "plain.txt")); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("plain file should stay linked")
+	}
+	m, _, _ := ReadManifest(dst)
+	if !m.Managed("README.md") || m.Managed("README.md.tmpl") {
+		t.Fatalf("unexpected manifest: %v", m.Paths())
+	}
+	added, removed, changed, err := DiffDirTemplate(src, dst, nil, nil, tmpl)
+	if err != nil || len(added)+len(removed)+len(changed) != 0 {
+		t.Fatalf("expected clean diff: %v %v %v %v", added, removed, changed, err)
+	}
+	report, err = SyncDir(src, dst, opts)
+	if err != nil || report.Count(ActionUnchanged) != 2 {
+		t.Fatalf("expected unchanged: %+v %v", report, err)
+	}
+
+	other := &Template{Data: map[string]string{"Name": "beta"}}
+	_, _, changed, err = DiffDirTemplate(src, dst, nil, nil, other)
+	if err != nil || len(changed) != 1 || changed[0] != "README.md" {
+		t.Fatalf("expected rendered change: %v %v", changed, err)
+	}
+	local, upstream, err := ClassifyChanges(src, dst, changed, m, other)
+	if err != nil || len(local) != 0 || len(upstream) != 1 {
+		t.Fatalf("expected upstream change: %v %v %v", local, upstream, err)
+	}
+	report, err = SyncDir(src, dst, SyncOptions{Mode: ModeLink, ConflictPolicy: ConflictFail, Template: other})
+	if err != nil || report.Count(ActionUpdated) != 1 {
+		t.Fatalf("expected managed render update: %+v %v", report, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "README.md")); string(data) != "# beta\n" {
+		t.Fatalf("unexpected render: %q", data)
+	}
+}
+
+func TestSyncDirTemplateAll(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	dst := filepath.Join(root, "dst")
+	_ = os.MkdirAll(src, 0o755)
+	_ = os.WriteFile(filepath.Join(src, "plain.txt"), []byte("{{ .Name }}"), 0o644)
+	opts := SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictMerge, Template: &Template{All: true, Data: map[string]string{"Name": "alpha"}}}
+	if _, err := SyncDir(src, dst, opts); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "plain.txt")); string(data) != "alpha" {
+		t.Fatalf("unexpected render: %q", data)
+	}
+	if data, _ := os.ReadFile(testBasePath(t, dst, "plain.txt")); string(data) != "alpha" {
+		t.Fatalf("expected rendered base: %q", data)
+	}
+}
+
+func TestSyncRenderedPolicies(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	dst := filepath.Join(root, "dst")
+	_ = os.MkdirAll(src, 0o755)
+	_ = os.WriteFile(filepath.Join(src, "a.txt.tmpl"), []byte("one\ntwo\n{{ .Name }}\n"), 0o644)
+	data := func(name string) *Template { return &Template{Data: map[string]string{"Name": name}} }
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictMerge, Template: data("x")}); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	_ = os.WriteFile(filepath.Join(dst, "a.txt"), []byte("ONE\ntwo\nx\n"), 0o644)
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictFail, Template: data("y")}); err == nil {
+		t.Fatalf("expected conflict")
+	}
+	report, err := SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictKeepLocal, Template: data("y")})
+	if err != nil || report.Count(ActionKept) != 1 {
+		t.Fatalf("expected kept: %+v %v", report, err)
+	}
+	report, err = SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictMerge, Template: data("y")})
+	if err != nil || report.Count(ActionMerged) != 1 {
+		t.Fatalf("expected merged: %+v %v", report, err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dst, "a.txt")); string(got) != "ONE\ntwo\ny\n" {
+		t.Fatalf("unexpected merge: %q", got)
+	}
+	_ = os.Remove(filepath.Join(dst, "a.txt"))
+	_ = os.MkdirAll(filepath.Join(dst, "a.txt"), 0o755)
+	if _, err := SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictFail, Template: data("y")}); err == nil {
+		t.Fatalf("expected dir conflict")
+	}
+	report, err = SyncDir(src, dst, SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictOverwrite, Template: data("y")})
+	if err != nil || report.Count(ActionUpdated) != 1 {
+		t.Fatalf("expected dir replaced: %+v %v", report, err)
+	}
+	report, err = SyncDir(src, filepath.Join(root, "dry"), SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictFail, DryRun: true, Template: data("y")})
+	if err != nil || report.Count(ActionCreated) != 1 {
+		t.Fatalf("expected dry-run create: %+v %v", report, err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "dry")); !os.IsNotExist(err) {
+		t.Fatalf("dry run wrote files")
+	}
+}
+
+func TestTemplateErrors(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	_ = os.MkdirAll(src, 0o755)
+	_ = os.WriteFile(filepath.Join(src, "bad.tmpl"), []byte("{{ .Missing }}"), 0o644)
+	tmpl := &Template{Data: map[string]string{}}
+	if _, err := SyncDir(src, filepath.Join(root, "dst"), SyncOptions{Mode: ModeCopy, ConflictPolicy: ConflictFail, Template: tmpl}); err == nil {
+		t.Fatalf("expected missing key error")
+	}
+	_ = os.MkdirAll(filepath.Join(root, "dst"), 0o755)
+	_ = os.WriteFile(filepath.Join(root, "dst", "bad"), []byte("x"), 0o644)
+	if _, _, _, err := DiffDirTemplate(src, filepath.Join(root, "dst"), nil, nil, tmpl); err == nil {
+		t.Fatalf("expected diff render error")
+	}
+	_ = os.WriteFile(filepath.Join(src, "bad.tmpl"), []byte("{{ .Broken"), 0o644)
+	if _, err := tmpl.Render(filepath.Join(src, "bad.tmpl")); err == nil {
+		t.Fatalf("expected parse error")
+	}
+	if _, err := tmpl.Render(filepath.Join(src, "missing")); err == nil {
+		t.Fatalf("expected read error")
+	}
+	if _, err := syncRendered(nil, filepath.Join(src, "missing"), filepath.Join(root, "x"), "", "", SyncOptions{}); err == nil {
+		t.Fatalf("expected src stat error")
+	}
+}
+
+func TestDestNames(t *testing.T) {
+	files := []string{"a.txt", "b.md.tmpl"}
+	if got := DestNames(files, &Template{}); got[0] != "a.txt" || got[1] != "b.md" {
+		t.Fatalf("unexpected names: %v", got)
+	}
+	if got := DestNames(files, nil); got[1] != "b.md.tmpl" {
+		t.Fatalf("unexpected raw names: %v", got)
+	}
+	added, _, _, err := DiffDirTemplate(t.TempDir(), filepath.Join(t.TempDir(), "missing"), nil, nil, &Template{})
+	if err != nil || len(added) != 0 {
+		t.Fatalf("unexpected: %v %v", added, err)
+	}
+}
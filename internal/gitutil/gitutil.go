@@ -9,6 +9,26 @@ import (
 	"github.com/TT-AIXion/github-kanri/internal/executil"
 )
 
+type Commit struct {
+	Hash    string
+	Author  string
+	Email   string
+	Unix    int64
+	Subject string
+}
+
+type GrepMatch struct {
+	Path string
+	Line int
+	Text string
+}
+
+type GrepOptions struct {
+	Globs      []string
+	IgnoreCase bool
+	Fixed      bool
+}
+
 var symbolicRef = func(ctx context.Context, r executil.Runner, repo string) (executil.Result, error) {
 	return r.Run(ctx, repo, "git", "symbolic-ref", "refs/remotes/origin/HEAD")
 }
@@ -44,6 +64,49 @@ func DefaultBranch(ctx context.Context, r executil.Runner, repo string) (string,
 	return parts[len(parts)-1], nil
 }
 
+func HeadCommit(ctx context.Context, r executil.Runner, repo string) (string, error) {
+	res, err := r.Run(ctx, repo, "git", "rev-parse", "HEAD")
+	return strings.TrimSpace(res.Stdout), err
+}
+
+func HasCommits(ctx context.Context, r executil.Runner, repo string) (bool, error) {
+	if _, err := r.Run(ctx, repo, "git", "rev-parse", "--git-dir"); err != nil {
+		return false, err
+	}
+	_, err := r.Run(ctx, repo, "git", "rev-parse", "--verify", "--quiet", "HEAD")
+	return err == nil, nil
+}
+
+func ExactTag(ctx context.Context, r executil.Runner, repo string) (string, error) {
+	res, err := r.Run(ctx, repo, "git", "describe", "--tags", "--exact-match", "HEAD")
+	return strings.TrimSpace(res.Stdout), err
+}
+
+func ResolveCommit(ctx context.Context, r executil.Runner, repo string, ref string) (string, error) {
+	res, err := r.Run(ctx, repo, "git", "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	return strings.TrimSpace(res.Stdout), err
+}
+
+func AheadBehind(ctx context.Context, r executil.Runner, repo string) (int, int, error) {
+	res, err := r.Run(ctx, repo, "git", "rev-list", "--left-right", "--count", "HEAD...@{upstream}")
+	if err != nil {
+		return 0, 0, err
+	}
+	fields := strings.Fields(res.Stdout)
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected rev-list output: %s", strings.TrimSpace(res.Stdout))
+	}
+	ahead, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	behind, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	return ahead, behind, nil
+}
+
 func OriginURL(ctx context.Context, r executil.Runner, repo string) (string, error) {
 	res, err := r.Run(ctx, repo, "git", "remote", "get-url", "origin")
 	return strings.TrimSpace(res.Stdout), err
@@ -66,6 +129,113 @@ func LogOneline(ctx context.Context, r executil.Runner, repo string, limit int)
 	return strings.TrimSpace(res.Stdout), err
 }
 
+func Log(ctx context.Context, r executil.Runner, repo string, since string, author string) ([]Commit, error) {
+	args := []string{"log", "--no-merges", "--format=%H%x1f%an%x1f%ae%x1f%ct%x1f%s"}
+	if since != "" {
+		args = append(args, "--since="+since)
+	}
+	if author != "" {
+		args = append(args, "--author="+author)
+	}
+	res, err := r.Run(ctx, repo, "git", args...)
+	if err != nil {
+		return nil, err
+	}
+	var commits []Commit
+	for _, line := range strings.Split(res.Stdout, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, "\x1f", 5)
+		if len(parts) != 5 {
+			return nil, fmt.Errorf("unexpected log line: %s", line)
+		}
+		unix, err := strconv.ParseInt(parts[3], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		commits = append(commits, Commit{Hash: parts[0], Author: parts[1], Email: parts[2], Unix: unix, Subject: parts[4]})
+	}
+	return commits, nil
+}
+
+func Grep(ctx context.Context, r executil.Runner, repo string, pattern string, opts GrepOptions) ([]GrepMatch, error) {
+	args := []string{"grep", "-n", "-z", "-I"}
+	if opts.IgnoreCase {
+		args = append(args, "-i")
+	}
+	if opts.Fixed {
+		args = append(args, "-F")
+	}
+	args = append(args, "-e", pattern)
+	if len(opts.Globs) > 0 {
+		args = append(args, "--")
+		args = append(args, opts.Globs...)
+	}
+	res, err := r.Run(ctx, repo, "git", args...)
+	if err != nil {
+		if res.ExitCode == 1 && strings.TrimSpace(res.Stderr) == "" {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var matches []GrepMatch
+	for _, line := range strings.Split(res.Stdout, "\n") {
+		if line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, "\x00", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("unexpected grep line: %s", line)
+		}
+		n, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, GrepMatch{Path: parts[0], Line: n, Text: parts[2]})
+	}
+	return matches, nil
+}
+
+func UntrackedPaths(ctx context.Context, r executil.Runner, repo string) (untracked []string, ignored []string, err error) {
+	res, err := r.Run(ctx, repo, "git", "status", "--porcelain", "-z", "--ignored")
+	if err != nil {
+		return nil, nil, err
+	}
+	fields := strings.Split(res.Stdout, "\x00")
+	for i := 0; i < len(fields); i++ {
+		entry := fields[i]
+		if len(entry) < 4 {
+			continue
+		}
+		switch {
+		case entry[0] == 'R' || entry[0] == 'C':
+			i++
+		case strings.HasPrefix(entry, "?? "):
+			untracked = append(untracked, entry[3:])
+		case strings.HasPrefix(entry, "!! "):
+			ignored = append(ignored, entry[3:])
+		}
+	}
+	return untracked, ignored, nil
+}
+
+func CleanIgnored(ctx context.Context, r executil.Runner, repo string) error {
+	_, err := r.Run(ctx, repo, "git", "clean", "-fdX")
+	return err
+}
+
+func GC(ctx context.Context, r executil.Runner, repo string) error {
+	_, err := r.Run(ctx, repo, "git", "gc", "--quiet")
+	return err
+}
+
+func ConfigValue(ctx context.Context, r executil.Runner, repo string, key string) (string, error) {
+	res, err := r.Run(ctx, repo, "git", "config", "--get", key)
+	return strings.TrimSpace(res.Stdout), err
+}
+
 func Clone(ctx context.Context, r executil.Runner, url string, dest string) error {
 	_, err := r.Run(ctx, "", "git", "clone", url, dest)
 	return err
@@ -85,3 +255,21 @@ func Checkout(ctx context.Context, r executil.Runner, repo string, ref string) e
 	_, err := r.Run(ctx, repo, "git", "checkout", ref)
 	return err
 }
+
+func CreateBranch(ctx context.Context, r executil.Runner, repo string, name string) error {
+	_, err := r.Run(ctx, repo, "git", "checkout", "-b", name)
+	return err
+}
+
+func DeleteBranch(ctx context.Context, r executil.Runner, repo string, name string) error {
+	_, err := r.Run(ctx, repo, "git", "branch", "-D", name)
+	return err
+}
+
+func CommitPaths(ctx context.Context, r executil.Runner, repo string, message string, paths []string) error {
+	if _, err := r.Run(ctx, repo, "git", append([]string{"add", "--"}, paths...)...); err != nil {
+		return err
+	}
+	_, err := r.Run(ctx, repo, "git", append([]string{"commit", "-m", message, "--"}, paths...)...)
+	return err
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
@@ -149,3 +150,196 @@ func runGit(dir string, args ...string) error {
 	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
 	return cmd.Run()
 }
+
+func TestLogAndConfigValue(t *testing.T) {
+	root := t.TempDir()
+	repoPath := filepath.Join(root, "repo")
+	if err := runGit(root, "init", repoPath); err != nil {
+		t.Fatalf("git init: %v", err)
+	}
+	_ = runGit(repoPath, "config", "user.email", "test@example.com")
+	_ = runGit(repoPath, "config", "user.name", "Tester")
+	_ = os.WriteFile(filepath.Join(repoPath, "a.txt"), []byte("a"), 0o644)
+	_ = runGit(repoPath, "add", ".")
+	if err := runGit(repoPath, "commit", "-m", "first: subject"); err != nil {
+		t.Fatalf("git commit: %v", err)
+	}
+	runner := executil.Runner{Guard: safety.Guard{AllowCommands: []string{"*"}}}
+	commits, err := Log(context.Background(), runner, repoPath, "1 days ago", "test@example.com")
+	if err != nil || len(commits) != 1 {
+		t.Fatalf("expected one commit: %v %v", commits, err)
+	}
+	if commits[0].Subject != "first: subject" || commits[0].Author != "Tester" || commits[0].Unix == 0 {
+		t.Fatalf("unexpected commit: %+v", commits[0])
+	}
+	commits, err = Log(context.Background(), runner, repoPath, "", "nobody")
+	if err != nil || len(commits) != 0 {
+		t.Fatalf("expected no commits: %v %v", commits, err)
+	}
+	email, err := ConfigValue(context.Background(), runner, repoPath, "user.email")
+	if err != nil || email != "test@example.com" {
+		t.Fatalf("unexpected email: %q %v", email, err)
+	}
+	if _, err := Log(context.Background(), runner, t.TempDir(), "", ""); err == nil {
+		t.Fatalf("expected log error")
+	}
+}
+
+func TestGrep(t *testing.T) {
+	root := t.TempDir()
+	repoPath := filepath.Join(root, "repo")
+	if err := runGit(root, "init", repoPath); err != nil {
+		t.Fatalf("git init: %v", err)
+	}
+	_ = os.WriteFile(filepath.Join(repoPath, "a:b.txt"), []byte("hello\nfoo:bar Hello\n"), 0o644)
+	_ = os.WriteFile(filepath.Join(repoPath, "c.go"), []byte("hello\n"), 0o644)
+	_ = runGit(repoPath, "add", ".")
+	runner := executil.Runner{Guard: safety.Guard{AllowCommands: []string{"*"}}}
+	matches, err := Grep(context.Background(), runner, repoPath, "hello", GrepOptions{Globs: []string{"*.txt"}, IgnoreCase: true})
+	if err != nil || len(matches) != 2 {
+		t.Fatalf("expected two matches: %v %v", matches, err)
+	}
+	if matches[1].Path != "a:b.txt" || matches[1].Line != 2 || matches[1].Text != "foo:bar Hello" {
+		t.Fatalf("unexpected match: %+v", matches[1])
+	}
+	matches, err = Grep(context.Background(), runner, repoPath, "a.c", GrepOptions{Fixed: true})
+	if err != nil || len(matches) != 0 {
+		t.Fatalf("expected no matches: %v %v", matches, err)
+	}
+	if _, err := Grep(context.Background(), runner, t.TempDir(), "x", GrepOptions{}); err == nil {
+		t.Fatalf("expected grep error")
+	}
+}
+
+func TestUntrackedPathsCleanAndGC(t *testing.T) {
+	root := t.TempDir()
+	repoPath := filepath.Join(root, "repo")
+	if err := runGit(root, "init", repoPath); err != nil {
+		t.Fatalf("git init: %v", err)
+	}
+	_ = os.WriteFile(filepath.Join(repoPath, ".gitignore"), []byte("build/\n*.log\n"), 0o644)
+	_ = os.MkdirAll(filepath.Join(repoPath, "build"), 0o755)
+	_ = os.WriteFile(filepath.Join(repoPath, "build", "out.bin"), []byte("x"), 0o644)
+	_ = os.WriteFile(filepath.Join(repoPath, "d\u00e9bug \"1\".log"), []byte("x"), 0o644)
+	_ = os.MkdirAll(filepath.Join(repoPath, "new dir"), 0o755)
+	_ = os.WriteFile(filepath.Join(repoPath, "new dir", "a\tb.txt"), []byte("x"), 0o644)
+	runner := executil.Runner{Guard: safety.Guard{AllowCommands: []string{"*"}}}
+	untracked, ignored, err := UntrackedPaths(context.Background(), runner, repoPath)
+	if err != nil || !slices.Equal(untracked, []string{".gitignore", "new dir/"}) || !slices.Equal(ignored, []string{"build/", "d\u00e9bug \"1\".log"}) {
+		t.Fatalf("unexpected untracked paths: %q %q %v", untracked, ignored, err)
+	}
+	if err := CleanIgnored(context.Background(), runner, repoPath); err != nil {
+		t.Fatalf("clean: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(repoPath, "build")); !os.IsNotExist(err) {
+		t.Fatalf("expected build removed")
+	}
+	if err := GC(context.Background(), runner, repoPath); err != nil {
+		t.Fatalf("gc: %v", err)
+	}
+	if _, _, err := UntrackedPaths(context.Background(), runner, t.TempDir()); err == nil {
+		t.Fatalf("expected untracked paths error")
+	}
+}
+
+func TestHeadCommitAndAheadBehind(t *testing.T) {
+	root := t.TempDir()
+	bare := filepath.Join(root, "bare.git")
+	if err := runGit(root, "init", "--bare", bare); err != nil {
+		t.Fatalf("git init bare: %v", err)
+	}
+	repoPath := filepath.Join(root, "repo")
+	if err := runGit(root, "clone", bare, repoPath); err != nil {
+		t.Fatalf("git clone: %v", err)
+	}
+	_ = runGit(repoPath, "config", "user.email", "test@example.com")
+	_ = runGit(repoPath, "config", "user.name", "Tester")
+	_ = os.WriteFile(filepath.Join(repoPath, "a.txt"), []byte("a"), 0o644)
+	_ = runGit(repoPath, "add", ".")
+	_ = runGit(repoPath, "commit", "-m", "init")
+	_ = runGit(repoPath, "push", "-u", "origin", "HEAD")
+	_ = os.WriteFile(filepath.Join(repoPath, "a.txt"), []byte("b"), 0o644)
+	_ = runGit(repoPath, "commit", "-am", "second")
+	runner := executil.Runner{Guard: safety.Guard{AllowCommands: []string{"*"}}}
+	head, err := HeadCommit(context.Background(), runner, repoPath)
+	if err != nil || len(head) != 40 {
+		t.Fatalf("unexpected head: %q %v", head, err)
+	}
+	if has, err := HasCommits(context.Background(), runner, repoPath); err != nil || !has {
+		t.Fatalf("expected commits: %v %v", has, err)
+	}
+	empty := filepath.Join(root, "empty")
+	_ = runGit(root, "init", empty)
+	if has, err := HasCommits(context.Background(), runner, empty); err != nil || has {
+		t.Fatalf("expected no commits: %v %v", has, err)
+	}
+	if _, err := HasCommits(context.Background(), runner, t.TempDir()); err == nil {
+		t.Fatalf("expected not a repo error")
+	}
+	ahead, behind, err := AheadBehind(context.Background(), runner, repoPath)
+	if err != nil || ahead != 1 || behind != 0 {
+		t.Fatalf("unexpected ahead/behind: %d %d %v", ahead, behind, err)
+	}
+	if _, _, err := AheadBehind(context.Background(), runner, t.TempDir()); err == nil {
+		t.Fatalf("expected ahead/behind error")
+	}
+	if commit, err := ResolveCommit(context.Background(), runner, repoPath, "HEAD"); err != nil || commit != head {
+		t.Fatalf("unexpected resolved commit: %q %v", commit, err)
+	}
+	if _, err := ResolveCommit(context.Background(), runner, repoPath, "missing"); err == nil {
+		t.Fatalf("expected resolve error")
+	}
+	if _, err := ExactTag(context.Background(), runner, repoPath); err == nil {
+		t.Fatalf("expected no tag on head")
+	}
+	_ = runGit(repoPath, "tag", "v1")
+	if tag, err := ExactTag(context.Background(), runner, repoPath); err != nil || tag != "v1" {
+		t.Fatalf("unexpected tag: %q %v", tag, err)
+	}
+}
+
+func TestCreateBranchAndCommitPaths(t *testing.T) {
+	root := t.TempDir()
+	repoPath := filepath.Join(root, "repo")
+	_ = runGit(root, "init", repoPath)
+	_ = runGit(repoPath, "config", "user.email", "test@example.com")
+	_ = runGit(repoPath, "config", "user.name", "Tester")
+	_ = os.WriteFile(filepath.Join(repoPath, "a.txt"), []byte("a"), 0o644)
+	_ = os.WriteFile(filepath.Join(repoPath, "b.txt"), []byte("b"), 0o644)
+	_ = runGit(repoPath, "add", ".")
+	_ = runGit(repoPath, "commit", "-m", "init")
+	runner := executil.Runner{Guard: safety.Guard{AllowCommands: []string{"*"}}}
+	ctx := context.Background()
+	if err := CreateBranch(ctx, runner, repoPath, "promote"); err != nil {
+		t.Fatalf("create branch: %v", err)
+	}
+	if branch, _ := CurrentBranch(ctx, runner, repoPath); branch != "promote" {
+		t.Fatalf("unexpected branch: %q", branch)
+	}
+	if err := CreateBranch(ctx, runner, repoPath, "promote"); err == nil {
+		t.Fatalf("expected existing branch error")
+	}
+	_ = os.WriteFile(filepath.Join(repoPath, "a.txt"), []byte("a2"), 0o644)
+	_ = os.WriteFile(filepath.Join(repoPath, "b.txt"), []byte("b2"), 0o644)
+	_ = os.WriteFile(filepath.Join(repoPath, "c.txt"), []byte("c"), 0o644)
+	if err := CommitPaths(ctx, runner, repoPath, "promote a", []string{"a.txt", "c.txt"}); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	status, err := StatusPorcelain(ctx, runner, repoPath)
+	if err != nil || strings.TrimSpace(status) != "M b.txt" {
+		t.Fatalf("unexpected status: %q %v", status, err)
+	}
+	if err := CommitPaths(ctx, runner, repoPath, "missing", []string{"missing.txt"}); err == nil {
+		t.Fatalf("expected commit error")
+	}
+	if err := DeleteBranch(ctx, runner, repoPath, "promote"); err == nil {
+		t.Fatalf("expected error deleting the current branch")
+	}
+	_ = runGit(repoPath, "checkout", "-b", "other")
+	if err := DeleteBranch(ctx, runner, repoPath, "promote"); err != nil {
+		t.Fatalf("delete branch: %v", err)
+	}
+	if _, err := ResolveCommit(ctx, runner, repoPath, "refs/heads/promote"); err == nil {
+		t.Fatalf("branch not deleted")
+	}
+}
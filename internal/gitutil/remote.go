@@ -0,0 +1,82 @@
+package gitutil
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/TT-AIXion/github-kanri/internal/executil"
+)
+
+type RemoteURL struct {
+	Host  string
+	Owner string
+	Name  string
+}
+
+func ParseRemoteURL(raw string) (RemoteURL, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return RemoteURL{}, fmt.Errorf("empty remote url")
+	}
+	var host, path string
+	if strings.Contains(raw, "://") {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return RemoteURL{}, err
+		}
+		if u.Scheme == "file" || u.Host == "" {
+			return RemoteURL{}, fmt.Errorf("unsupported remote url: %s", raw)
+		}
+		host = u.Hostname()
+		path = u.Path
+	} else {
+		at := strings.Index(raw, "@")
+		colon := strings.Index(raw, ":")
+		if colon < 0 || strings.HasPrefix(raw, "/") || strings.HasPrefix(raw, ".") {
+			return RemoteURL{}, fmt.Errorf("unsupported remote url: %s", raw)
+		}
+		if at >= 0 && at < colon {
+			host = raw[at+1 : colon]
+		} else {
+			host = raw[:colon]
+		}
+		path = raw[colon+1:]
+	}
+	path = strings.Trim(path, "/")
+	path = strings.TrimSuffix(path, ".git")
+	idx := strings.LastIndex(path, "/")
+	if host == "" || idx <= 0 || idx == len(path)-1 {
+		return RemoteURL{}, fmt.Errorf("unsupported remote url: %s", raw)
+	}
+	return RemoteURL{Host: strings.ToLower(host), Owner: path[:idx], Name: path[idx+1:]}, nil
+}
+
+func (u RemoteURL) Canonical() string {
+	return strings.ToLower(u.Host + "/" + u.Owner + "/" + u.Name)
+}
+
+func (u RemoteURL) WebURL() string {
+	return "https://" + u.Canonical()
+}
+
+func NormalizeRemote(raw string) string {
+	u, err := ParseRemoteURL(raw)
+	if err != nil {
+		return strings.TrimSpace(raw)
+	}
+	return u.Canonical()
+}
+
+func RewriteRemote(raw, from, to string) (string, bool) {
+	if from == "" || !strings.HasPrefix(raw, from) {
+		return raw, false
+	}
+	return to + strings.TrimPrefix(raw, from), true
+}
+
+func SetRemoteURL(ctx context.Context, r executil.Runner, repo string, name string, url string) error {
+	_, err := r.Run(ctx, repo, "git", "remote", "set-url", name, url)
+	return err
+}
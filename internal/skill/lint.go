@@ -0,0 +1,118 @@
+package skill
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type Level string
+
+const (
+	LevelError   Level = "error"
+	LevelWarning Level = "warning"
+)
+
+const (
+	maxNameLength        = 64
+	maxDescriptionLength = 1024
+)
+
+type Issue struct {
+	Source  string `json:"source,omitempty"`
+	Path    string `json:"path"`
+	Skill   string `json:"skill,omitempty"`
+	Level   Level  `json:"level"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+var (
+	namePattern    = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	versionPattern = regexp.MustCompile(`^v?(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+)
+
+func Lint(skills []Skill) []Issue {
+	var issues []Issue
+	byName := map[string][]Skill{}
+	for _, s := range skills {
+		if s.Error == "" && s.Name != "" {
+			byName[s.Name] = append(byName[s.Name], s)
+		}
+	}
+	for _, s := range skills {
+		add := func(level Level, field, msg string) {
+			issues = append(issues, Issue{Source: s.Source, Path: s.Path, Skill: s.Name, Level: level, Field: field, Message: msg})
+		}
+		if s.Error != "" {
+			add(LevelError, "", s.Error)
+			continue
+		}
+		switch {
+		case s.Name == "":
+			add(LevelError, "name", "name is required")
+		case len(s.Name) > maxNameLength || !namePattern.MatchString(s.Name):
+			add(LevelError, "name", fmt.Sprintf("invalid name %q (lowercase letters, digits and hyphens, up to %d characters)", s.Name, maxNameLength))
+		case s.Name != path.Base(s.Path) && s.Path != ".":
+			add(LevelWarning, "name", fmt.Sprintf("name %q does not match directory %q", s.Name, path.Base(s.Path)))
+		}
+		switch {
+		case strings.TrimSpace(s.Description) == "":
+			add(LevelError, "description", "description is required")
+		case len(s.Description) > maxDescriptionLength:
+			add(LevelWarning, "description", fmt.Sprintf("description is longer than %d characters", maxDescriptionLength))
+		}
+		if s.Version != "" && !versionPattern.MatchString(s.Version) {
+			add(LevelError, "version", fmt.Sprintf("invalid version %q (expected semver like 1.2.0)", s.Version))
+		}
+		if others := byName[s.Name]; len(others) > 1 {
+			var where []string
+			for _, o := range others {
+				if o.Dir != s.Dir {
+					where = append(where, location(o))
+				}
+			}
+			add(LevelError, "name", fmt.Sprintf("duplicate name %q (also in %s)", s.Name, strings.Join(where, ", ")))
+		}
+		for _, req := range s.Requires {
+			switch {
+			case req == s.Name:
+				add(LevelError, "requires", "skill requires itself")
+			case len(byName[req]) == 0:
+				add(LevelError, "requires", fmt.Sprintf("required skill %q not found", req))
+			}
+		}
+		for _, link := range s.Links {
+			if _, err := os.Stat(filepath.Join(s.Dir, filepath.FromSlash(link))); err != nil {
+				add(LevelError, "", fmt.Sprintf("referenced file not found: %s", link))
+			}
+		}
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Source != issues[j].Source {
+			return issues[i].Source < issues[j].Source
+		}
+		return issues[i].Path < issues[j].Path
+	})
+	return issues
+}
+
+func HasErrors(issues []Issue) bool {
+	for _, issue := range issues {
+		if issue.Level == LevelError {
+			return true
+		}
+	}
+	return false
+}
+
+func location(s Skill) string {
+	if s.Source == "" {
+		return s.Path
+	}
+	return s.Source + ":" + s.Path
+}
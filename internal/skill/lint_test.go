@@ -0,0 +1,54 @@
+package skill
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	root := t.TempDir()
+	other := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(root, "good", "docs"), 0o755)
+	_ = os.WriteFile(filepath.Join(root, "good", "docs", "ref.md"), []byte("ref"), 0o644)
+	skills := []Skill{
+		{Manifest: Manifest{Name: "good", Description: "ok", Version: "1.0.0", Requires: []string{"dup"}}, Source: "main", Path: "good", Dir: filepath.Join(root, "good"), Links: []string{"docs/ref.md"}},
+		{Manifest: Manifest{Name: "dup", Description: "one"}, Source: "main", Path: "dup", Dir: filepath.Join(root, "dup")},
+		{Manifest: Manifest{Name: "dup", Description: "two"}, Source: "extra", Path: "dup", Dir: filepath.Join(other, "dup")},
+		{Manifest: Manifest{Name: "Bad_Name", Version: "one", Requires: []string{"missing"}}, Source: "main", Path: "bad", Dir: filepath.Join(root, "bad"), Links: []string{"gone.md"}},
+		{Manifest: Manifest{Name: "renamed", Description: strings.Repeat("x", maxDescriptionLength+1), Requires: []string{"renamed"}}, Source: "main", Path: "other", Dir: filepath.Join(root, "other")},
+		{Manifest: Manifest{Description: "no name"}, Source: "main", Path: "anon", Dir: filepath.Join(root, "anon")},
+		{Source: "main", Path: "broken", Dir: filepath.Join(root, "broken"), Error: "missing frontmatter"},
+	}
+	issues := Lint(skills)
+	got := map[string][]string{}
+	for _, issue := range issues {
+		got[issue.Source+":"+issue.Path] = append(got[issue.Source+":"+issue.Path], string(issue.Level)+" "+issue.Field+" "+issue.Message)
+	}
+	if len(got["main:good"]) != 0 {
+		t.Fatalf("unexpected issues for good skill: %v", got["main:good"])
+	}
+	if len(got["main:dup"]) != 1 || !strings.Contains(got["main:dup"][0], "also in extra:dup") || len(got["extra:dup"]) != 1 {
+		t.Fatalf("expected duplicate issues: %v", got)
+	}
+	bad := strings.Join(got["main:bad"], "\n")
+	for _, want := range []string{"invalid name", "description is required", "invalid version", `required skill "missing" not found`, "referenced file not found: gone.md"} {
+		if !strings.Contains(bad, want) {
+			t.Fatalf("missing %q in %s", want, bad)
+		}
+	}
+	renamed := strings.Join(got["main:other"], "\n")
+	if !strings.Contains(renamed, "warning name") || !strings.Contains(renamed, "warning description") || !strings.Contains(renamed, "requires itself") {
+		t.Fatalf("unexpected issues: %s", renamed)
+	}
+	if !strings.Contains(strings.Join(got["main:anon"], ""), "name is required") || !strings.Contains(strings.Join(got["main:broken"], ""), "missing frontmatter") {
+		t.Fatalf("unexpected issues: %v", got)
+	}
+	if !HasErrors(issues) || HasErrors(Lint(skills[1:2])) {
+		t.Fatalf("unexpected HasErrors result")
+	}
+	if issues := Lint([]Skill{{Manifest: Manifest{Name: "solo", Description: "d"}, Path: ".", Dir: root}}); len(issues) != 0 {
+		t.Fatalf("root skill should not warn about its directory: %v", issues)
+	}
+}
@@ -0,0 +1,276 @@
+package skill
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const ManifestName = "SKILL.md"
+
+type Manifest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Version     string   `json:"version,omitempty"`
+	Requires    []string `json:"requires,omitempty"`
+}
+
+type Skill struct {
+	Manifest
+	Source string   `json:"source,omitempty"`
+	Path   string   `json:"path"`
+	Dir    string   `json:"dir"`
+	Links  []string `json:"links,omitempty"`
+	Error  string   `json:"error,omitempty"`
+}
+
+var linkPattern = regexp.MustCompile(`\]\(\s*<?([^)\s>]+)>?(?:\s+"[^"]*")?\s*\)`)
+
+func Parse(data []byte) (Manifest, []string, error) {
+	var m Manifest
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	if !scanner.Scan() || strings.TrimSpace(scanner.Text()) != "---" {
+		return m, nil, errors.New("missing frontmatter")
+	}
+	var front []string
+	closed := false
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "---" {
+			closed = true
+			break
+		}
+		front = append(front, line)
+	}
+	if !closed {
+		return m, nil, errors.New("unterminated frontmatter")
+	}
+	fields, err := parseFields(front)
+	if err != nil {
+		return m, nil, err
+	}
+	for key, v := range fields {
+		switch key {
+		case "name":
+			m.Name = v.scalar()
+		case "description":
+			m.Description = v.scalar()
+		case "version":
+			m.Version = v.scalar()
+		case "requires":
+			m.Requires = v.list()
+		}
+	}
+	var links []string
+	fenced := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			fenced = !fenced
+			continue
+		}
+		if fenced {
+			continue
+		}
+		for _, match := range linkPattern.FindAllStringSubmatch(line, -1) {
+			if target, ok := localLink(match[1]); ok {
+				links = append(links, target)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return m, nil, err
+	}
+	return m, links, nil
+}
+
+type fieldValue struct {
+	text  string
+	items []string
+	isSet bool
+}
+
+func (v fieldValue) scalar() string {
+	if v.isSet {
+		return strings.Join(v.items, ", ")
+	}
+	return v.text
+}
+
+func (v fieldValue) list() []string {
+	if v.isSet {
+		return v.items
+	}
+	if v.text == "" {
+		return nil
+	}
+	return []string{v.text}
+}
+
+func parseFields(lines []string) (map[string]fieldValue, error) {
+	fields := map[string]fieldValue{}
+	key := ""
+	block := ""
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		indented := line != "" && (line[0] == ' ' || line[0] == '\t')
+		if block != "" && (indented || trimmed == "") {
+			v := fields[key]
+			switch {
+			case trimmed == "":
+				if block == "|" {
+					v.text += "\n"
+				}
+			case v.text == "" || strings.HasSuffix(v.text, "\n"):
+				v.text += trimmed
+			case block == "|":
+				v.text += "\n" + trimmed
+			default:
+				v.text += " " + trimmed
+			}
+			fields[key] = v
+			continue
+		}
+		if block != "" {
+			v := fields[key]
+			v.text = strings.TrimSpace(v.text)
+			fields[key] = v
+			block = ""
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "- ") || trimmed == "-" {
+			if key == "" {
+				return nil, fmt.Errorf("frontmatter line %d: list item without field", i+2)
+			}
+			v := fields[key]
+			if v.text != "" {
+				return nil, fmt.Errorf("frontmatter line %d: %s mixes a value and list items", i+2, key)
+			}
+			v.isSet = true
+			if item := unquote(strings.TrimSpace(strings.TrimPrefix(trimmed, "-"))); item != "" {
+				v.items = append(v.items, item)
+			}
+			fields[key] = v
+			continue
+		}
+		if indented && key != "" {
+			continue
+		}
+		name, value, ok := strings.Cut(trimmed, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || indented {
+			return nil, fmt.Errorf("frontmatter line %d: expected \"key: value\"", i+2)
+		}
+		if _, dup := fields[name]; dup {
+			return nil, fmt.Errorf("frontmatter line %d: duplicate field %s", i+2, name)
+		}
+		key = name
+		value = strings.TrimSpace(value)
+		switch {
+		case value == "|" || value == "|-" || value == ">" || value == ">-":
+			block = value[:1]
+			fields[key] = fieldValue{}
+		case strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]"):
+			v := fieldValue{isSet: true}
+			for _, item := range strings.Split(value[1:len(value)-1], ",") {
+				if item = unquote(strings.TrimSpace(item)); item != "" {
+					v.items = append(v.items, item)
+				}
+			}
+			fields[key] = v
+		default:
+			fields[key] = fieldValue{text: unquote(value)}
+		}
+	}
+	if block != "" {
+		v := fields[key]
+		v.text = strings.TrimSpace(v.text)
+		fields[key] = v
+	}
+	return fields, nil
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 {
+		if (value[0] == '"' && value[len(value)-1] == '"') || (value[0] == '\'' && value[len(value)-1] == '\'') {
+			return value[1 : len(value)-1]
+		}
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value
+}
+
+func localLink(target string) (string, bool) {
+	if target == "" || strings.HasPrefix(target, "#") || strings.HasPrefix(target, "/") || strings.Contains(target, ":") {
+		return "", false
+	}
+	if i := strings.IndexAny(target, "#?"); i >= 0 {
+		target = target[:i]
+	}
+	return target, target != ""
+}
+
+func Load(dir string) Skill {
+	s := Skill{Dir: dir}
+	data, err := os.ReadFile(filepath.Join(dir, ManifestName))
+	if err != nil {
+		s.Error = err.Error()
+		return s
+	}
+	m, links, err := Parse(data)
+	if err != nil {
+		s.Error = err.Error()
+		return s
+	}
+	s.Manifest = m
+	s.Links = links
+	return s
+}
+
+func Discover(root string) ([]Skill, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("not a directory: %s", root)
+	}
+	var out []Skill
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if _, err := os.Stat(filepath.Join(path, ManifestName)); err != nil {
+			return nil
+		}
+		s := Load(path)
+		rel, _ := filepath.Rel(root, path)
+		s.Path = filepath.ToSlash(rel)
+		out = append(out, s)
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out, nil
+}
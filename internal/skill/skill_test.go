@@ -0,0 +1,114 @@
+package skill
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeSkill(t *testing.T, dir, manifest string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ManifestName), []byte(manifest), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	data := "\ufeff---\r\n" +
+		"name: pdf-tools # trailing comment\n" +
+		"description: >\n  Work with PDF\n  files.\n\n" +
+		"version: \"1.2.0\"\n" +
+		"requires:\n  - base\n  - 'ocr'\n" +
+		"license: MIT\n" +
+		"metadata:\n  author: someone\n" +
+		"---\n" +
+		"See [reference](docs/ref.md#usage) and [site](https://example.com), [top](#top), [abs](/etc/passwd).\n" +
+		"```\n[ignored](missing.md)\n```\n" +
+		"![diagram](<img/flow.png> \"Flow\")\n"
+	m, links, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := Manifest{Name: "pdf-tools", Description: "Work with PDF files.", Version: "1.2.0", Requires: []string{"base", "ocr"}}
+	if !reflect.DeepEqual(m, want) {
+		t.Fatalf("unexpected manifest: %+v", m)
+	}
+	if !reflect.DeepEqual(links, []string{"docs/ref.md", "img/flow.png"}) {
+		t.Fatalf("unexpected links: %v", links)
+	}
+}
+
+func TestParseValues(t *testing.T) {
+	m, _, err := Parse([]byte("---\nname: a\ndescription: |\n  line one\n  line two\nrequires: [b, \"c\"]\n---\n"))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if m.Description != "line one\nline two" || !reflect.DeepEqual(m.Requires, []string{"b", "c"}) {
+		t.Fatalf("unexpected manifest: %+v", m)
+	}
+	m, _, err = Parse([]byte("---\nname: a\nrequires: b\n---\n"))
+	if err != nil || !reflect.DeepEqual(m.Requires, []string{"b"}) {
+		t.Fatalf("unexpected scalar requires: %+v %v", m, err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := map[string]string{
+		"missing":      "# Title\n",
+		"empty":        "",
+		"unterminated": "---\nname: a\n",
+		"no colon":     "---\nname\n---\n",
+		"duplicate":    "---\nname: a\nname: b\n---\n",
+		"orphan item":  "---\n- a\n---\n",
+		"mixed":        "---\nrequires: a\n  - b\n---\n",
+	}
+	for name, data := range cases {
+		if _, _, err := Parse([]byte(data)); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	root := t.TempDir()
+	writeSkill(t, filepath.Join(root, "b"), "---\nname: b\ndescription: B\n---\n")
+	writeSkill(t, filepath.Join(root, "group", "a"), "---\nname: a\ndescription: A\n---\n")
+	writeSkill(t, filepath.Join(root, "group", "a", "nested"), "---\nname: nested\n---\n")
+	writeSkill(t, filepath.Join(root, ".git", "x"), "---\nname: x\n---\n")
+	writeSkill(t, filepath.Join(root, "broken"), "no frontmatter")
+	_ = os.MkdirAll(filepath.Join(root, "plain"), 0o755)
+	skills, err := Discover(root)
+	if err != nil {
+		t.Fatalf("discover: %v", err)
+	}
+	var paths []string
+	for _, s := range skills {
+		paths = append(paths, s.Path)
+	}
+	if !reflect.DeepEqual(paths, []string{"b", "broken", "group/a"}) {
+		t.Fatalf("unexpected skills: %v", paths)
+	}
+	if skills[0].Name != "b" || skills[0].Dir != filepath.Join(root, "b") || skills[1].Error == "" {
+		t.Fatalf("unexpected skill data: %+v", skills)
+	}
+
+	single := filepath.Join(t.TempDir(), "solo")
+	writeSkill(t, single, "---\nname: solo\ndescription: S\n---\n")
+	skills, err = Discover(single)
+	if err != nil || len(skills) != 1 || skills[0].Path != "." {
+		t.Fatalf("unexpected root skill: %+v %v", skills, err)
+	}
+	if _, err := Discover(filepath.Join(root, "missing")); err == nil {
+		t.Fatalf("expected missing root error")
+	}
+	if _, err := Discover(filepath.Join(root, "b", ManifestName)); err == nil {
+		t.Fatalf("expected file root error")
+	}
+	if s := Load(filepath.Join(root, "plain")); s.Error == "" {
+		t.Fatalf("expected load error")
+	}
+}
@@ -0,0 +1,178 @@
+//go:build linux
+
+package watch
+
+import (
+	"encoding/binary"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+)
+
+const inotifyMask = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MODIFY | syscall.IN_CLOSE_WRITE |
+	syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_ATTRIB | syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF
+
+type inotify struct {
+	file   *os.File
+	fd     int
+	roots  []string
+	mu     sync.Mutex
+	dirs   map[int]string
+	events chan string
+	errors chan error
+	done   chan struct{}
+	once   sync.Once
+}
+
+func newNative(roots []string) (Watcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	w := &inotify{
+		file:   os.NewFile(uintptr(fd), "inotify"),
+		fd:     fd,
+		roots:  roots,
+		dirs:   map[int]string{},
+		events: make(chan string, 256),
+		errors: make(chan error, 1),
+		done:   make(chan struct{}),
+	}
+	for _, root := range roots {
+		if err := w.addTree(root); err != nil {
+			_ = w.file.Close()
+			return nil, err
+		}
+	}
+	go w.run()
+	return w, nil
+}
+
+func (w *inotify) Events() <-chan string { return w.events }
+
+func (w *inotify) Errors() <-chan error { return w.errors }
+
+func (w *inotify) Close() error {
+	var err error
+	w.once.Do(func() {
+		close(w.done)
+		err = w.file.Close()
+	})
+	return err
+}
+
+func (w *inotify) addTree(root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path != root && errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && skipName(d.Name()) {
+			return filepath.SkipDir
+		}
+		wd, err := syscall.InotifyAddWatch(w.fd, path, inotifyMask)
+		if err != nil {
+			if path != root && errors.Is(err, syscall.ENOENT) {
+				return nil
+			}
+			return &os.PathError{Op: "inotify_add_watch", Path: path, Err: err}
+		}
+		w.mu.Lock()
+		w.dirs[wd] = path
+		w.mu.Unlock()
+		return nil
+	})
+}
+
+func (w *inotify) run() {
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			select {
+			case <-w.done:
+			default:
+				w.sendError(err)
+			}
+			close(w.events)
+			return
+		}
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			wd := int(int32(binary.NativeEndian.Uint32(buf[offset:])))
+			mask := binary.NativeEndian.Uint32(buf[offset+4:])
+			nameLen := int(binary.NativeEndian.Uint32(buf[offset+12:]))
+			start := offset + syscall.SizeofInotifyEvent
+			name := string(trimNull(buf[start : start+nameLen]))
+			offset = start + nameLen
+			if !w.handle(wd, mask, name) {
+				return
+			}
+		}
+	}
+}
+
+func (w *inotify) handle(wd int, mask uint32, name string) bool {
+	if mask&syscall.IN_Q_OVERFLOW != 0 {
+		for _, root := range w.roots {
+			if !w.send(root) {
+				return false
+			}
+		}
+		return true
+	}
+	w.mu.Lock()
+	dir, ok := w.dirs[wd]
+	if mask&syscall.IN_IGNORED != 0 {
+		delete(w.dirs, wd)
+	}
+	w.mu.Unlock()
+	if !ok || skipName(name) {
+		return true
+	}
+	path := dir
+	if name != "" {
+		path = filepath.Join(dir, name)
+	}
+	if mask&syscall.IN_ISDIR != 0 && mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+		if err := w.addTree(path); err != nil {
+			w.sendError(err)
+		}
+	}
+	if mask&syscall.IN_IGNORED != 0 {
+		return true
+	}
+	return w.send(path)
+}
+
+func (w *inotify) send(path string) bool {
+	select {
+	case w.events <- path:
+		return true
+	case <-w.done:
+		return false
+	}
+}
+
+func (w *inotify) sendError(err error) {
+	select {
+	case w.errors <- err:
+	default:
+	}
+}
+
+func trimNull(b []byte) []byte {
+	for i, c := range b {
+		if c == 0 {
+			return b[:i]
+		}
+	}
+	return b
+}
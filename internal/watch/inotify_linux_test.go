@@ -0,0 +1,70 @@
+//go:build linux
+
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestInotify(t *testing.T) {
+	root := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(root, ".git"), 0o755)
+	_ = os.MkdirAll(filepath.Join(root, "skills"), 0o755)
+	w, err := New([]string{root, filepath.Join(root, "skills")}, time.Second, false)
+	if err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	defer w.Close()
+	if _, ok := w.(*inotify); !ok {
+		t.Fatalf("expected inotify watcher, got %T", w)
+	}
+	_ = os.WriteFile(filepath.Join(root, ".git", "HEAD"), []byte("x"), 0o644)
+	_ = os.WriteFile(filepath.Join(root, "skills", "a.md"), []byte("a"), 0o644)
+	collect(t, w, filepath.Join(root, "skills", "a.md"))
+
+	nested := filepath.Join(root, "skills", "new")
+	_ = os.MkdirAll(nested, 0o755)
+	collect(t, w, nested)
+	time.Sleep(50 * time.Millisecond)
+	_ = os.WriteFile(filepath.Join(nested, "b.md"), []byte("b"), 0o644)
+	collect(t, w, filepath.Join(nested, "b.md"))
+
+	_ = os.Rename(filepath.Join(root, "skills", "a.md"), filepath.Join(root, "skills", "c.md"))
+	collect(t, w, filepath.Join(root, "skills", "c.md"))
+	_ = os.RemoveAll(nested)
+	collect(t, w, nested)
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	_ = w.Close()
+	if _, err := New([]string{filepath.Join(root, "missing")}, time.Second, false); err == nil {
+		t.Fatalf("expected missing root error")
+	}
+}
+
+func TestInotifyHandle(t *testing.T) {
+	w := &inotify{roots: []string{"/src"}, dirs: map[int]string{1: "/src"}, events: make(chan string, 4), errors: make(chan error, 1), done: make(chan struct{})}
+	if !w.handle(0, syscall.IN_Q_OVERFLOW, "") || <-w.events != "/src" {
+		t.Fatalf("expected overflow to report the root")
+	}
+	if !w.handle(1, syscall.IN_IGNORED, "") || len(w.dirs) != 0 || len(w.events) != 0 {
+		t.Fatalf("expected ignored watch to be dropped")
+	}
+	if !w.handle(7, syscall.IN_MODIFY, "x") || len(w.events) != 0 {
+		t.Fatalf("expected unknown watch to be skipped")
+	}
+	close(w.done)
+	w.dirs[1] = "/src"
+	w.events = make(chan string)
+	if w.handle(1, syscall.IN_MODIFY, "x") {
+		t.Fatalf("expected closed watcher to stop")
+	}
+	if string(trimNull([]byte("ab\x00\x00"))) != "ab" || string(trimNull([]byte("ab"))) != "ab" {
+		t.Fatalf("unexpected trim")
+	}
+}
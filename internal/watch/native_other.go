@@ -0,0 +1,7 @@
+//go:build !linux
+
+package watch
+
+func newNative([]string) (Watcher, error) {
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,123 @@
+package watch
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type stamp struct {
+	size    int64
+	modTime time.Time
+	mode    fs.FileMode
+}
+
+type poller struct {
+	roots    []string
+	interval time.Duration
+	state    map[string]stamp
+	events   chan string
+	errors   chan error
+	done     chan struct{}
+	once     sync.Once
+}
+
+func NewPoller(roots []string, interval time.Duration) (Watcher, error) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	p := &poller{
+		roots:    outerRoots(roots),
+		interval: interval,
+		events:   make(chan string, 256),
+		errors:   make(chan error, 1),
+		done:     make(chan struct{}),
+	}
+	state, err := p.scan()
+	if err != nil {
+		return nil, err
+	}
+	p.state = state
+	go p.run()
+	return p, nil
+}
+
+func (p *poller) Events() <-chan string { return p.events }
+
+func (p *poller) Errors() <-chan error { return p.errors }
+
+func (p *poller) Close() error {
+	p.once.Do(func() { close(p.done) })
+	return nil
+}
+
+func (p *poller) run() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+		}
+		next, err := p.scan()
+		if err != nil {
+			select {
+			case p.errors <- err:
+			default:
+			}
+			continue
+		}
+		var changed []string
+		for path, s := range next {
+			if old, ok := p.state[path]; !ok || old != s {
+				changed = append(changed, path)
+			}
+		}
+		for path := range p.state {
+			if _, ok := next[path]; !ok {
+				changed = append(changed, path)
+			}
+		}
+		p.state = next
+		for _, path := range changed {
+			select {
+			case p.events <- path:
+			case <-p.done:
+				return
+			}
+		}
+	}
+}
+
+func (p *poller) scan() (map[string]stamp, error) {
+	state := map[string]stamp{}
+	for _, root := range p.roots {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if path != root && d.IsDir() && skipName(d.Name()) {
+				return filepath.SkipDir
+			}
+			if d.IsDir() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			state[path] = stamp{size: info.Size(), modTime: info.ModTime(), mode: info.Mode()}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return state, nil
+}
@@ -0,0 +1,104 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const DefaultInterval = 5 * time.Second
+
+var ErrUnsupported = errors.New("filesystem notifications are not supported on this platform")
+
+type Watcher interface {
+	Events() <-chan string
+	Errors() <-chan error
+	Close() error
+}
+
+func New(roots []string, interval time.Duration, poll bool) (Watcher, error) {
+	roots = outerRoots(roots)
+	if !poll {
+		w, err := newNative(roots)
+		if err == nil {
+			return w, nil
+		}
+		if !errors.Is(err, ErrUnsupported) {
+			return nil, err
+		}
+	}
+	return NewPoller(roots, interval)
+}
+
+func Debounce(ctx context.Context, in <-chan string, wait time.Duration) <-chan []string {
+	out := make(chan []string)
+	go func() {
+		defer close(out)
+		pending := map[string]struct{}{}
+		var timer *time.Timer
+		var fire <-chan time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case path, ok := <-in:
+				if !ok {
+					return
+				}
+				pending[path] = struct{}{}
+				if timer == nil {
+					timer = time.NewTimer(wait)
+				} else {
+					if !timer.Stop() {
+						select {
+						case <-timer.C:
+						default:
+						}
+					}
+					timer.Reset(wait)
+				}
+				fire = timer.C
+			case <-fire:
+				fire = nil
+				batch := make([]string, 0, len(pending))
+				for path := range pending {
+					batch = append(batch, path)
+				}
+				sort.Strings(batch)
+				pending = map[string]struct{}{}
+				select {
+				case out <- batch:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
+
+func skipName(name string) bool {
+	return name == ".git"
+}
+
+func outerRoots(roots []string) []string {
+	var cleaned []string
+	for _, r := range roots {
+		cleaned = append(cleaned, filepath.Clean(r))
+	}
+	sort.Strings(cleaned)
+	var out []string
+	for _, r := range cleaned {
+		if len(out) > 0 {
+			last := out[len(out)-1]
+			if r == last || strings.HasPrefix(r, last+string(filepath.Separator)) {
+				continue
+			}
+		}
+		out = append(out, r)
+	}
+	return out
+}
@@ -0,0 +1,113 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func collect(t *testing.T, w Watcher, want string) {
+	t.Helper()
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case path := <-w.Events():
+			if path == want {
+				return
+			}
+		case err := <-w.Errors():
+			t.Fatalf("watch error: %v", err)
+		case <-deadline:
+			t.Fatalf("timed out waiting for %s", want)
+		}
+	}
+}
+
+func TestDebounce(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	in := make(chan string)
+	out := Debounce(ctx, in, 50*time.Millisecond)
+	in <- "b"
+	in <- "a"
+	in <- "b"
+	batch := <-out
+	if !reflect.DeepEqual(batch, []string{"a", "b"}) {
+		t.Fatalf("unexpected batch: %v", batch)
+	}
+	in <- "c"
+	if batch := <-out; !reflect.DeepEqual(batch, []string{"c"}) {
+		t.Fatalf("unexpected second batch: %v", batch)
+	}
+	close(in)
+	if _, ok := <-out; ok {
+		t.Fatalf("expected closed output")
+	}
+
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	out = Debounce(ctx2, make(chan string), time.Millisecond)
+	cancel2()
+	if _, ok := <-out; ok {
+		t.Fatalf("expected closed output after cancel")
+	}
+}
+
+func TestOuterRoots(t *testing.T) {
+	got := outerRoots([]string{"/a/b", "/a", "/ab", "/a/", "/c/d"})
+	if !reflect.DeepEqual(got, []string{"/a", "/ab", "/c/d"}) {
+		t.Fatalf("unexpected roots: %v", got)
+	}
+}
+
+func TestPoller(t *testing.T) {
+	root := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(root, ".git"), 0o755)
+	_ = os.WriteFile(filepath.Join(root, "a.txt"), []byte("a"), 0o644)
+	w, err := New([]string{root}, 10*time.Millisecond, true)
+	if err != nil {
+		t.Fatalf("poller: %v", err)
+	}
+	defer w.Close()
+	if _, ok := w.(*poller); !ok {
+		t.Fatalf("expected poller, got %T", w)
+	}
+	_ = os.WriteFile(filepath.Join(root, ".git", "HEAD"), []byte("x"), 0o644)
+	_ = os.WriteFile(filepath.Join(root, "b.txt"), []byte("b"), 0o644)
+	collect(t, w, filepath.Join(root, "b.txt"))
+	_ = os.Remove(filepath.Join(root, "a.txt"))
+	collect(t, w, filepath.Join(root, "a.txt"))
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	if _, err := NewPoller([]string{filepath.Join(root, "missing")}, 0); err == nil {
+		t.Fatalf("expected missing root error")
+	}
+	p, err := NewPoller([]string{root}, 0)
+	if err != nil || p.(*poller).interval != DefaultInterval {
+		t.Fatalf("expected default interval: %v", err)
+	}
+	_ = p.Close()
+}
+
+func TestPollerScanError(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "src")
+	_ = os.MkdirAll(root, 0o755)
+	w, err := NewPoller([]string{root}, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("poller: %v", err)
+	}
+	defer w.Close()
+	_ = os.RemoveAll(root)
+	select {
+	case err := <-w.Errors():
+		if err == nil {
+			t.Fatalf("expected error value")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected scan error")
+	}
+}